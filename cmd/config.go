@@ -0,0 +1,169 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// DefaultConfigFiles 是未指定 --config 时在工作目录中依次查找的配置文件名
+var DefaultConfigFiles = []string{"txt2geo.yaml", "txt2geo.yml"}
+
+var configFile string
+
+// configEntry 是配置文件中的一个键及其取值（列表按元素展开）。
+type configEntry struct {
+	key    string
+	values []string
+	line   int
+}
+
+// findConfigFile 返回要加载的配置文件路径：显式指定的路径必须存在；未指定时查找工作目录中的默认文件，找不到返回空。
+func findConfigFile(explicit string) (string, error) {
+	if explicit != "" {
+		if _, err := os.Stat(explicit); err != nil {
+			return "", fmt.Errorf("无法读取配置文件 %s: %w", explicit, err)
+		}
+		return explicit, nil
+	}
+	for _, name := range DefaultConfigFiles {
+		if info, err := os.Stat(name); err == nil && !info.IsDir() {
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+// applyConfigFile 将配置文件中的值作为 cmd 的参数默认值：命令行中显式给出的参数优先，
+// 只有与 cmd 参数同名的键生效，返回被忽略的键。
+func applyConfigFile(cmd *cobra.Command, path string) (ignored []string, err error) {
+	entries, err := parseConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	flags := cmd.Flags()
+	for _, e := range entries {
+		flag := flags.Lookup(e.key)
+		if flag == nil || e.key == "config" {
+			ignored = append(ignored, e.key)
+			continue
+		}
+		if flag.Changed {
+			continue // 命令行参数优先
+		}
+		for _, v := range e.values {
+			if err := flags.Set(e.key, v); err != nil {
+				return nil, fmt.Errorf("%s:%d: 参数 %s 的值 %q 无效: %w", path, e.line, e.key, v, err)
+			}
+		}
+	}
+	return ignored, nil
+}
+
+// parseConfigFile 解析扁平的 YAML 子集：每行一个 "键: 值"，支持 # 注释、单/双引号字符串、
+// 行内列表 "[a, b]" 以及以 "- 值" 逐行书写的列表。键名即命令行参数名（不含 "--"）。
+func parseConfigFile(path string) ([]configEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取配置文件 %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var entries []configEntry
+	var current *configEntry // 等待 "- 值" 列表项的键
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if lineNo == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" {
+			continue
+		}
+
+		if item, ok := strings.CutPrefix(line, "- "); ok || line == "-" {
+			if current == nil {
+				return nil, fmt.Errorf("%s:%d: 列表项缺少所属的键", path, lineNo)
+			}
+			v, err := configScalar(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+			}
+			current.values = append(current.values, v)
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: 无法识别的配置行 %q，应为 \"键: 值\"", path, lineNo, line)
+		}
+		entries = append(entries, configEntry{key: strings.TrimPrefix(key, "--"), line: lineNo})
+		current = &entries[len(entries)-1]
+
+		raw = strings.TrimSpace(raw)
+		if raw == "" || strings.HasPrefix(raw, "#") {
+			continue // 值在后续的 "- 值" 行中
+		}
+		values, err := configValues(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		current.values = values
+		current = nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("无法读取配置文件 %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// configValues 解析键后的取值：行内列表展开为多个值，其余为单个标量。
+func configValues(raw string) ([]string, error) {
+	if !strings.HasPrefix(raw, "[") {
+		v, err := configScalar(raw)
+		if err != nil {
+			return nil, err
+		}
+		return []string{v}, nil
+	}
+	end := strings.LastIndex(raw, "]")
+	if end < 0 {
+		return nil, errors.New("行内列表缺少 ]")
+	}
+	var values []string
+	for _, item := range strings.Split(raw[1:end], ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		v, err := configScalar(item)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// configScalar 解析单个标量：去除引号，未加引号时去除行尾 " #" 注释。
+func configScalar(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw != "" && (raw[0] == '"' || raw[0] == '\'') {
+		end := strings.IndexByte(raw[1:], raw[0])
+		if end < 0 {
+			return "", fmt.Errorf("字符串 %s 缺少结束引号", raw)
+		}
+		return raw[1 : end+1], nil
+	}
+	if i := strings.Index(raw, " #"); i >= 0 {
+		raw = raw[:i]
+	}
+	return strings.TrimSpace(raw), nil
+}
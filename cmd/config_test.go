@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// newConfigTestCmd 返回带有几个与 export 同名参数的命令，用于验证配置文件与命令行的优先级。
+func newConfigTestCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().String("format", "FGB", "")
+	cmd.Flags().String("output", "", "")
+	cmd.Flags().Int("depth", -1, "")
+	cmd.Flags().Bool("merge", false, "")
+	cmd.Flags().String("name", "", "")
+	cmd.Flags().StringArray("exclude", nil, "")
+	cmd.Flags().String("config", "", "")
+	return cmd
+}
+
+func TestApplyConfigFilePrecedence(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "txt2geo.yaml", []byte(`# 导出默认值
+format: GPKG
+output: "D:\data\out"   # 引号内原样保留
+depth: 2
+merge: true
+name: '{name}_{date}'
+exclude: [backup, "*_old.txt"]
+unknown-key: 1
+config: other.yaml
+`))
+	cmd := newConfigTestCmd()
+	if err := cmd.ParseFlags([]string{"--format", "SHP", "--exclude", "tmp"}); err != nil {
+		t.Fatal(err)
+	}
+	ignored, err := applyConfigFile(cmd, path)
+	if err != nil {
+		t.Fatalf("applyConfigFile: %v", err)
+	}
+
+	flags := cmd.Flags()
+	str := func(name string) string {
+		v, err := flags.GetString(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	if got := str("format"); got != "SHP" {
+		t.Errorf("format = %s, want SHP（命令行优先）", got)
+	}
+	if got := str("output"); got != `D:\data\out` {
+		t.Errorf("output = %s, want D:\\data\\out", got)
+	}
+	if got := str("name"); got != "{name}_{date}" {
+		t.Errorf("name = %s, want {name}_{date}", got)
+	}
+	if got, _ := flags.GetInt("depth"); got != 2 {
+		t.Errorf("depth = %d, want 2", got)
+	}
+	if got, _ := flags.GetBool("merge"); !got {
+		t.Error("merge = false, want true")
+	}
+	if got, _ := flags.GetStringArray("exclude"); !slices.Equal(got, []string{"tmp"}) {
+		t.Errorf("exclude = %v, want [tmp]（命令行优先，不与配置合并）", got)
+	}
+	if want := []string{"unknown-key", "config"}; !slices.Equal(ignored, want) {
+		t.Errorf("ignored = %v, want %v", ignored, want)
+	}
+}
+
+func TestApplyConfigFileList(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "txt2geo.yaml", []byte("exclude:\n  - backup\n  - '*_old.txt'\ndepth: 1\n"))
+	cmd := newConfigTestCmd()
+	if _, err := applyConfigFile(cmd, path); err != nil {
+		t.Fatalf("applyConfigFile: %v", err)
+	}
+	if got, _ := cmd.Flags().GetStringArray("exclude"); !slices.Equal(got, []string{"backup", "*_old.txt"}) {
+		t.Errorf("exclude = %v, want [backup *_old.txt]", got)
+	}
+}
+
+func TestApplyConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"无效的值", "depth: deep\n", "txt2geo.yaml:1: 参数 depth 的值 \"deep\" 无效"},
+		{"缺少冒号", "format GPKG\n", "无法识别的配置行"},
+		{"列表项缺少键", "- a\n", "列表项缺少所属的键"},
+		{"缺少结束引号", "name: \"abc\n", "缺少结束引号"},
+		{"行内列表缺少 ]", "exclude: [a, b\n", "缺少 ]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "txt2geo.yaml", []byte(tt.content))
+			_, err := applyConfigFile(newConfigTestCmd(), path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFindConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if got, err := findConfigFile(""); err != nil || got != "" {
+		t.Errorf("无配置文件时 findConfigFile = %q, %v, want 空", got, err)
+	}
+	if _, err := findConfigFile(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("显式指定的配置文件不存在时应返回错误")
+	}
+
+	writeFile(t, dir, "txt2geo.yml", []byte("depth: 1\n"))
+	if got, _ := findConfigFile(""); got != "txt2geo.yml" {
+		t.Errorf("findConfigFile = %q, want txt2geo.yml", got)
+	}
+	writeFile(t, dir, "txt2geo.yaml", []byte("depth: 1\n"))
+	if got, _ := findConfigFile(""); got != "txt2geo.yaml" {
+		t.Errorf("findConfigFile = %q, want txt2geo.yaml（优先于 .yml）", got)
+	}
+	explicit := writeFile(t, dir, "custom.yaml", []byte("depth: 1\n"))
+	if got, _ := findConfigFile(explicit); got != explicit {
+		t.Errorf("findConfigFile = %q, want %q", got, explicit)
+	}
+}
+
+func TestConfigFileAppliesToSubcommand(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	sample := writeFile(t, dir, "a.txt", []byte(sampleContent))
+	writeFile(t, dir, "txt2geo.yaml", []byte("json: true\nlog-level: debug\n"))
+	resetFlags(t, rootCmd)
+	resetFlags(t, inspectCmd)
+	t.Cleanup(func() { rootCmd.SetArgs(nil) })
+
+	// 配置文件中的持久参数与子命令参数均生效，命令行显式给出的 --log-level 优先
+	rootCmd.SetArgs([]string{"inspect", sample, "--log-level", "warn"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !inspectJSON {
+		t.Error("配置文件中的 json: true 未生效")
+	}
+	if logLevel != "warn" {
+		t.Errorf("logLevel = %s, want warn（命令行优先）", logLevel)
+	}
+}
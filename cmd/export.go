@@ -5,6 +5,8 @@ package cmd
 
 import (
 	"fmt"
+	"time"
+	"txt2geo/internal/domain"
 	"txt2geo/internal/export"
 	"txt2geo/pkg/logger"
 
@@ -13,15 +15,63 @@ import (
 
 // 命令行参数变量
 var (
-	exportInputPaths   []string
-	exportDepth        int
-	exportFormatKey    string
-	exportOutputDir    string
-	exportMerge        bool
-	exportNameTemplate string
-	exportDryRun       bool
-	exportOverwrite    bool
-	exportForceRefresh bool
+	exportInputPaths       []string
+	exportInputList        string
+	exportDepth            int
+	exportFormatKey        string
+	exportOutputEncoding   string
+	exportOutputDir        string
+	exportMerge            bool
+	exportReproject        bool
+	exportNameTemplate     string
+	exportSeqStart         int
+	exportDryRun           bool
+	exportOverwrite        bool
+	exportAppend           bool
+	exportForceRefresh     bool
+	exportRecoverTruncated bool
+	exportSkipInvalid      bool
+	exportSummaryOnly      bool
+	exportResultsNDJSON    string
+	exportConcurrency      int
+	exportWorkers          int
+	exportTimeout          time.Duration
+	exportQGISPath         string
+	exportFields           []string
+	exportKeepUnmapped     bool
+	exportFilter           string
+	exportCreationOptions  []string
+	exportTimeZone         string
+	exportIncludeGenerated bool
+	exportMeasureColumn    int
+	exportEncoding         string
+	exportRejectsNDJSON    string
+	exportDumpPayload      string
+	exportReport           string
+	exportSlowestFiles     int
+	exportResume           bool
+	exportHistoryFile      string
+	exportNoHistory        bool
+	exportHistoryTTL       time.Duration
+	exportHistoryJSON      bool
+	exportExcludes         []string
+	exportMinSize          int64
+	exportMaxSize          int64
+	exportModifiedAfter    string
+	exportModifiedBefore   string
+	exportAttrSection      string
+	exportGeomSection      string
+	exportDelimiter        string
+	exportCheckPointCount  bool
+	exportCheckGeometry    bool
+	exportSkipExtentCheck  bool
+	exportComputeMetrics   bool
+	exportSimplify         float64
+	exportFixWinding       bool
+	exportEmitBBox         bool
+	exportLatOrigin        float64
+	exportScaleFactor      float64
+	exportFalseNorthing    float64
 )
 
 // exportCmd represents the export command
@@ -33,10 +83,18 @@ var exportCmd = &cobra.Command{
 名称模板占位符:
   * {name}: 基础名称 (分散模式下为源文件名，合并模式下为 "merged_output" 或外部传入)。
   * {index[:width]}: 当前处理文件的序号，支持用 :width 指定补零宽度 (如 {index:03})。
+  * {seq[:width]}: 延续编号，从 --seq-start 开始 (默认 1)，可跨多次运行连续编号，补零规则同 {index}。
   * {count}: 本次任务处理的总文件数。
-  * {date[:layout]}: 当前日期，支持用 :layout 指定 Go 时间格式 (默认 20060102)。
+  * {date[:layout[:tz]]}: 当前日期，支持用 :layout 指定 Go 时间格式 (默认 20060102)，
+    可再用 :tz 指定时区 (如 {date:20060102:UTC})，未指定时使用 --tz 或本地时区。
   * {uuid}: 一个随机的 UUID v4 字符串。
   * {rand[:len]}: 一个随机的字母数字字符串，支持用 :len 指定长度 (默认 8 位)。
+  * {parent}: 源文件所在目录名，可避免不同子目录中的同名文件冲突 (如 {parent}_{name})；
+    合并模式下为各源文件的共同上级目录名，没有时为空。
+  * {epsg}: 源文件坐标系的 EPSG 代码，自定义中央经线时为 custom；合并模式下坐标系不一致时为 mixed。
+  * {crs}: 坐标系简称，如 EPSG4526，自定义中央经线时为投影名称；不一致时为 mixed。
+  * {hash[:len]}: 源文件内容的 SHA-256 哈希 (默认 64 位)，支持用 :len 截取前若干位 (如 {hash:8})；
+    合并模式下为各源文件哈希排序拼接后的哈希。
 
 所有占位符都支持大小写修饰符，例如 {name:upper} 会将名称转换为大写。
 
@@ -52,16 +110,72 @@ var exportCmd = &cobra.Command{
   geoflow export -i a.txt -i b.txt -o out --merge --format GPKG --name "blocks_{date:2006-01-02}" --dry-run
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// 仅显式指定的投影参数覆盖标准值，未指定时保持可输出 EPSG 码的标准投影
+		var projection domain.ProjectionOptions
+		if cmd.Flags().Changed("lat-origin") {
+			projection.LatitudeOfOrigin = &exportLatOrigin
+		}
+		if cmd.Flags().Changed("scale-factor") {
+			projection.ScaleFactor = &exportScaleFactor
+		}
+		projection.FalseNorthing = exportFalseNorthing
+
 		exporter, err := export.NewExporter(export.ExportConfig{
-			InputPaths:   exportInputPaths,
-			Depth:        exportDepth,
-			FormatKey:    exportFormatKey,
-			OutputDir:    exportOutputDir,
-			Merge:        exportMerge,
-			NameTemplate: exportNameTemplate,
-			DryRun:       exportDryRun,
-			Overwrite:    exportOverwrite,
-			ForceRefresh: exportForceRefresh,
+			InputPaths:        exportInputPaths,
+			InputList:         exportInputList,
+			Depth:             exportDepth,
+			FormatKey:         exportFormatKey,
+			OutputEncoding:    exportOutputEncoding,
+			OutputDir:         exportOutputDir,
+			Merge:             exportMerge,
+			Reproject:         exportReproject,
+			NameTemplate:      exportNameTemplate,
+			SeqStart:          exportSeqStart,
+			DryRun:            exportDryRun,
+			Overwrite:         exportOverwrite,
+			Append:            exportAppend,
+			ForceRefresh:      exportForceRefresh,
+			RecoverTruncated:  exportRecoverTruncated,
+			SkipInvalid:       exportSkipInvalid,
+			SummaryOnly:       exportSummaryOnly,
+			ResultsNDJSONPath: exportResultsNDJSON,
+			ExportConcurrency: exportConcurrency,
+			Concurrency:       exportWorkers,
+			ExecTimeout:       exportTimeout,
+			QGISPath:          exportQGISPath,
+			TimeZone:          exportTimeZone,
+			IncludeGenerated:  exportIncludeGenerated,
+			MeasureColumn:     exportMeasureColumn,
+			Encoding:          exportEncoding,
+			RejectsNDJSONPath: exportRejectsNDJSON,
+			DumpPayload:       exportDumpPayload,
+			ReportPath:        exportReport,
+			SlowestFiles:      exportSlowestFiles,
+			Resume:            exportResume,
+			HistoryFileName:   exportHistoryFile,
+			NoHistory:         exportNoHistory,
+			HistoryTTL:        exportHistoryTTL,
+			HistoryJSON:       exportHistoryJSON,
+			Excludes:          exportExcludes,
+			MinSize:           exportMinSize,
+			MaxSize:           exportMaxSize,
+			ModifiedAfter:     exportModifiedAfter,
+			ModifiedBefore:    exportModifiedBefore,
+			AttrSection:       exportAttrSection,
+			GeomSection:       exportGeomSection,
+			Delimiter:         exportDelimiter,
+			CheckPointCount:   exportCheckPointCount,
+			CheckGeometry:     exportCheckGeometry,
+			SkipExtentCheck:   exportSkipExtentCheck,
+			ComputeMetrics:    exportComputeMetrics,
+			SimplifyTolerance: exportSimplify,
+			FixWinding:        exportFixWinding,
+			EmitBBox:          exportEmitBBox,
+			Projection:        projection,
+			Fields:            exportFields,
+			KeepUnmapped:      exportKeepUnmapped,
+			Filter:            exportFilter,
+			CreationArgs:      exportCreationOptions,
 		})
 		if err != nil {
 			logger.Log().Error("创建导出器失败", "error", err)
@@ -75,16 +189,79 @@ var exportCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(exportCmd)
 
-	exportCmd.Flags().StringArrayVarP(&exportInputPaths, "input", "i", nil, "输入文件或目录，可重复指定")
+	exportCmd.Flags().StringArrayVarP(&exportInputPaths, "input", "i", nil, "输入文件、目录或通配符（如 data/*.txt），可重复指定")
+	exportCmd.Flags().StringVar(&exportInputList, "input-list", "", "输入清单文件，每行一个文件、目录或通配符（# 开头为注释），相对路径以清单所在目录为基准")
 	exportCmd.Flags().IntVar(&exportDepth, "depth", -1, "递归深度：0=仅当前目录，正数=最大层级，-1=无限")
-	exportCmd.Flags().StringVar(&exportFormatKey, "format", "FGB", "输出格式：SHP|FGB|GPKG|GDB，默认 FGB")
+	exportCmd.Flags().StringVar(&exportFormatKey, "format", "FGB", "输出格式：SHP|FGB|GPKG|GDB|SPATIALITE|TAB|KML|GEOJSON|CSV，默认 FGB")
+	exportCmd.Flags().StringVar(&exportOutputEncoding, "output-encoding", "UTF-8", "属性文本编码：UTF-8|GBK|GB18030，SHP 以此写入 DBF 代码页")
 	exportCmd.Flags().StringVarP(&exportOutputDir, "output", "o", "", "输出目录")
 	exportCmd.Flags().BoolVar(&exportMerge, "merge", false, "合并导出")
-	exportCmd.Flags().StringVar(&exportNameTemplate, "name", "", "文件名模板，支持占位符 {name}{index}{date}{uuid}{rand}{count}")
+	exportCmd.Flags().BoolVar(&exportReproject, "reproject", false, "合并模式下源文件坐标系不一致时统一重投影，而不是报错")
+	exportCmd.Flags().StringVar(&exportNameTemplate, "name", "", "文件名模板，支持占位符 {name}{index}{seq}{date}{uuid}{rand}{count}{parent}{epsg}{crs}{hash}")
 	exportCmd.Flags().BoolVar(&exportDryRun, "dry-run", false, "仅预览导出计划，不执行写入")
 	exportCmd.Flags().BoolVar(&exportOverwrite, "overwrite", false, "允许覆盖已存在的目标文件")
+	exportCmd.Flags().BoolVar(&exportAppend, "append", false, "追加要素到已存在的目标图层（不可与 --overwrite 同时使用）")
 	exportCmd.Flags().BoolVar(&exportForceRefresh, "force-refresh", false, "强制重新处理，忽视processed已存在的条目")
 
-	_ = exportCmd.MarkFlagRequired("input")
+	exportCmd.Flags().BoolVar(&exportRecoverTruncated, "recover-truncated", false, "丢弃文件末尾被截断的地块，保留其余完整地块")
+	exportCmd.Flags().BoolVar(&exportSkipInvalid, "skip-invalid", false, "跳过无法构成合法几何的地块（逐个警告），保留其余地块而不是让整个文件失败")
+
+	exportCmd.Flags().BoolVar(&exportSummaryOnly, "summary-only", false, "仅输出汇总信息，逐文件日志降级为 debug")
+
+	exportCmd.Flags().StringVar(&exportResultsNDJSON, "results-ndjson", "", "将逐文件处理结果以 NDJSON 写入指定文件")
+
+	exportCmd.Flags().IntVar(&exportWorkers, "concurrency", 0, "预处理（解码、解析、几何处理）并发数，0 表示使用 CPU 核数")
+	exportCmd.Flags().IntVar(&exportConcurrency, "export-concurrency", 1, "Python 导出并发进程数（仅分散模式的非容器格式生效）")
+	exportCmd.Flags().DurationVar(&exportTimeout, "timeout", export.DefaultExecTimeout, "单个 Python 导出进程的执行超时（如 90s、10m），0 表示不限制")
+	exportCmd.Flags().StringVar(&exportQGISPath, "qgis-path", "", "QGIS 安装目录（便携版或自定义安装），默认读取环境变量 TXT2GEO_QGIS/QGIS_PREFIX_PATH 或自动查找")
+
+	exportCmd.Flags().IntVar(&exportSeqStart, "seq-start", 1, "名称模板 {seq} 的起始值，用于在多次运行间延续编号")
+	exportCmd.Flags().StringVar(&exportTimeZone, "tz", "", "名称模板 {date} 使用的时区，如 UTC、Asia/Shanghai，默认本地时区")
+
+	exportCmd.Flags().BoolVar(&exportIncludeGenerated, "include-generated", false, "不跳过本工具生成的文本文件（首行带有生成标记）")
+
+	exportCmd.Flags().IntVar(&exportMeasureColumn, "measure-column", 0, "坐标行中测量值 (M) 的列号（从 1 开始，≥5），0 表示不读取")
+
+	exportCmd.Flags().StringVar(&exportEncoding, "encoding", "", "强制源文件编码：utf-8|utf-8-sig|utf-16-le|utf-16-be|gb18030，默认自动探测")
+
+	exportCmd.Flags().StringVar(&exportRejectsNDJSON, "rejects-ndjson", "", "将容错处理中被剔除的要素（含原因）以 NDJSON 写入指定文件")
+	exportCmd.Flags().StringVar(&exportReport, "report", "", "将逐文件要素统计以 JSON 写入指定文件")
+	exportCmd.Flags().StringVar(&exportDumpPayload, "dump-payload", "", "将发送给 Python 的 JSON 负载（缩进格式）写入指定文件，便于排查问题")
+
+	exportCmd.Flags().IntVar(&exportSlowestFiles, "slowest", 0, "任务结束时输出耗时最长的前 N 个文件（读取/处理阶段耗时），0 表示不输出")
+
+	exportCmd.Flags().StringVar(&exportHistoryFile, "history-file", export.ProcessedFileName, "处理历史文件名（位于输出目录）")
+	exportCmd.Flags().BoolVar(&exportNoHistory, "no-history", false, "不记录处理历史，每次都重新处理")
+	exportCmd.Flags().DurationVar(&exportHistoryTTL, "history-ttl", 0, "处理历史有效期（如 720h），早于该时长的记录被清理并重新处理，0 表示永不过期")
+	exportCmd.Flags().BoolVar(&exportHistoryJSON, "history-json", false, "以 JSON 行记录处理历史（含源文件路径、输出格式与时间）")
+	exportCmd.Flags().BoolVar(&exportResume, "resume", false, "恢复中断的任务：核对处理历史与实际输出，输出缺失的文件将被重新处理")
+
+	exportCmd.Flags().StringArrayVar(&exportExcludes, "exclude", nil, "排除匹配的文件或目录（glob 模式，如 backup、*_old.txt），可重复指定")
+
+	exportCmd.Flags().Int64Var(&exportMinSize, "min-size", 0, "仅处理不小于该大小（字节）的文件，0 表示不限制")
+	exportCmd.Flags().Int64Var(&exportMaxSize, "max-size", 0, "仅处理不大于该大小（字节）的文件，0 表示不限制")
+	exportCmd.Flags().StringVar(&exportModifiedAfter, "modified-after", "", "仅处理在该时间之后修改的文件：2006-01-02、RFC3339 或相对天数（如 7d）")
+	exportCmd.Flags().StringVar(&exportModifiedBefore, "modified-before", "", "仅处理在该时间之前修改的文件，格式同 --modified-after")
+
+	exportCmd.Flags().StringVar(&exportAttrSection, "attr-section", "", "源文件属性部分的标记行，默认 [属性描述]")
+	exportCmd.Flags().StringVar(&exportGeomSection, "geom-section", "", "源文件坐标部分的标记行，默认 [地块坐标]")
+
+	exportCmd.Flags().StringVar(&exportDelimiter, "delimiter", ",", "坐标行字段分隔符（单个字符，tab 表示制表符），默认逗号")
+
+	exportCmd.Flags().BoolVar(&exportCheckPointCount, "check-point-count", false, "校验地块声明的界址点数与实际点数，不一致时输出警告")
+	exportCmd.Flags().BoolVar(&exportSkipExtentCheck, "skip-extent-check", false, "不检查坐标范围（北向坐标是否合理、坐标列是否互换），用于地方/工程坐标网")
+	exportCmd.Flags().BoolVar(&exportCheckGeometry, "check-geometry", false, "检查地块环的几何问题（自相交、点数不足、未闭合、面积为 0），发现时输出警告")
+	exportCmd.Flags().BoolVar(&exportComputeMetrics, "compute-metrics", false, "输出由几何计算的面积与周长字段 (QGIS 格式为 JSMJ/JSZC，GEOJSON/CSV 为 computed_area/computed_perimeter)")
+	exportCmd.Flags().StringArrayVar(&exportFields, "field", nil, "字段映射 源键=目标键（目标为空表示删除），可多次使用")
+	exportCmd.Flags().BoolVar(&exportKeepUnmapped, "keep-unmapped", false, "使用 --field 时保留未列出的字段")
+	exportCmd.Flags().StringVar(&exportFilter, "filter", "", "要素过滤表达式，如 area>1000 或 usage==耕地")
+	exportCmd.Flags().StringArrayVar(&exportCreationOptions, "co", nil, "GDAL 图层创建选项 KEY=VALUE（如 SPATIAL_INDEX=NO、2GB_LIMIT=YES），可多次使用")
+	exportCmd.Flags().BoolVar(&exportFixWinding, "fix-winding", false, "统一面的环方向：外环逆时针、洞顺时针（默认保持源文件点序）")
+	exportCmd.Flags().BoolVar(&exportEmitBBox, "emit-bbox", false, "为每个要素计算外包矩形（含洞），随要素传给导出脚本")
+	exportCmd.Flags().Float64Var(&exportSimplify, "simplify", 0, "Douglas-Peucker 简化容差（米），0 表示不简化")
+	exportCmd.Flags().Float64Var(&exportLatOrigin, "lat-origin", 0, "覆盖投影原点纬度（度），用于地方/工程坐标系；指定后不再输出 EPSG 码")
+	exportCmd.Flags().Float64Var(&exportScaleFactor, "scale-factor", 1, "覆盖中央经线比例因子（如 0.9996），用于地方/工程坐标系；指定后不再输出 EPSG 码")
+	exportCmd.Flags().Float64Var(&exportFalseNorthing, "false-northing", 0, "投影北偏移（米，如 10000000），用于带北向偏移的地方/工程坐标网；非 0 时不再输出 EPSG 码")
+
 	_ = exportCmd.MarkFlagRequired("output")
 }
@@ -0,0 +1,83 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"txt2geo/internal/export"
+	"txt2geo/internal/process"
+	"txt2geo/pkg/logger"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyDir     string
+	historyFile    string
+	historyClear   bool
+	historyVerbose bool
+)
+
+// historyCmd 查看或清空输出目录中的处理历史
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Inspect or clear the processed history",
+	Long: `查看或清空输出目录中的处理历史 (默认 .processed)。
+
+处理历史记录了已导出源文件的内容哈希，export 会跳过其中的文件。
+容器格式 (GPKG/GDB/SPATIALITE) 的处理历史位于容器所在的目录。
+
+示例:
+  # 查看 out 目录的历史条目数
+  txt2geo history --dir out
+
+  # 列出所有哈希
+  txt2geo history --dir out --verbose
+
+  # 清空历史，使所有文件在下次导出时重新处理
+  txt2geo history --dir out --clear
+`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if historyFile == "" {
+			return errors.New("history-file 不能为空")
+		}
+		path := filepath.Join(historyDir, historyFile)
+		history, err := process.NewProcessHistory(path)
+		if err != nil {
+			return fmt.Errorf("无法加载处理历史: %w", err)
+		}
+
+		if historyClear {
+			count := len(history.List())
+			if err := history.Clear(); err != nil {
+				return fmt.Errorf("清空处理历史失败: %w", err)
+			}
+			logger.Log().Info("[历史] 已清空处理历史", "文件", path, "删除", count)
+			return nil
+		}
+
+		hashes := history.List()
+		fmt.Printf("%s: %d 条记录\n", path, len(hashes))
+		if historyVerbose {
+			for _, h := range hashes {
+				fmt.Println(h)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	historyCmd.Flags().StringVar(&historyDir, "dir", "", "处理历史所在目录（导出的输出目录；容器格式为容器所在目录）")
+	historyCmd.Flags().StringVar(&historyFile, "history-file", export.ProcessedFileName, "处理历史文件名")
+	historyCmd.Flags().BoolVar(&historyClear, "clear", false, "清空处理历史")
+	historyCmd.Flags().BoolVarP(&historyVerbose, "verbose", "v", false, "列出所有哈希")
+
+	_ = historyCmd.MarkFlagRequired("dir")
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"txt2geo/internal/domain"
+	"txt2geo/pkg/charset"
+
+	"github.com/spf13/cobra"
+)
+
+var inspectJSON bool
+
+// inspectSummary 是 inspect 输出的文件概要。
+type inspectSummary struct {
+	File            string      `json:"file"`
+	Encoding        string      `json:"encoding"`
+	Parcels         int         `json:"parcels"`
+	Rings           int         `json:"rings"`
+	Points          int         `json:"points"`
+	CoordinateName  string      `json:"coordinate_system,omitempty"`
+	EPSG            int         `json:"epsg,omitempty"`
+	CentralMeridian float64     `json:"central_meridian,omitempty"`
+	CRSError        string      `json:"crs_error,omitempty"`
+	Extent          *[4]float64 `json:"extent,omitempty"` // minX, minY, maxX, maxY（源文件坐标）
+	Warnings        []string    `json:"warnings,omitempty"`
+}
+
+// inspectCmd 打印单个源文件的编码、地块统计、坐标系与范围，不写出任何文件，也不调用 QGIS
+var inspectCmd = &cobra.Command{
+	Use:   "inspect FILE",
+	Short: "Print metadata of a source text file",
+	Long: `解析单个源文件并输出概要：探测到的编码、地块/环/界址点数量、推断的坐标系 (EPSG、中央经线) 与坐标范围。
+
+不写出任何文件，也不调用 QGIS。
+
+示例:
+  txt2geo inspect D:\data\a.txt
+  txt2geo inspect D:\data\a.txt --json
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		summary, err := inspectFile(args[0])
+		if err != nil {
+			return err
+		}
+		if inspectJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.SetEscapeHTML(false)
+			return enc.Encode(summary)
+		}
+		printInspectSummary(summary)
+		return nil
+	},
+}
+
+// inspectFile 解码并解析源文件，汇总其元数据。坐标系无法推断时记录原因而不报错。
+func inspectFile(path string) (*inspectSummary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取文件 %s: %w", path, err)
+	}
+	text, enc, err := charset.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("解码失败 %s: %w", path, err)
+	}
+	parsed, err := domain.Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析失败 %s: %w", path, err)
+	}
+
+	summary := &inspectSummary{
+		File:     path,
+		Encoding: enc,
+		Parcels:  len(parsed.Parcels),
+	}
+	extent := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, parcel := range parsed.Parcels {
+		summary.Rings += len(parcel.Rings)
+		for _, ring := range parcel.Rings {
+			summary.Points += len(ring)
+			for _, p := range ring {
+				extent[0], extent[1] = min(extent[0], p.X), min(extent[1], p.Y)
+				extent[2], extent[3] = max(extent[2], p.X), max(extent[3], p.Y)
+			}
+		}
+	}
+	if summary.Points > 0 {
+		summary.Extent = &extent
+	}
+
+	if cs, err := domain.BuildCoordinateSystem(parsed); err != nil {
+		summary.CRSError = err.Error()
+	} else {
+		summary.CoordinateName = cs.Name
+		summary.EPSG = cs.EPSG
+		summary.CentralMeridian = cs.CentralMeridian
+	}
+	summary.Warnings = parsed.Warnings // 包含构建坐标系时追加的警告
+	return summary, nil
+}
+
+// printInspectSummary 以简洁的文本形式输出概要。
+func printInspectSummary(s *inspectSummary) {
+	fmt.Printf("文件:     %s\n", s.File)
+	fmt.Printf("编码:     %s\n", s.Encoding)
+	fmt.Printf("地块:     %d (环 %d，界址点 %d)\n", s.Parcels, s.Rings, s.Points)
+	if s.CRSError != "" {
+		fmt.Printf("坐标系:   无法推断 (%s)\n", s.CRSError)
+	} else {
+		fmt.Printf("坐标系:   %s\n", s.CoordinateName)
+		if s.EPSG > 0 {
+			fmt.Printf("EPSG:     %d\n", s.EPSG)
+		} else {
+			fmt.Println("EPSG:     无 (自定义投影)")
+		}
+		fmt.Printf("中央经线: %g\n", s.CentralMeridian)
+	}
+	if s.Extent != nil {
+		fmt.Printf("X 范围:   %.3f ~ %.3f\n", s.Extent[0], s.Extent[2])
+		fmt.Printf("Y 范围:   %.3f ~ %.3f\n", s.Extent[1], s.Extent[3])
+	}
+	for _, w := range s.Warnings {
+		fmt.Printf("警告:     %s\n", w)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(inspectCmd)
+
+	inspectCmd.Flags().BoolVar(&inspectJSON, "json", false, "以 JSON 格式输出")
+}
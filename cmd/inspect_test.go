@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// sampleContent 是与 domain 包共用的示例源文件：CGCS2000 3 度带第 38 带，一个闭合的三角形地块。
+var sampleContent = func() string {
+	data, err := os.ReadFile(filepath.Join("..", "internal", "domain", "testdata", "sample.txt"))
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}()
+
+// writeFile 在 dir 中写入名为 name 的文件并返回其路径。
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// resetFlags 在测试结束后将 cmd 的参数恢复为默认值并清除 Changed 标记，避免影响其他测试。
+func resetFlags(t *testing.T, cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			sv.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	t.Cleanup(func() {
+		cmd.Flags().VisitAll(reset)
+		cmd.PersistentFlags().VisitAll(reset)
+	})
+}
+
+func TestInspectFile(t *testing.T) {
+	gb, err := simplifiedchinese.GB18030.NewEncoder().String(sampleContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	tests := []struct {
+		name         string
+		content      string
+		wantEncoding string
+	}{
+		{"UTF-8", sampleContent, "utf-8"},
+		{"GB18030", gb, "gb18030"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, dir, tt.name+".txt", []byte(tt.content))
+			s, err := inspectFile(path)
+			if err != nil {
+				t.Fatalf("inspectFile: %v", err)
+			}
+			if s.File != path || s.Encoding != tt.wantEncoding {
+				t.Errorf("File/Encoding = %s/%s, want %s/%s", s.File, s.Encoding, path, tt.wantEncoding)
+			}
+			if s.Parcels != 1 || s.Rings != 1 || s.Points != 4 {
+				t.Errorf("地块/环/界址点 = %d/%d/%d, want 1/1/4", s.Parcels, s.Rings, s.Points)
+			}
+			if s.EPSG != 4526 || s.CentralMeridian != 114 || s.CRSError != "" {
+				t.Errorf("EPSG/中央经线/CRSError = %d/%g/%q, want 4526/114/空", s.EPSG, s.CentralMeridian, s.CRSError)
+			}
+			if want := [4]float64{3400000, 38500000, 3400100, 38500100}; s.Extent == nil || *s.Extent != want {
+				t.Errorf("Extent = %v, want %v", s.Extent, want)
+			}
+		})
+	}
+}
+
+func TestInspectFileCRSError(t *testing.T) {
+	content := strings.Replace(sampleContent, "坐标系=2000国家大地坐标系", "坐标系=火星坐标系", 1)
+	s, err := inspectFile(writeFile(t, t.TempDir(), "a.txt", []byte(content)))
+	if err != nil {
+		t.Fatalf("坐标系无法推断时不应报错: %v", err)
+	}
+	if s.CRSError == "" || s.EPSG != 0 {
+		t.Errorf("CRSError/EPSG = %q/%d, want 非空/0", s.CRSError, s.EPSG)
+	}
+	if s.Parcels != 1 {
+		t.Errorf("Parcels = %d, want 1", s.Parcels)
+	}
+
+	if _, err := inspectFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("文件不存在时应返回错误")
+	}
+}
+
+func TestInspectSummaryJSON(t *testing.T) {
+	s, err := inspectFile(writeFile(t, t.TempDir(), "a.txt", []byte(sampleContent)))
+	if err != nil {
+		t.Fatalf("inspectFile: %v", err)
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"file", "encoding", "parcels", "rings", "points", "coordinate_system", "epsg", "central_meridian", "extent"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("JSON 缺少键 %q: %s", key, data)
+		}
+	}
+	for _, key := range []string{"crs_error", "warnings"} {
+		if _, ok := got[key]; ok {
+			t.Errorf("JSON 不应包含空的 %q: %s", key, data)
+		}
+	}
+	if len(got) != 9 {
+		t.Errorf("JSON 键数 = %d, want 9: %s", len(got), data)
+	}
+}
@@ -12,9 +12,16 @@ import (
 	"txt2geo/pkg/logger"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
-var logLevel string
+var (
+	logLevel     string
+	logFormat    string
+	logFile      string
+	logMaxSizeMB int
+	assumeYes    bool
+)
 
 // rootCmd represents the base command when called without any subcommandsgo
 var rootCmd = &cobra.Command{
@@ -23,8 +30,34 @@ var rootCmd = &cobra.Command{
 	Long:    "TXT2GEO 是一个将文本文件转换为各种地理数据格式的工具。支持多种输出格式，方便用户进行地理数据处理和分析。",
 	Args:    cobra.MinimumNArgs(1),
 	Version: version.Version,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		logger.Init(logLevel)
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// 先应用配置文件，使其中的日志参数同样生效
+		path, err := findConfigFile(configFile)
+		if err != nil {
+			return err
+		}
+		var ignored []string
+		if path != "" {
+			if ignored, err = applyConfigFile(cmd, path); err != nil {
+				return err
+			}
+		}
+
+		if err := logger.InitWithOptions(logger.Options{
+			Level:     logLevel,
+			Format:    logFormat,
+			File:      logFile,
+			MaxSizeMB: logMaxSizeMB,
+		}); err != nil {
+			return err
+		}
+		if path != "" {
+			logger.Log().Debug("已加载配置文件", "file", path)
+		}
+		if len(ignored) > 0 {
+			logger.Log().Warn("配置文件中的以下键不是当前命令的参数，已忽略", "file", path, "keys", ignored)
+		}
+		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		logger.Log().Info("正在以默认配置快速处理...")
@@ -44,40 +77,26 @@ var rootCmd = &cobra.Command{
 			}
 			logger.Log().Info(fmt.Sprintf("  %d. %s%s", i+1, path, pathType))
 		}
-		// 交互式选择输出格式
-		var formatKey string
-		fmt.Println("请选择输出格式:")
-		fmt.Println("1. SHP (ESRI Shapefile)")
-		fmt.Println("2. FGB (FlatGeobuf)")
-		fmt.Println("3. GPKG (GeoPackage)")
-		fmt.Println("4. GDB (OpenFileGDB)")
-		fmt.Print("输入序号并回车: ")
-		var choice int
-		fmt.Scanln(&choice)
-		switch choice {
-		case 1:
-			formatKey = "SHP"
-		case 2:
-			formatKey = "FGB"
-		case 3:
-			formatKey = "GPKG"
-		case 4:
-			formatKey = "GDB"
-		default:
-			fmt.Println("无效选择，默认使用 FGB 格式。")
-			formatKey = "FGB"
+		// 标准输入不是终端（管道、CI）或指定了 --yes 时不提示，直接使用默认格式
+		interactive := !assumeYes && stdinIsTerminal()
+		formatKey := "FGB"
+		if interactive {
+			formatKey = promptFormat()
+			fmt.Printf("已选择格式: %s\n", formatKey)
+			fmt.Println("请按下 Enter 键执行导出...")
+			fmt.Scanln()
+		} else {
+			logger.Log().Info("非交互模式，使用默认格式", "格式", formatKey)
 		}
 
-		fmt.Printf("已选择格式: %s\n", formatKey)
-		fmt.Println("请按下 Enter 键执行导出...")
-		fmt.Scanln()
-
 		exporter, err := export.NewExporter(export.ExportConfig{
 			InputPaths: args,
 			Depth:      0,
 			FormatKey:  formatKey,
 			OutputDir:  "output",
 			Merge:      false,
+
+			HistoryFileName: export.ProcessedFileName,
 		})
 		if err != nil {
 			return fmt.Errorf("创建导出器失败: %w", err)
@@ -92,13 +111,64 @@ var rootCmd = &cobra.Command{
 			logger.Log().Info("导出成功完成！")
 		}
 
-		fmt.Println("操作已完成，请按下 Enter 键退出程序...")
-		fmt.Scanln()
+		if interactive {
+			fmt.Println("操作已完成，请按下 Enter 键退出程序...")
+			fmt.Scanln()
+		}
 
 		return execErr
 	},
 }
 
+// promptFormat 交互式选择输出格式，无效选择时回退到 FGB。
+func promptFormat() string {
+	fmt.Println("请选择输出格式:")
+	fmt.Println("1. SHP (ESRI Shapefile)")
+	fmt.Println("2. FGB (FlatGeobuf)")
+	fmt.Println("3. GPKG (GeoPackage)")
+	fmt.Println("4. GDB (OpenFileGDB)")
+	fmt.Println("5. SPATIALITE (SpatiaLite)")
+	fmt.Println("6. GEOJSON (GeoJSON，无需 QGIS)")
+	fmt.Println("7. CSV (属性 + WKT，无需 QGIS)")
+	fmt.Println("8. TAB (MapInfo)")
+	fmt.Println("9. KML (Google Earth)")
+	fmt.Print("输入序号并回车: ")
+	var choice int
+	fmt.Scanln(&choice)
+	switch choice {
+	case 1:
+		return "SHP"
+	case 2:
+		return "FGB"
+	case 3:
+		return "GPKG"
+	case 4:
+		return "GDB"
+	case 5:
+		return "SPATIALITE"
+	case 6:
+		return "GEOJSON"
+	case 7:
+		return "CSV"
+	case 8:
+		return "TAB"
+	case 9:
+		return "KML"
+	default:
+		fmt.Println("无效选择，默认使用 FGB 格式。")
+		return "FGB"
+	}
+}
+
+// stdinIsTerminal 判断标准输入是否连接到终端。
+func stdinIsTerminal() bool {
+	fd := os.Stdin.Fd()
+	if fd > uintptr(^uint(0)>>1) {
+		return false
+	}
+	return term.IsTerminal(int(fd))
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -110,5 +180,11 @@ func Execute() {
 
 func init() {
 	cobra.MousetrapHelpText = ""
+	rootCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "非交互模式：不提示选择格式与确认，直接以默认格式 FGB 导出")
+	rootCmd.Flags().BoolVar(&assumeYes, "non-interactive", false, "同 --yes")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "配置文件路径，默认读取工作目录中的 txt2geo.yaml（存在时）")
 	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "Set log levels (debug, info, warn, error)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "日志输出格式 (text, json)")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "同时将日志（无颜色）写入指定文件")
+	rootCmd.PersistentFlags().IntVar(&logMaxSizeMB, "log-max-size", 10, "日志文件超过该大小（MB）时轮转为 <log-file>.1，0 表示不轮转")
 }
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"txt2geo/internal/export"
+)
+
+// pipeStdin 将标准输入替换为一个管道并写入 input；写端保持打开，读取超出 input 的内容会一直阻塞。
+func pipeStdin(t *testing.T, input string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteString(input); err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = orig
+		w.Close()
+		r.Close()
+	})
+}
+
+func TestStdinIsTerminal(t *testing.T) {
+	pipeStdin(t, "")
+	if stdinIsTerminal() {
+		t.Error("管道不应被识别为终端")
+	}
+}
+
+func TestRootNonInteractive(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	pipeStdin(t, "") // 若仍然提示输入，将阻塞直到超时
+	resetFlags(t, rootCmd)
+	t.Cleanup(func() { rootCmd.SetArgs(nil) })
+
+	// 空目录中没有输入文件：不阻塞地走完非交互流程，并返回 ErrNoInputFiles
+	rootCmd.SetArgs([]string{dir})
+	done := make(chan error, 1)
+	go func() { done <- rootCmd.Execute() }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, export.ErrNoInputFiles) {
+			t.Fatalf("Execute err = %v, want ErrNoInputFiles", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("非终端标准输入下根命令仍在等待输入")
+	}
+}
+
+func TestPromptFormat(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"1\n", "SHP"},
+		{"3\n", "GPKG"},
+		{"6\n", "GEOJSON"},
+		{"9\n", "KML"},
+		{"0\n", "FGB"},
+		{"abc\n", "FGB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want+"_"+tt.input[:len(tt.input)-1], func(t *testing.T) {
+			pipeStdin(t, tt.input)
+			if got := promptFormat(); got != tt.want {
+				t.Errorf("promptFormat(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -6,32 +6,215 @@ package domain
 import (
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 // CoordinateSystem 汇总由属性与几何推导出的坐标系统信息。
-// 用于描述 CGCS2000 高斯-克吕格投影参数，包括分带、带号、中央经线、EPSG 码及 WKT。
+// 用于描述 CGCS2000 / 西安80 / 北京54 高斯-克吕格投影参数，包括分带、带号、中央经线、EPSG 码及 WKT。
 type CoordinateSystem struct {
 	Name             string  // 投影坐标系名称（ESRI WKT 中的 PROJCS 名称）
+	Datum            string  // 大地基准：CGCS2000、Xian1980、Beijing1954；直接采用投影定义时为空
 	Degree           int     // 几度分带（3 或 6）
 	Band             int     // 带号
 	CentralMeridian  float64 // 中央经线（单位：度）
 	EPSG             int     // EPSG 代码；若为 0 表示不存在标准 EPSG
+	EPSGName         string  // EPSG 注册库中的投影名称（EPSG > 0 时填充），如 "CGCS2000 / 3-degree Gauss-Kruger zone 38"
 	IsCustomMeridian bool    // 是否来源于 "坐标系" 字段自定义的中央经线
 	WKT              string  // ESRI Well Known Text 描述
+	Proj4            string  // PROJ.4 定义字符串（如 "+proj=tmerc ..."），与 WKT 描述同一投影
 }
 
-// BuildCoordinateSystem 根据解析结果构建 CGCS2000 高斯-克吕格投影定义。
+// 支持的大地基准标识
+const (
+	DatumCGCS2000    = "CGCS2000"
+	DatumXian1980    = "Xian1980"
+	DatumBeijing1954 = "Beijing1954"
+)
+
+// datumDef 描述一个大地基准的椭球参数、ESRI 命名及 EPSG 编码规则。
+// EPSG 编码按带号连续分配：6 度带 13~23 带、3 度带 25~45 带，
+// "Zone" 为坐标带前缀（False_Easting 含带号），"CM" 为不带带号前缀的中央经线命名。
+type datumDef struct {
+	Key        string   // 基准标识（Datum* 常量）
+	Aliases    []string // 坐标系字段中用于识别该基准的关键字（大小写不敏感）
+	GCSName    string   // ESRI GEOGCS 名称
+	DatumName  string   // ESRI DATUM 名称
+	Spheroid   string   // ESRI SPHEROID 名称
+	SemiMajor  float64  // 长半轴（米）
+	InvFlat    float64  // 扁率倒数
+	Proj4Ellps string   // PROJ.4 中的椭球参数，如 "+ellps=GRS80"
+	NamePrefix string   // 投影名称前缀，如 "CGCS2000"
+	EPSGPrefix string   // EPSG 注册名称中的基准名，如 "Xian 1980"
+	EPSG6Zone  int      // 6 度带 13 带（含带号）EPSG
+	EPSG6CM    int      // 6 度带 13 带（不含带号，中央经线 75E）EPSG
+	EPSG3Zone  int      // 3 度带 25 带（含带号）EPSG
+	EPSG3CM    int      // 3 度带 25 带（不含带号，中央经线 75E）EPSG
+}
+
+// supportedDatums 按识别优先级排列的大地基准定义。
+// 西安80 使用 IAG-75 椭球，北京54 使用克拉索夫斯基椭球。
+var supportedDatums = [...]datumDef{
+	{
+		Key: DatumCGCS2000, Aliases: []string{"2000国家大地坐标系", "CGCS2000"},
+		GCSName: "GCS_China_Geodetic_Coordinate_System_2000", DatumName: "D_China_2000",
+		Spheroid: "CGCS2000", SemiMajor: 6378137.0, InvFlat: 298.257222101, Proj4Ellps: "+ellps=GRS80",
+		NamePrefix: "CGCS2000", EPSGPrefix: "CGCS2000", EPSG6Zone: 4491, EPSG6CM: 4502, EPSG3Zone: 4513, EPSG3CM: 4534,
+	},
+	{
+		Key: DatumXian1980, Aliases: []string{"西安", "XIAN"},
+		GCSName: "GCS_Xian_1980", DatumName: "D_Xian_1980",
+		Spheroid: "Xian_1980", SemiMajor: 6378140.0, InvFlat: 298.257, Proj4Ellps: "+a=6378140 +rf=298.257",
+		NamePrefix: "Xian_1980", EPSGPrefix: "Xian 1980", EPSG6Zone: 2327, EPSG6CM: 2338, EPSG3Zone: 2349, EPSG3CM: 2370,
+	},
+	{
+		Key: DatumBeijing1954, Aliases: []string{"北京", "BEIJING"},
+		GCSName: "GCS_Beijing_1954", DatumName: "D_Beijing_1954",
+		Spheroid: "Krasovsky_1940", SemiMajor: 6378245.0, InvFlat: 298.3, Proj4Ellps: "+ellps=krass",
+		NamePrefix: "Beijing_1954", EPSGPrefix: "Beijing 1954", EPSG6Zone: 21413, EPSG6CM: 21473, EPSG3Zone: 2401, EPSG3CM: 2422,
+	},
+}
+
+// epsgNames 为支持的大地基准所有高斯-克吕格 EPSG 代码到注册名称的映射。
+var epsgNames = buildEPSGNames()
+
+// buildEPSGNames 按 EPSG 注册库的命名规则生成名称表：
+// 6 度带为 "<基准> / Gauss-Kruger zone N" 与 "<基准> / Gauss-Kruger CM <6N-3>E"，
+// 3 度带为 "<基准> / 3-degree Gauss-Kruger zone N" 与 "<基准> / 3-degree Gauss-Kruger CM <3N>E"。
+func buildEPSGNames() map[int]string {
+	names := make(map[int]string)
+	for _, d := range supportedDatums {
+		for band := 13; band <= 23; band++ {
+			names[d.EPSG6Zone+band-13] = fmt.Sprintf("%s / Gauss-Kruger zone %d", d.EPSGPrefix, band)
+			names[d.EPSG6CM+band-13] = fmt.Sprintf("%s / Gauss-Kruger CM %dE", d.EPSGPrefix, 6*band-3)
+		}
+		for band := 25; band <= 45; band++ {
+			names[d.EPSG3Zone+band-25] = fmt.Sprintf("%s / 3-degree Gauss-Kruger zone %d", d.EPSGPrefix, band)
+			names[d.EPSG3CM+band-25] = fmt.Sprintf("%s / 3-degree Gauss-Kruger CM %dE", d.EPSGPrefix, 3*band)
+		}
+	}
+	return names
+}
+
+// LookupEPSGName 返回 EPSG 代码对应的注册名称，仅覆盖本工具会输出的高斯-克吕格投影代码。
+func LookupEPSGName(code int) (string, bool) {
+	name, ok := epsgNames[code]
+	return name, ok
+}
+
+// detectDatum 根据坐标系字段识别大地基准，无法识别时返回 false。
+func detectDatum(coordName string) (datumDef, bool) {
+	upper := strings.ToUpper(coordName)
+	for _, d := range supportedDatums {
+		for _, alias := range d.Aliases {
+			if strings.Contains(upper, strings.ToUpper(alias)) {
+				return d, true
+			}
+		}
+	}
+	return datumDef{}, false
+}
+
+// 文件属性中可直接给出投影定义的键名（大小写不敏感）
+var projDefinitionKeys = [...]string{"proj4", "prj"}
+
+// projDefinitionFromAttributes 查找文件属性中的投影定义。
+// 以 "+" 开头的值视为 PROJ.4 字符串，以 PROJCS/GEOGCS 开头的值视为 WKT（.prj 文件内容）。
+// 未找到或无法识别时两者均为空。
+func projDefinitionFromAttributes(attrs map[string]string) (proj4, wkt string) {
+	for key, val := range attrs {
+		matched := false
+		for _, k := range projDefinitionKeys {
+			if strings.EqualFold(strings.TrimSpace(key), k) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		upper := strings.ToUpper(val)
+		switch {
+		case strings.HasPrefix(val, "+"):
+			return val, ""
+		case strings.HasPrefix(upper, "PROJCS[") || strings.HasPrefix(upper, "GEOGCS["):
+			return "", val
+		}
+	}
+	return "", ""
+}
+
+// hasProjDefinition 判断文件属性中是否存在可识别的投影定义。
+func hasProjDefinition(attrs map[string]string) bool {
+	proj4, wkt := projDefinitionFromAttributes(attrs)
+	return proj4 != "" || wkt != ""
+}
+
+// ProjectionOptions 高斯-克吕格投影参数覆盖，用于非标准的地方/工程坐标系。
+// 字段为 nil/0 时使用标准参数（原点纬度 0、比例因子 1、北偏移 0）；任一字段被覆盖时不再输出 EPSG 码。
+type ProjectionOptions struct {
+	LatitudeOfOrigin *float64 // 原点纬度（度）
+	ScaleFactor      *float64 // 中央经线比例因子
+	FalseNorthing    float64  // 北偏移（米），用于带北向偏移的地方/工程坐标网，0 为标准值
+}
+
+// overridden 判断是否覆盖了任一标准投影参数。
+func (o ProjectionOptions) overridden() bool {
+	return o.LatitudeOfOrigin != nil || o.ScaleFactor != nil || o.FalseNorthing != 0
+}
+
+// latitudeOfOrigin 返回原点纬度，未覆盖时为 0。
+func (o ProjectionOptions) latitudeOfOrigin() float64 {
+	if o.LatitudeOfOrigin != nil {
+		return *o.LatitudeOfOrigin
+	}
+	return 0
+}
+
+// scaleFactor 返回比例因子，未覆盖时为 1。
+func (o ProjectionOptions) scaleFactor() float64 {
+	if o.ScaleFactor != nil {
+		return *o.ScaleFactor
+	}
+	return 1
+}
+
+// Validate 校验覆盖参数的取值范围。
+func (o ProjectionOptions) Validate() error {
+	if lat := o.LatitudeOfOrigin; lat != nil && (math.IsNaN(*lat) || *lat < -90 || *lat > 90) {
+		return fmt.Errorf("原点纬度 %v 超出范围 [-90,90]", *lat)
+	}
+	if k := o.ScaleFactor; k != nil && (math.IsNaN(*k) || math.IsInf(*k, 0) || *k <= 0) {
+		return fmt.Errorf("比例因子 %v 必须为正数", *k)
+	}
+	if math.IsNaN(o.FalseNorthing) || math.IsInf(o.FalseNorthing, 0) {
+		return fmt.Errorf("北偏移 %v 必须为有限数", o.FalseNorthing)
+	}
+	return nil
+}
+
+// BuildCoordinateSystem 根据解析结果构建高斯-克吕格投影定义。
 // 规则：
-//  1. 坐标系字段必须包含 "2000国家大地坐标系"，括号内数字表示自定义中央经线。
+//  1. 坐标系字段必须能识别为 2000国家大地坐标系、西安80 或北京54，括号内数字表示自定义中央经线。
 //  2. 仅支持 3 度或 6 度分带，3 度带号范围 [25,45]，6 度带号范围 [13,23]。
 //  3. 标准中央经线输出 EPSG 码和 WKT，自定义中央经线仅输出 WKT。
 //  4. 若属性分带/带号与几何推断不一致，优先采用几何。
+//  5. 上述字段不足以构建投影时，若属性中提供了 proj4 / prj 投影定义，则直接采用该定义。
 //
 // 参数：pd 解析后的地块数据
 // 返回：坐标系统结构体或错误
 func BuildCoordinateSystem(pd *ParsedData) (*CoordinateSystem, error) {
+	return BuildCoordinateSystemWithOptions(pd, ProjectionOptions{})
+}
+
+// BuildCoordinateSystemWithOptions 与 BuildCoordinateSystem 相同，但允许覆盖原点纬度、比例因子与北偏移。
+// 覆盖参数仅作用于由坐标系字段构建的高斯-克吕格投影，不影响属性中直接给出的投影定义。
+func BuildCoordinateSystemWithOptions(pd *ParsedData, opts ProjectionOptions) (*CoordinateSystem, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
 	if pd == nil {
 		return nil, fmt.Errorf("parsed data is nil")
 	}
@@ -43,12 +226,40 @@ func BuildCoordinateSystem(pd *ParsedData) (*CoordinateSystem, error) {
 		return nil, fmt.Errorf("file attributes missing")
 	}
 
+	// 仅在中文坐标系字段不足时回退到属性中直接给出的投影定义；
+	// 字段齐全但取值有误（如带号超出范围、坐标点跨带）时照常报错，不被投影定义掩盖
+	if !hasGaussKrugerFields(attrs) {
+		if proj4, wkt := projDefinitionFromAttributes(attrs); proj4 != "" || wkt != "" {
+			return &CoordinateSystem{
+				Name:  "Custom_Projection",
+				WKT:   wkt,
+				Proj4: proj4,
+			}, nil
+		}
+	}
+	return buildGaussKrugerCoordinateSystem(pd, opts)
+}
+
+// hasGaussKrugerFields 判断中文坐标系字段是否足以构建高斯-克吕格投影：
+// 坐标系可识别为支持的大地基准，且几度分带与带号均已填写（取值是否有效由构建过程校验）。
+func hasGaussKrugerFields(attrs map[string]string) bool {
+	if _, ok := detectDatum(strings.TrimSpace(attrs["坐标系"])); !ok {
+		return false
+	}
+	return strings.TrimSpace(attrs["几度分带"]) != "" && strings.TrimSpace(attrs["带号"]) != ""
+}
+
+// buildGaussKrugerCoordinateSystem 根据中文坐标系字段构建高斯-克吕格投影定义，规则见 BuildCoordinateSystem。
+func buildGaussKrugerCoordinateSystem(pd *ParsedData, opts ProjectionOptions) (*CoordinateSystem, error) {
+	attrs := pd.FileAttributes
+
 	coordName := strings.TrimSpace(attrs["坐标系"])
 	if coordName == "" {
 		return nil, fmt.Errorf("缺少坐标系字段")
 	}
-	if !strings.Contains(coordName, "2000国家大地坐标系") {
-		return nil, fmt.Errorf("坐标系必须为\"2000国家大地坐标系\"")
+	datum, ok := detectDatum(coordName)
+	if !ok {
+		return nil, fmt.Errorf("无法识别的坐标系 %q，支持 2000国家大地坐标系、1980西安坐标系、1954北京坐标系", coordName)
 	}
 
 	// 1. 先用属性分带和带号
@@ -64,8 +275,16 @@ func BuildCoordinateSystem(pd *ParsedData) (*CoordinateSystem, error) {
 		return nil, fmt.Errorf("带号无效: %v", err)
 	}
 
-	// 2. 再用几何样本点推断带号
-	bandGeom := deriveBandFromFirstPoint(pd)
+	// 2. 再用所有几何点推断带号（多数表决）。少数带的点只是零星个别时视为录入错误，按多数带处理并记录警告；
+	// 少数带点数较多或分布在多个地块时视为真正跨带，报错以免将跨带文件静默归入某一带
+	bandGeom, consistent := deriveBandFromPoints(pd)
+	if !consistent {
+		counts := bandCounts(pd)
+		if significantBandMinority(pd, bandGeom) {
+			return nil, fmt.Errorf("坐标点跨越多个投影带（%s），请按带拆分文件后再导出", formatBandCounts(counts))
+		}
+		pd.Warnings = append(pd.Warnings, fmt.Sprintf("个别坐标点的带号与多数点不一致（%s），已按带号 %d 处理，请检查这些点的坐标", formatBandCounts(counts), bandGeom))
+	}
 	degreeGeom := normalizeDegreeForBand(degreeAttr, bandGeom)
 
 	// 3. 判断是否有自定义中央经线
@@ -117,45 +336,161 @@ func BuildCoordinateSystem(pd *ParsedData) (*CoordinateSystem, error) {
 	isStandardCentral := math.Abs(math.Mod(central, 3)) < 1e-8
 	var epsg int
 	hasBand := bandGeom > 0
-	if isStandardCentral {
-		epsg = computeEPSGCode(band, hasBand)
+	if isStandardCentral && !opts.overridden() {
+		epsg = computeEPSGCode(datum, band, hasBand)
 	} else {
 		epsg = 0
 	}
 
-	projName := buildProjectionName(band, central, hasBand, isStandardCentral)
-	wkt := buildCGCS2000WKT(projName, central, band, hasBand)
+	projName := buildProjectionName(datum, band, central, hasBand, isStandardCentral)
+	wkt := buildGaussKrugerWKT(datum, projName, central, band, hasBand, opts)
+	proj4 := buildProj4(datum, central, band, hasBand, opts)
 
 	return &CoordinateSystem{
 		Name:             projName,
+		Datum:            datum.Key,
 		Degree:           degree,
 		Band:             band,
 		CentralMeridian:  central,
 		EPSG:             epsg,
+		EPSGName:         epsgNames[epsg],
 		IsCustomMeridian: hasCustom,
 		WKT:              wkt,
+		Proj4:            proj4,
 	}, nil
 }
 
-// deriveBandFromFirstPoint 从几何首个点推断带号（取 Y 坐标的百万位）。
-// 若无有效点则返回 0。
-func deriveBandFromFirstPoint(pd *ParsedData) int {
-	// 只取第一个 parcel 的第一个 ring 的第一个有效点
-	if pd == nil || len(pd.Parcels) == 0 {
-		return 0
+// 北向（X）坐标合理范围：约对应北纬 15°~55°，覆盖中国陆地及近海。
+const (
+	minPlausibleNorthing = 1_600_000.0
+	maxPlausibleNorthing = 6_200_000.0
+)
+
+// SanityCheckExtent 检查坐标范围是否合理，用于发现 X/Y 列互换等常见错误。
+// X（北向）须落在约北纬 15°~55° 对应的范围内；Y（东向）须为不含带号的 [0,1000000)，
+// 或带号前缀属于 3 度带 [25,45] / 6 度带 [13,23]。
+// 中文坐标系字段不足而直接采用属性中的 proj4 / prj 定义时，坐标不一定是高斯-克吕格坐标，不做检查。
+func SanityCheckExtent(pd *ParsedData) error {
+	if pd == nil {
+		return fmt.Errorf("parsed data is nil")
+	}
+	if !hasGaussKrugerFields(pd.FileAttributes) && hasProjDefinition(pd.FileAttributes) {
+		return nil
+	}
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, parcel := range pd.Parcels {
+		for _, ring := range parcel.Rings {
+			for _, p := range ring {
+				minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+				minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+			}
+		}
+	}
+	if math.IsInf(minX, 1) {
+		return nil // 无坐标点
 	}
-	parcel := pd.Parcels[0]
-	if len(parcel.Rings) > 0 {
-		for _, pt := range parcel.Rings[0] {
-			if pt.Y != 0 {
+	northingOK := func(v float64) bool { return v >= minPlausibleNorthing && v <= maxPlausibleNorthing }
+	if !northingOK(minX) || !northingOK(maxX) {
+		if northingOK(minY) && northingOK(maxY) && plausibleEasting(minX) && plausibleEasting(maxX) {
+			return fmt.Errorf("第 3 列坐标范围 [%.3f, %.3f] 不是合理的北向 (X) 坐标，而第 4 列坐标范围 [%.3f, %.3f] 符合北向坐标，坐标列可能互换", minX, maxX, minY, maxY)
+		}
+		return fmt.Errorf("第 3 列北向 (X) 坐标范围 [%.3f, %.3f] 超出北纬 15°~55° 对应的范围 [%.0f, %.0f]，请检查坐标列顺序或坐标系", minX, maxX, minPlausibleNorthing, maxPlausibleNorthing)
+	}
+	if !plausibleEasting(minY) || !plausibleEasting(maxY) {
+		return fmt.Errorf("第 4 列东向 (Y) 坐标范围 [%.3f, %.3f] 不合理（应不含带号且小于 1000000，或带号属于 [13,23]/[25,45]）", minY, maxY)
+	}
+	return nil
+}
+
+// plausibleEasting 判断东向坐标是否合理：不含带号前缀时位于 (0,1000000)，否则带号须有效。
+func plausibleEasting(v float64) bool {
+	if v <= 0 {
+		return false
+	}
+	if v < 1_000_000 {
+		return true
+	}
+	band := int(v / 1_000_000)
+	return (band >= 13 && band <= 23) || (band >= 25 && band <= 45)
+}
+
+// deriveBandFromPoints 从所有地块的几何点推断带号（取 Y 坐标的百万位），返回出现次数最多的带号
+// （次数相同时取较小带号），以及所有有效点是否属于同一带。
+// Y 坐标不含带号前缀（百万位为 0）的点不参与统计；若无有效点则返回 (0, true)。
+func deriveBandFromPoints(pd *ParsedData) (band int, consistent bool) {
+	counts := bandCounts(pd)
+	best := 0
+	for b, n := range counts {
+		if n > counts[best] || (n == counts[best] && b < best) {
+			best = b
+		}
+	}
+	return best, len(counts) <= 1
+}
+
+// maxBandMinorityRatio 是少数带点数占全部带号点数的上限，超过即视为跨带而非个别录入错误。
+const maxBandMinorityRatio = 0.05
+
+// significantBandMinority 判断不属于多数带 band 的点是否足以说明文件真正跨带：
+// 这些点分布在多个地块，或其数量超过全部带号点数的 maxBandMinorityRatio。
+func significantBandMinority(pd *ParsedData, band int) bool {
+	var total, minority, parcels int
+	for _, parcel := range pd.Parcels {
+		inParcel := 0
+		for _, ring := range parcel.Rings {
+			for _, pt := range ring {
 				candidate := int(math.Floor(pt.Y / 1_000_000))
-				if candidate > 0 {
-					return candidate
+				if pt.Y == 0 || candidate <= 0 {
+					continue
+				}
+				total++
+				if candidate != band {
+					inParcel++
 				}
 			}
 		}
+		if inParcel > 0 {
+			minority += inParcel
+			parcels++
+		}
 	}
-	return 0
+	return parcels > 1 || float64(minority) > float64(total)*maxBandMinorityRatio
+}
+
+// bandCounts 统计各带号（Y 坐标百万位）的点数，忽略不含带号前缀的点。
+func bandCounts(pd *ParsedData) map[int]int {
+	counts := make(map[int]int)
+	if pd == nil {
+		return counts
+	}
+	for _, parcel := range pd.Parcels {
+		for _, ring := range parcel.Rings {
+			for _, pt := range ring {
+				if pt.Y == 0 {
+					continue
+				}
+				if candidate := int(math.Floor(pt.Y / 1_000_000)); candidate > 0 {
+					counts[candidate]++
+				}
+			}
+		}
+	}
+	return counts
+}
+
+// formatBandCounts 将带号统计格式化为 "带号 38: 120 点, 带号 39: 4 点"，按带号升序。
+func formatBandCounts(counts map[int]int) string {
+	bands := make([]int, 0, len(counts))
+	for b := range counts {
+		bands = append(bands, b)
+	}
+	sort.Ints(bands)
+	parts := make([]string, 0, len(bands))
+	for _, b := range bands {
+		parts = append(parts, fmt.Sprintf("带号 %d: %d 点", b, counts[b]))
+	}
+	return strings.Join(parts, ", ")
 }
 
 // normalizeDegreeForBand 校验分带与带号是否匹配。
@@ -220,21 +555,21 @@ func computeStandardCentral(degree, band int) (float64, error) {
 	return 0, fmt.Errorf("仅支持 3 度或 6 度分带")
 }
 
-// computeEPSGCode 根据带号和分带类型推断 EPSG 代码。
+// computeEPSGCode 根据大地基准、带号和分带类型推断 EPSG 代码。
 // 3度带号范围 [25,45]，6度带号范围 [13,23]。
 // hasBand 表示是否有几何推断带号。
-func computeEPSGCode(band int, hasBand bool) int {
+func computeEPSGCode(d datumDef, band int, hasBand bool) int {
 	if band >= 13 && band <= 23 {
 		if hasBand {
-			return 4491 + (band - 13)
+			return d.EPSG6Zone + (band - 13)
 		}
-		return 4502 + (band - 13)
+		return d.EPSG6CM + (band - 13)
 	}
 	if band >= 25 && band <= 45 {
 		if hasBand {
-			return 4513 + (band - 25)
+			return d.EPSG3Zone + (band - 25)
 		}
-		return 4534 + (band - 25)
+		return d.EPSG3CM + (band - 25)
 	}
 	return 0 // 带号超出有效范围
 }
@@ -242,14 +577,14 @@ func computeEPSGCode(band int, hasBand bool) int {
 // buildProjectionName 构造投影名称。
 // 标准中央经线用整数，非标准用一位小数。
 // hasBand 控制 Zone/CM 命名。
-func buildProjectionName(band int, central float64, hasBand bool, isStandardCentral bool) string {
+func buildProjectionName(d datumDef, band int, central float64, hasBand bool, isStandardCentral bool) string {
 	var prefix string
 	// 带号区分前缀
 	if band >= 13 && band <= 23 {
-		prefix = "CGCS2000_GK_"
+		prefix = d.NamePrefix + "_GK_"
 	}
 	if band >= 25 && band <= 45 {
-		prefix = "CGCS2000_3_Degree_GK_"
+		prefix = d.NamePrefix + "_3_Degree_GK_"
 	}
 	// 中央经线显示格式
 	var cmStr string
@@ -264,26 +599,58 @@ func buildProjectionName(band int, central float64, hasBand bool, isStandardCent
 	return fmt.Sprintf("%sCM_%sE", prefix, cmStr)
 }
 
-// buildCGCS2000WKT 构造 CGCS2000 高斯-克吕格投影 WKT。
-// hasBand 控制 False_Easting。
-func buildCGCS2000WKT(name string, central float64, band int, hasBand bool) string {
-	var falseEasting float64
+// falseEasting 返回东偏移：含带号时为 带号*1e6+500000，否则为 500000。
+func falseEasting(band int, hasBand bool) float64 {
 	if hasBand {
-		falseEasting = float64(band)*1_000_000 + 500000
-	} else {
-		falseEasting = 500000.0
+		return float64(band)*1_000_000 + 500000
 	}
+	return 500000.0
+}
+
+// buildCGCS2000Proj4 构造 CGCS2000 标准高斯-克吕格投影的 PROJ.4 字符串，东偏移与 WKT 相同：
+// hasBand 时为 带号*1e6+500000，否则为 500000。其它大地基准与参数覆盖见 buildProj4。
+func buildCGCS2000Proj4(central float64, band int, hasBand bool) string {
+	return buildProj4(supportedDatums[0], central, band, hasBand, ProjectionOptions{})
+}
+
+// buildProj4 构造与 buildGaussKrugerWKT 等价的 PROJ.4 字符串，
+// 如 "+proj=tmerc +lat_0=0 +lon_0=114 +k=1 +x_0=38500000 +y_0=0 +ellps=GRS80 +units=m +no_defs"。
+func buildProj4(d datumDef, central float64, band int, hasBand bool, opts ProjectionOptions) string {
+	return fmt.Sprintf("+proj=tmerc +lat_0=%s +lon_0=%s +k=%s +x_0=%s +y_0=%s %s +units=m +no_defs",
+		strconv.FormatFloat(opts.latitudeOfOrigin(), 'f', -1, 64),
+		strconv.FormatFloat(central, 'f', -1, 64),
+		strconv.FormatFloat(opts.scaleFactor(), 'f', -1, 64),
+		strconv.FormatFloat(falseEasting(band, hasBand), 'f', -1, 64),
+		strconv.FormatFloat(opts.FalseNorthing, 'f', -1, 64),
+		d.Proj4Ellps)
+}
+
+// buildGaussKrugerWKT 构造指定大地基准的高斯-克吕格投影 WKT。
+// hasBand 控制 False_Easting，opts 覆盖 False_Northing、Scale_Factor 与 Latitude_Of_Origin。
+func buildGaussKrugerWKT(d datumDef, name string, central float64, band int, hasBand bool, opts ProjectionOptions) string {
+	fe := falseEasting(band, hasBand)
 	wkt := `PROJCS["%s",` +
-		`GEOGCS["GCS_China_Geodetic_Coordinate_System_2000",` +
-		`DATUM["D_China_2000",SPHEROID["CGCS2000",6378137.0,298.257222101]],` +
+		`GEOGCS["%s",` +
+		`DATUM["%s",SPHEROID["%s",%s,%s]],` +
 		`PRIMEM["Greenwich",0.0],` +
 		`UNIT["Degree",0.0174532925199433]],` +
 		`PROJECTION["Gauss_Kruger"],` +
 		`PARAMETER["False_Easting",%.1f],` +
-		`PARAMETER["False_Northing",0.0],` +
+		`PARAMETER["False_Northing",%s],` +
 		`PARAMETER["Central_Meridian",%.1f],` +
-		`PARAMETER["Scale_Factor",1.0],` +
-		`PARAMETER["Latitude_Of_Origin",0.0],` +
+		`PARAMETER["Scale_Factor",%s],` +
+		`PARAMETER["Latitude_Of_Origin",%s],` +
 		`UNIT["Meter",1.0]]`
-	return fmt.Sprintf(wkt, name, falseEasting, central)
+	return fmt.Sprintf(wkt, name, d.GCSName, d.DatumName, d.Spheroid,
+		strconv.FormatFloat(d.SemiMajor, 'f', 1, 64), strconv.FormatFloat(d.InvFlat, 'f', -1, 64),
+		fe, formatWKTNumber(opts.FalseNorthing), central, formatWKTNumber(opts.scaleFactor()), formatWKTNumber(opts.latitudeOfOrigin()))
+}
+
+// formatWKTNumber 以最短形式输出数值并至少保留一位小数，如 1 -> "1.0"、0.9996 -> "0.9996"。
+func formatWKTNumber(v float64) string {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
 }
@@ -0,0 +1,440 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newCoordinateData 构造一个单地块的解析结果，ys 为各点的 Y 坐标（含带号前缀）。
+func newCoordinateData(attrs map[string]string, ys ...float64) *ParsedData {
+	ring := make(Ring, 0, len(ys))
+	for i, y := range ys {
+		ring = append(ring, Point{ID: i + 1, RingID: 1, X: 3400000 + float64(i)*10, Y: y})
+	}
+	return &ParsedData{
+		Parcels:        []Parcel{{Rings: []Ring{ring}}},
+		FileAttributes: attrs,
+	}
+}
+
+// cgcs2000Attrs 返回 CGCS2000 3 度带第 38 带的文件属性，extra 中的键值覆盖默认值。
+func cgcs2000Attrs(extra map[string]string) map[string]string {
+	attrs := map[string]string{"坐标系": "2000国家大地坐标系", "几度分带": "3", "带号": "38"}
+	for k, v := range extra {
+		attrs[k] = v
+	}
+	return attrs
+}
+
+const testProj4 = "+proj=tmerc +lat_0=0 +lon_0=117 +k=1 +x_0=500000 +y_0=0 +ellps=GRS80 +units=m +no_defs"
+
+func TestBuildCoordinateSystemProjFallback(t *testing.T) {
+	tests := []struct {
+		name      string
+		attrs     map[string]string
+		ys        []float64
+		wantProj4 string // 期望回退到属性中的投影定义
+		wantErr   string // 期望的错误片段，为空表示成功
+	}{
+		{
+			name:      "缺少中文坐标系字段时采用 proj4",
+			attrs:     map[string]string{"proj4": testProj4},
+			ys:        []float64{500000, 500010, 500020},
+			wantProj4: testProj4,
+		},
+		{
+			name:      "坐标系无法识别时采用 proj4",
+			attrs:     map[string]string{"坐标系": "地方坐标系", "几度分带": "3", "带号": "38", "proj4": testProj4},
+			ys:        []float64{500000, 500010, 500020},
+			wantProj4: testProj4,
+		},
+		{
+			name:  "字段齐全时优先使用中文坐标系字段",
+			attrs: cgcs2000Attrs(map[string]string{"proj4": testProj4}),
+			ys:    []float64{38500000, 38500010, 38500020},
+		},
+		{
+			name:    "坐标点跨带的错误不被 proj4 掩盖",
+			attrs:   cgcs2000Attrs(map[string]string{"proj4": testProj4}),
+			ys:      []float64{38500000, 39500010, 39500020},
+			wantErr: "跨越多个投影带",
+		},
+		{
+			name:    "带号超出范围的错误不被 proj4 掩盖",
+			attrs:   cgcs2000Attrs(map[string]string{"带号": "99", "proj4": testProj4}),
+			ys:      []float64{500000, 500010, 500020},
+			wantErr: "带号必须在",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, err := BuildCoordinateSystem(newCoordinateData(tt.attrs, tt.ys...))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuildCoordinateSystem: %v", err)
+			}
+			if tt.wantProj4 != "" {
+				if cs.Proj4 != tt.wantProj4 || cs.EPSG != 0 {
+					t.Errorf("got Proj4=%q EPSG=%d, want fallback %q", cs.Proj4, cs.EPSG, tt.wantProj4)
+				}
+				return
+			}
+			if cs.EPSG != 4526 {
+				t.Errorf("EPSG = %d, want 4526", cs.EPSG)
+			}
+		})
+	}
+}
+
+func TestBuildCGCS2000Proj4(t *testing.T) {
+	tests := []struct {
+		name    string
+		central float64
+		band    int
+		hasBand bool
+		want    string
+	}{
+		{"3 度带第 38 带", 114, 38, true, "+proj=tmerc +lat_0=0 +lon_0=114 +k=1 +x_0=38500000 +y_0=0 +ellps=GRS80 +units=m +no_defs"},
+		{"自定义中央经线", 114.5, 38, false, "+proj=tmerc +lat_0=0 +lon_0=114.5 +k=1 +x_0=500000 +y_0=0 +ellps=GRS80 +units=m +no_defs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildCGCS2000Proj4(tt.central, tt.band, tt.hasBand); got != tt.want {
+				t.Errorf("buildCGCS2000Proj4() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	// 由文件属性构建的坐标系同时给出等价的 PROJ.4 字符串
+	cs, err := BuildCoordinateSystem(newCoordinateData(cgcs2000Attrs(nil), 38500000, 38500010, 38500020))
+	if err != nil {
+		t.Fatalf("BuildCoordinateSystem: %v", err)
+	}
+	if want := buildCGCS2000Proj4(114, 38, true); cs.Proj4 != want {
+		t.Errorf("Proj4 = %q, want %q", cs.Proj4, want)
+	}
+}
+
+func TestProjectionOverrides(t *testing.T) {
+	scale := 0.9996
+	lat := 10.0
+	tests := []struct {
+		name      string
+		opts      ProjectionOptions
+		wantEPSG  int
+		wantWKT   []string
+		wantProj4 []string
+	}{
+		{
+			name:      "标准参数与原输出一致",
+			wantEPSG:  4526,
+			wantWKT:   []string{`"False_Northing",0.0]`, `"Scale_Factor",1.0]`, `"Latitude_Of_Origin",0.0]`},
+			wantProj4: []string{"+lat_0=0 ", "+k=1 ", "+y_0=0 "},
+		},
+		{
+			name:      "比例因子 0.9996",
+			opts:      ProjectionOptions{ScaleFactor: &scale},
+			wantWKT:   []string{`"Scale_Factor",0.9996]`, `"Latitude_Of_Origin",0.0]`},
+			wantProj4: []string{"+k=0.9996 ", "+lat_0=0 "},
+		},
+		{
+			name:      "原点纬度",
+			opts:      ProjectionOptions{LatitudeOfOrigin: &lat},
+			wantWKT:   []string{`"Latitude_Of_Origin",10.0]`, `"Scale_Factor",1.0]`},
+			wantProj4: []string{"+lat_0=10 ", "+k=1 "},
+		},
+		{
+			name:      "北偏移 10000000",
+			opts:      ProjectionOptions{FalseNorthing: 10000000},
+			wantWKT:   []string{`"False_Northing",10000000.0]`, `"Scale_Factor",1.0]`},
+			wantProj4: []string{"+y_0=10000000 ", "+k=1 "},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, err := BuildCoordinateSystemWithOptions(newCoordinateData(cgcs2000Attrs(nil), 38500000, 38500010, 38500020), tt.opts)
+			if err != nil {
+				t.Fatalf("BuildCoordinateSystemWithOptions: %v", err)
+			}
+			if cs.EPSG != tt.wantEPSG {
+				t.Errorf("EPSG = %d, want %d", cs.EPSG, tt.wantEPSG)
+			}
+			for _, want := range tt.wantWKT {
+				if !strings.Contains(cs.WKT, want) {
+					t.Errorf("WKT missing %s:\n%s", want, cs.WKT)
+				}
+			}
+			for _, want := range tt.wantProj4 {
+				if !strings.Contains(cs.Proj4, want) {
+					t.Errorf("Proj4 missing %q: %s", want, cs.Proj4)
+				}
+			}
+		})
+	}
+
+	bad := -1.0
+	if _, err := BuildCoordinateSystemWithOptions(newCoordinateData(cgcs2000Attrs(nil), 38500000), ProjectionOptions{ScaleFactor: &bad}); err == nil {
+		t.Error("非正比例因子应返回错误")
+	}
+}
+
+func TestBuildGeometryPreprocessDataProjection(t *testing.T) {
+	scale := 0.9996
+	parsed, err := Parse(sampleContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := BuildGeometryPreprocessData(parsed, GeometryOptions{Projection: ProjectionOptions{ScaleFactor: &scale}})
+	if err != nil {
+		t.Fatalf("BuildGeometryPreprocessData: %v", err)
+	}
+	if data.EPSG != 0 || !strings.Contains(data.CRS, `"Scale_Factor",0.9996]`) {
+		t.Errorf("CRS = %q (EPSG %d), want overridden WKT without EPSG", data.CRS, data.EPSG)
+	}
+}
+
+func TestSanityCheckExtent(t *testing.T) {
+	// swap 交换所有点的 X/Y，模拟坐标列互换
+	swap := func(pd *ParsedData) *ParsedData {
+		for _, parcel := range pd.Parcels {
+			for _, ring := range parcel.Rings {
+				for i := range ring {
+					ring[i].X, ring[i].Y = ring[i].Y, ring[i].X
+				}
+			}
+		}
+		return pd
+	}
+	tests := []struct {
+		name    string
+		data    *ParsedData
+		wantErr string
+	}{
+		{"带号前缀的正确坐标", newCoordinateData(cgcs2000Attrs(nil), 38500000, 38500010), ""},
+		{"不含带号的正确坐标", newCoordinateData(cgcs2000Attrs(nil), 500000, 500010), ""},
+		{"坐标列互换", swap(newCoordinateData(cgcs2000Attrs(nil), 38500000, 38500010)), "可能互换"},
+		{"带号无效", newCoordinateData(cgcs2000Attrs(nil), 99500000), "东向 (Y)"},
+		{"直接给出投影定义时不检查", swap(newCoordinateData(map[string]string{"proj4": testProj4}, 500000)), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SanityCheckExtent(tt.data)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("SanityCheckExtent: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeriveBandFromPoints(t *testing.T) {
+	tests := []struct {
+		name           string
+		ys             []float64
+		wantBand       int
+		wantConsistent bool
+	}{
+		{"全部同一带", []float64{38500000, 38500010, 38500020}, 38, true},
+		{"无带号前缀", []float64{500000, 500010}, 0, true},
+		{"首点缺少带号前缀", []float64{500000, 38500010, 38500020}, 38, true},
+		{"首点为零", []float64{0, 38500010, 38500020}, 38, true},
+		{"首点带号损坏时取多数", []float64{99500000, 38500010, 38500020}, 38, false},
+		{"跨带取多数", []float64{39500000, 38500010, 38500020, 39500030, 38500040}, 38, false},
+		{"票数相同取较小带号", []float64{39500000, 38500010}, 38, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			band, consistent := deriveBandFromPoints(newCoordinateData(nil, tt.ys...))
+			if band != tt.wantBand || consistent != tt.wantConsistent {
+				t.Errorf("deriveBandFromPoints = (%d, %v), want (%d, %v)", band, consistent, tt.wantBand, tt.wantConsistent)
+			}
+		})
+	}
+}
+
+// zone38Ys 返回 first 之后接 n 个第 38 带东向坐标的序列。
+func zone38Ys(first float64, n int) []float64 {
+	ys := []float64{first}
+	for i := range n {
+		ys = append(ys, 38500000+float64(i)*10)
+	}
+	return ys
+}
+
+func TestBuildCoordinateSystemBandFromPoints(t *testing.T) {
+	tests := []struct {
+		name     string
+		attrs    map[string]string
+		ys       []float64
+		wantBand int
+		wantEPSG int
+		wantWarn string
+		wantErr  string
+	}{
+		{"坐标与属性一致", cgcs2000Attrs(nil), []float64{38500000, 38500010, 38500020}, 38, 4526, "", ""},
+		{"以坐标推断的带号为准", cgcs2000Attrs(map[string]string{"带号": "39"}), []float64{38500000, 38500010, 38500020}, 38, 4526, "", ""},
+		{"首点缺少带号前缀", cgcs2000Attrs(map[string]string{"带号": "39"}), []float64{500000, 38500010, 38500020}, 38, 4526, "", ""},
+		{"跨带报错", cgcs2000Attrs(nil), []float64{38500000, 39500010, 38500020}, 0, 0, "", "坐标点跨越多个投影带（带号 38: 2 点, 带号 39: 1 点）"},
+		{"首点带号损坏按多数带处理并警告", cgcs2000Attrs(nil), zone38Ys(99500000, 20), 38, 4526, "带号 38: 20 点, 带号 99: 1 点", ""},
+		{"带号损坏的点占比较大时报错", cgcs2000Attrs(nil), zone38Ys(99500000, 2), 0, 0, "", "带号 99: 1 点"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pd := newCoordinateData(tt.attrs, tt.ys...)
+			cs, err := BuildCoordinateSystem(pd)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuildCoordinateSystem: %v", err)
+			}
+			if cs.Band != tt.wantBand || cs.EPSG != tt.wantEPSG {
+				t.Errorf("Band = %d, EPSG = %d, want %d, %d", cs.Band, cs.EPSG, tt.wantBand, tt.wantEPSG)
+			}
+			if tt.wantWarn == "" {
+				if len(pd.Warnings) != 0 {
+					t.Errorf("Warnings = %v, want 空", pd.Warnings)
+				}
+			} else if len(pd.Warnings) != 1 || !strings.Contains(pd.Warnings[0], tt.wantWarn) {
+				t.Errorf("Warnings = %v, want 一条包含 %q 的警告", pd.Warnings, tt.wantWarn)
+			}
+		})
+	}
+}
+
+func TestBuildCoordinateSystemMinorityAcrossParcels(t *testing.T) {
+	// 两个地块各有一个第 39 带的点：总占比很小，但分布在多个地块，视为跨带
+	pd := newCoordinateData(cgcs2000Attrs(nil), zone38Ys(39500000, 30)...)
+	pd.Parcels = append(pd.Parcels, newCoordinateData(nil, zone38Ys(39500000, 30)...).Parcels...)
+	if _, err := BuildCoordinateSystem(pd); err == nil || !strings.Contains(err.Error(), "带号 38: 60 点, 带号 39: 2 点") {
+		t.Fatalf("err = %v, want 跨带错误", err)
+	}
+}
+
+func TestBuildCoordinateSystemDatums(t *testing.T) {
+	tests := []struct {
+		name         string
+		coordName    string
+		degree       string
+		band         string
+		y            float64
+		wantDatum    string
+		wantEPSG     int
+		wantEPSGName string
+		wantWKT      []string
+		wantProj4    string
+	}{
+		{
+			name: "西安80 6 度带含带号", coordName: "1980西安坐标系", degree: "6", band: "20", y: 20500000,
+			wantDatum: DatumXian1980, wantEPSG: 2334, wantEPSGName: "Xian 1980 / Gauss-Kruger zone 20",
+			wantWKT:   []string{`GEOGCS["GCS_Xian_1980"`, `SPHEROID["Xian_1980",6378140.0,298.257]`, `PROJCS["Xian_1980_GK_Zone_20"`},
+			wantProj4: "+a=6378140 +rf=298.257",
+		},
+		{
+			name: "西安80 3 度带不含带号", coordName: "西安80", degree: "3", band: "38", y: 500000,
+			wantDatum: DatumXian1980, wantEPSG: 2383, wantEPSGName: "Xian 1980 / 3-degree Gauss-Kruger CM 114E",
+			wantWKT:   []string{`PROJCS["Xian_1980_3_Degree_GK_CM_114E"`},
+			wantProj4: "+lon_0=114 ",
+		},
+		{
+			name: "北京54 3 度带含带号", coordName: "1954北京坐标系", degree: "3", band: "38", y: 38500000,
+			wantDatum: DatumBeijing1954, wantEPSG: 2414, wantEPSGName: "Beijing 1954 / 3-degree Gauss-Kruger zone 38",
+			wantWKT:   []string{`GEOGCS["GCS_Beijing_1954"`, `SPHEROID["Krasovsky_1940",6378245.0,298.3]`},
+			wantProj4: "+ellps=krass",
+		},
+		{
+			name: "北京54 6 度带不含带号", coordName: "Beijing 1954", degree: "6", band: "19", y: 500000,
+			wantDatum: DatumBeijing1954, wantEPSG: 21479, wantEPSGName: "Beijing 1954 / Gauss-Kruger CM 111E",
+			wantWKT:   []string{`PROJCS["Beijing_1954_GK_CM_111E"`},
+			wantProj4: "+x_0=500000 ",
+		},
+		{
+			name: "CGCS2000 仍为默认识别", coordName: "2000国家大地坐标系", degree: "3", band: "38", y: 38500000,
+			wantDatum: DatumCGCS2000, wantEPSG: 4526, wantEPSGName: "CGCS2000 / 3-degree Gauss-Kruger zone 38",
+			wantWKT:   []string{`SPHEROID["CGCS2000",6378137.0,298.257222101]`},
+			wantProj4: "+ellps=GRS80",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := map[string]string{"坐标系": tt.coordName, "几度分带": tt.degree, "带号": tt.band}
+			cs, err := BuildCoordinateSystem(newCoordinateData(attrs, tt.y, tt.y+10, tt.y+20))
+			if err != nil {
+				t.Fatalf("BuildCoordinateSystem: %v", err)
+			}
+			if cs.Datum != tt.wantDatum || cs.EPSG != tt.wantEPSG || cs.EPSGName != tt.wantEPSGName {
+				t.Errorf("Datum/EPSG/EPSGName = %q/%d/%q, want %q/%d/%q", cs.Datum, cs.EPSG, cs.EPSGName, tt.wantDatum, tt.wantEPSG, tt.wantEPSGName)
+			}
+			for _, want := range tt.wantWKT {
+				if !strings.Contains(cs.WKT, want) {
+					t.Errorf("WKT missing %s:\n%s", want, cs.WKT)
+				}
+			}
+			if !strings.Contains(cs.Proj4, tt.wantProj4) {
+				t.Errorf("Proj4 missing %q: %s", tt.wantProj4, cs.Proj4)
+			}
+		})
+	}
+
+	_, err := BuildCoordinateSystem(newCoordinateData(map[string]string{"坐标系": "WGS84", "几度分带": "3", "带号": "38"}, 38500000))
+	if err == nil || !strings.Contains(err.Error(), "无法识别的坐标系") {
+		t.Errorf("err = %v, want 无法识别的坐标系", err)
+	}
+}
+
+func TestEPSGNameBoundaryBands(t *testing.T) {
+	tests := []struct {
+		degree   string
+		band     int
+		hasBand  bool
+		wantEPSG int
+		wantName string
+	}{
+		{"6", 13, true, 4491, "CGCS2000 / Gauss-Kruger zone 13"},
+		{"6", 23, true, 4501, "CGCS2000 / Gauss-Kruger zone 23"},
+		{"6", 13, false, 4502, "CGCS2000 / Gauss-Kruger CM 75E"},
+		{"6", 23, false, 4512, "CGCS2000 / Gauss-Kruger CM 135E"},
+		{"3", 25, true, 4513, "CGCS2000 / 3-degree Gauss-Kruger zone 25"},
+		{"3", 45, true, 4533, "CGCS2000 / 3-degree Gauss-Kruger zone 45"},
+		{"3", 25, false, 4534, "CGCS2000 / 3-degree Gauss-Kruger CM 75E"},
+		{"3", 45, false, 4554, "CGCS2000 / 3-degree Gauss-Kruger CM 135E"},
+	}
+	for _, tt := range tests {
+		y := 500000.0
+		if tt.hasBand {
+			y += float64(tt.band) * 1_000_000
+		}
+		attrs := cgcs2000Attrs(map[string]string{"几度分带": tt.degree, "带号": strconv.Itoa(tt.band)})
+		cs, err := BuildCoordinateSystem(newCoordinateData(attrs, y, y+10))
+		if err != nil {
+			t.Errorf("%s 度带第 %d 带: %v", tt.degree, tt.band, err)
+			continue
+		}
+		if cs.EPSG != tt.wantEPSG || cs.EPSGName != tt.wantName {
+			t.Errorf("%s 度带第 %d 带 (hasBand=%v): EPSG = %d %q, want %d %q", tt.degree, tt.band, tt.hasBand, cs.EPSG, cs.EPSGName, tt.wantEPSG, tt.wantName)
+		}
+		if name, ok := LookupEPSGName(tt.wantEPSG); !ok || name != tt.wantName {
+			t.Errorf("LookupEPSGName(%d) = %q, %v", tt.wantEPSG, name, ok)
+		}
+	}
+
+	for _, code := range []int{4490, 4555, 0} {
+		if name, ok := LookupEPSGName(code); ok {
+			t.Errorf("LookupEPSGName(%d) = %q，超出表范围应返回 false", code, name)
+		}
+	}
+}
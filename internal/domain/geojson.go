@@ -0,0 +1,94 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package domain
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// buildFeatureGeoJSON 按地块 gtype 构建 GeoJSON 几何对象，几何类型规则与 buildFeatureWKT 一致。
+func buildFeatureGeoJSON(parcel Parcel, decimalPlaces int, axis AxisOrder) (json.RawMessage, error) {
+	kind := parcelGeometryKind(parcel)
+	if kind == kindPolygon {
+		return buildPolygonGeoJSON(parcel, decimalPlaces, axis)
+	}
+	if err := validateParcelPoints(parcel, kind); err != nil {
+		return nil, err
+	}
+	hasZ := parcelHasZ(parcel)
+
+	if kind == kindPoint {
+		var pts []Point
+		for _, ring := range parcel.Rings {
+			pts = append(pts, ring...)
+		}
+		coords := buildRingGeoJSON(pts, decimalPlaces, axis, hasZ)
+		if len(pts) == 1 {
+			// 单点坐标为位置本身，去掉外层数组
+			return json.RawMessage(`{"type":"Point","coordinates":` + coords[1:len(coords)-1] + `}`), nil
+		}
+		return json.RawMessage(`{"type":"MultiPoint","coordinates":` + coords + `}`), nil
+	}
+
+	lines := make([]string, 0, len(parcel.Rings))
+	for _, ring := range parcel.Rings {
+		lines = append(lines, buildRingGeoJSON(ring, decimalPlaces, axis, hasZ))
+	}
+	if len(lines) == 1 {
+		return json.RawMessage(`{"type":"LineString","coordinates":` + lines[0] + `}`), nil
+	}
+	return json.RawMessage(`{"type":"MultiLineString","coordinates":[` + strings.Join(lines, ",") + `]}`), nil
+}
+
+// buildPolygonGeoJSON 构建单个地块的 GeoJSON 几何对象（Polygon 或 MultiPolygon）。
+// 坐标轴需显式处理：Point.X 为北向、Point.Y 为东向（测量坐标约定），
+// GeoJSON 位置默认按 [东向, 北向] 即平面 [x, y] 输出，axis 与 WKT 使用同一轴顺序；携带高程时追加第三维。
+// GeoJSON 不支持测量值 (M)，M 将被忽略。
+func buildPolygonGeoJSON(parcel Parcel, decimalPlaces int, axis AxisOrder) (json.RawMessage, error) {
+	if err := validateParcelRings(parcel); err != nil {
+		return nil, err
+	}
+	hasZ := parcelHasZ(parcel)
+
+	polygons := classifyRings(parcel.Rings)
+	polysJSON := make([]string, 0, len(polygons))
+	for _, poly := range polygons {
+		ringsJSON := make([]string, 0, len(poly))
+		for _, ri := range poly {
+			ringsJSON = append(ringsJSON, buildRingGeoJSON(parcel.Rings[ri], decimalPlaces, axis, hasZ))
+		}
+		polysJSON = append(polysJSON, "["+strings.Join(ringsJSON, ",")+"]")
+	}
+
+	if len(polysJSON) == 1 {
+		return json.RawMessage(`{"type":"Polygon","coordinates":` + polysJSON[0] + `}`), nil
+	}
+	return json.RawMessage(`{"type":"MultiPolygon","coordinates":[` + strings.Join(polysJSON, ",") + `]}`), nil
+}
+
+// buildRingGeoJSON 构建 GeoJSON 线性环坐标数组，如 [[x,y],[x,y],...]。
+func buildRingGeoJSON(ring []Point, decimalPlaces int, axis AxisOrder, hasZ bool) string {
+	var builder strings.Builder
+	builder.Grow(len(ring) * (decimalPlaces*2 + 24))
+	builder.WriteByte('[')
+	for i, p := range ring {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteByte('[')
+		first, second := axis.ordered(p)
+		builder.WriteString(strconv.FormatFloat(first, 'f', decimalPlaces, 64))
+		builder.WriteByte(',')
+		builder.WriteString(strconv.FormatFloat(second, 'f', decimalPlaces, 64))
+		if hasZ {
+			builder.WriteByte(',')
+			builder.WriteString(strconv.FormatFloat(p.Z, 'f', decimalPlaces, 64))
+		}
+		builder.WriteByte(']')
+	}
+	builder.WriteByte(']')
+	return builder.String()
+}
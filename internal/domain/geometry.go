@@ -4,8 +4,10 @@ Copyright © 2025 TheMachine <592858548@qq.com>
 package domain
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -13,15 +15,28 @@ import (
 
 // Feature 预处理阶段单个要素
 type Feature struct {
+	WKT        string          `json:"wkt"`
+	GeoJSON    json.RawMessage `json:"geojson,omitempty"` // 可选的 GeoJSON 几何对象，仅在 GeometryOptions.GeoJSON 时生成
+	WKB        []byte          `json:"wkb,omitempty"`     // 可选的 ISO WKB（小端，完整 float64 精度），仅在 GeometryOptions.EmitWKB 时生成
+	BBox       *[4]float64     `json:"bbox,omitempty"`    // 可选的外包矩形 (minX, minY, maxX, maxY)，轴顺序与 WKT 一致，仅在 GeometryOptions.EmitBBox 时生成
+	Attributes map[string]any  `json:"attributes"`
+}
+
+// RejectedFeature 被剔除的要素：WKT 为尽力构建的原始点集（MULTIPOINT），Reason 为剔除原因
+type RejectedFeature struct {
 	WKT        string         `json:"wkt"`
 	Attributes map[string]any `json:"attributes"`
+	Reason     string         `json:"reason"`
 }
 
 // PreprocessData 预处理结果集合
 type PreprocessData struct {
 	CRS      string    `json:"crs"`
 	EPSG     int       `json:"epsg,omitempty"`
+	CRSName  string    `json:"crs_name,omitempty"` // 投影坐标系名称（CoordinateSystem.Name），用于名称模板 {crs}
 	Features []Feature `json:"features"`
+	// Skipped 启用 GeometryOptions.SkipInvalid 时被跳过的地块及原因（同时追加到 ParsedData.Rejected）
+	Skipped []RejectedParcel `json:"-"`
 }
 
 // MaxTolerance 最大允许容差（数字越小精度越高，容差越小精度越高）
@@ -60,6 +75,18 @@ func decimalPlacesFromPrecision(p float64) int {
 	return dec
 }
 
+// maxDecimalPlaces 是显式指定小数位时的上限，超过 float64 有效位数的位数没有意义。
+const maxDecimalPlaces = 15
+
+// outputDecimalPlaces 返回坐标输出小数位：opts.DecimalPlaces>0 时直接采用（不超过 maxDecimalPlaces），
+// 否则按容差推导，使输出位数与去重容差相互独立。
+func outputDecimalPlaces(opts GeometryOptions) int {
+	if opts.DecimalPlaces > 0 {
+		return min(opts.DecimalPlaces, maxDecimalPlaces)
+	}
+	return decimalPlacesFromPrecision(opts.Precision)
+}
+
 // precisionToScale 根据容差推导用于离散化的整型比例（至少与 MaxTolerance 对应精度一致）。
 func precisionToScale(p float64) float64 {
 	p = normalizePrecision(p)
@@ -75,11 +102,56 @@ func precisionToScale(p float64) float64 {
 type gridKey struct{ x, y int64 }
 
 type GeometryOptions struct {
-	Precision   float64 // 容差（<=MaxTolerance）
-	Deduplicate bool    // 是否去重（按坐标+容差）
-	AutoClose   bool    // 是否自动闭合
+	Precision         float64           // 容差（<=MaxTolerance）
+	DecimalPlaces     int               // WKT/GeoJSON 坐标输出小数位，>0 时直接使用（上限 maxDecimalPlaces），0 表示按容差推导（4~6）；不影响去重
+	Deduplicate       bool              // 是否去重（按坐标+容差）
+	DedupMode         DedupMode         // 去重方式，默认 DedupConsecutive
+	AutoClose         bool              // 是否自动闭合
+	MergeLabels       bool              // 去重时将被丢弃点的点号标签合并到保留点（以 LabelSeparator 连接），而不是直接丢弃
+	SimplifyTolerance float64           // Douglas-Peucker 简化容差（米），>0 时在去重之后、闭合之前简化每个环
+	SortByID          bool              // 是否按点号重排环上的点；默认保持源文件中的遍历顺序（点号不沿边界单调时排序会打乱边界）
+	AxisOrder         AxisOrder         // 坐标输出顺序，默认 AxisOrderYX
+	Metrics           bool              // 是否为每个要素附加计算面积与周长属性（KeyComputedArea / KeyComputedPerimeter）
+	GeoJSON           bool              // 是否同时为每个要素生成 GeoJSON 几何
+	EmitWKB           bool              // 是否同时为每个要素生成 WKB 几何（保留完整精度）
+	EmitBBox          bool              // 是否为每个要素计算外包矩形（含洞的顶点）
+	FixWinding        bool              // 是否统一环方向：外环逆时针、洞顺时针
+	SkipInvalid       bool              // 跳过无法构成合法几何的地块（移入 ParsedData.Rejected），而不是让整个文件失败
+	Projection        ProjectionOptions // 投影参数覆盖（原点纬度、比例因子、北偏移），零值表示标准参数
 }
 
+// DedupMode 控制去重方式。
+type DedupMode int
+
+const (
+	// DedupConsecutive 仅移除与前一个保留点距离不超过容差的点（连续重复顶点），为默认值。
+	DedupConsecutive DedupMode = iota
+	// DedupAggressive 坐标离散化后按八邻域去重：与任一已保留点落在相邻格点即视为重复，
+	// 可能合并两个真实存在但距离较近的顶点。
+	DedupAggressive
+)
+
+// AxisOrder 控制 WKT / GeoJSON 中坐标的输出顺序。
+type AxisOrder int
+
+const (
+	// AxisOrderYX 先输出 Y（东向）后输出 X（北向），即平面 x/y 顺序，为默认值以保持兼容。
+	AxisOrderYX AxisOrder = iota
+	// AxisOrderXY 先输出 X（北向）后输出 Y（东向），按测量坐标 X/Y 的字面顺序。
+	AxisOrderXY
+)
+
+// ordered 按轴顺序返回点的前两个输出坐标。
+func (a AxisOrder) ordered(p Point) (first, second float64) {
+	if a == AxisOrderXY {
+		return p.X, p.Y
+	}
+	return p.Y, p.X
+}
+
+// LabelSeparator 是去重合并点号标签时使用的分隔符，如 "J3/Z1"。
+const LabelSeparator = "/"
+
 // BuildGeometryPreprocessData 生成预处理数据：解析精度 -> 几何后处理 -> WKT+属性 -> CRS
 func BuildGeometryPreprocessData(parsed *ParsedData, opts GeometryOptions) (*PreprocessData, error) {
 	if parsed == nil || len(parsed.Parcels) == 0 {
@@ -91,34 +163,69 @@ func BuildGeometryPreprocessData(parsed *ParsedData, opts GeometryOptions) (*Pre
 		opts.Precision = parsePrecision(parsed.FileAttributes["精度"])
 	}
 	opts.Precision = normalizePrecision(opts.Precision)
-	dec := decimalPlacesFromPrecision(opts.Precision)
+	dec := outputDecimalPlaces(opts)
 
 	// 坐标点处理：去除重复点、自动闭合、有效性检查
-	if err := postProcessGeometry(parsed, opts); err != nil {
+	rejectedBefore := len(parsed.Rejected)
+	if err := PostProcessGeometry(parsed, opts); err != nil {
 		return nil, fmt.Errorf("坐标点处理失败: %w", err)
 	}
+	if len(parsed.Parcels) == 0 {
+		// 仅在 SkipInvalid 时出现：所有地块均被跳过，返回空要素集
+		return &PreprocessData{Skipped: parsed.Rejected[rejectedBefore:]}, nil
+	}
 
-	coordSystem, err := BuildCoordinateSystem(parsed)
+	coordSystem, err := BuildCoordinateSystemWithOptions(parsed, opts.Projection)
 	if err != nil {
 		return nil, fmt.Errorf("坐标系构建失败: %w", err)
 	}
 
 	features := make([]Feature, 0, len(parsed.Parcels))
 	for _, parcel := range parsed.Parcels {
-		wkt, err := buildPolygonWKTInternal(parcel, dec)
+		wkt, err := buildFeatureWKT(parcel, dec, opts.AxisOrder)
+		var geoJSON json.RawMessage
+		if err == nil && opts.GeoJSON {
+			geoJSON, err = buildFeatureGeoJSON(parcel, dec, opts.AxisOrder)
+		}
+		var wkb []byte
+		if err == nil && opts.EmitWKB {
+			wkb, err = buildFeatureWKB(parcel, opts.AxisOrder)
+		}
 		if err != nil {
+			if opts.SkipInvalid {
+				parsed.Rejected = append(parsed.Rejected, RejectedParcel{Parcel: parcel, Reason: err.Error()})
+				continue
+			}
 			// 有一个地块错误，那么为了数据完整性,整个预处理都视为失败
 			// err 中已经包含了地块标识,这里不需要再次添加
 			return nil, err
 		}
-		attrs := mapAttributes(parcel.Attributes)
+		var bbox *[4]float64
+		if opts.EmitBBox {
+			bbox = parcelBBox(parcel, opts.AxisOrder)
+		}
+		attrs := mapAttributes(parcel)
+		if opts.Metrics {
+			if attrs == nil {
+				attrs = make(map[string]any, 2)
+			}
+			attrs[KeyComputedArea] = ParcelArea(parcel)
+			attrs[KeyComputedPerimeter] = ParcelPerimeter(parcel)
+		}
 		features = append(features, Feature{
 			WKT:        wkt,
+			GeoJSON:    geoJSON,
+			WKB:        wkb,
+			BBox:       bbox,
 			Attributes: attrs,
 		})
 	}
 
 	crs := coordSystem.WKT
+	if crs == "" && coordSystem.Proj4 != "" {
+		// QGIS 通过 "PROJ4:" 前缀识别 PROJ.4 定义
+		crs = "PROJ4:" + coordSystem.Proj4
+	}
 	epsg := 0
 	if coordSystem.EPSG > 0 {
 		epsg = coordSystem.EPSG
@@ -128,35 +235,309 @@ func BuildGeometryPreprocessData(parsed *ParsedData, opts GeometryOptions) (*Pre
 	return &PreprocessData{
 		CRS:      crs,
 		EPSG:     epsg,
+		CRSName:  coordSystem.Name,
 		Features: features,
+		Skipped:  parsed.Rejected[rejectedBefore:],
 	}, nil
 }
 
+// BuildRejectedFeatures 将解析阶段被剔除的地块转换为 RejectedFeature。
+// 坐标轴顺序与小数位与 BuildGeometryPreprocessData 使用同一 opts（精度未设置时按文件 "精度" 推导），使留档与正常输出一致。
+// 独立于 BuildGeometryPreprocessData，确保即使后续几何处理失败，被剔除的地块也不会丢失。
+func BuildRejectedFeatures(parsed *ParsedData, opts GeometryOptions) []RejectedFeature {
+	if parsed == nil || len(parsed.Rejected) == 0 {
+		return nil
+	}
+	if opts.Precision <= 0 && parsed.FileAttributes != nil {
+		opts.Precision = parsePrecision(parsed.FileAttributes["精度"])
+	}
+	opts.Precision = normalizePrecision(opts.Precision)
+	dec := outputDecimalPlaces(opts)
+	rejected := make([]RejectedFeature, 0, len(parsed.Rejected))
+	for _, rp := range parsed.Rejected {
+		rejected = append(rejected, RejectedFeature{
+			WKT:        buildRawPointsWKT(rp.Parcel, dec, opts.AxisOrder),
+			Attributes: mapAttributes(rp.Parcel),
+			Reason:     rp.Reason,
+		})
+	}
+	return rejected
+}
+
+// buildRawPointsWKT 将地块所有原始点按顺序输出为 MULTIPOINT，用于无法构成合法多边形的地块留档；无点时返回空串
+func buildRawPointsWKT(parcel Parcel, decimalPlaces int, axis AxisOrder) string {
+	var pts []string
+	for _, ring := range parcel.Rings {
+		for _, p := range ring {
+			pts = append(pts, buildRingWKTInternal([]Point{p}, decimalPlaces, axis, false, false))
+		}
+	}
+	if len(pts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("MULTIPOINT (%s)", strings.Join(pts, ", "))
+}
+
+// geometryKind 地块的几何类型，由地块属性 gtype（图形属性）决定。
+type geometryKind int
+
+const (
+	kindPolygon geometryKind = iota // 面（默认）
+	kindLine                        // 线
+	kindPoint                       // 点
+)
+
+// parcelGeometryKind 根据 gtype 判断几何类型："点" 为点、"线" 为线，其它值（含 "面" 与空值）均按面处理。
+func parcelGeometryKind(parcel Parcel) geometryKind {
+	switch strings.ToLower(strings.TrimSpace(parcel.Attributes[KeyGType])) {
+	case "点", "point":
+		return kindPoint
+	case "线", "line", "linestring":
+		return kindLine
+	}
+	return kindPolygon
+}
+
+// minPoints 返回该几何类型每个环处理后至少需要的点数。
+func (k geometryKind) minPoints() int {
+	switch k {
+	case kindPoint:
+		return 1
+	case kindLine:
+		return 2
+	}
+	return 4
+}
+
+// dimensionSuffix 返回 WKT 几何类型的维度后缀，如 " Z"、" M"、" ZM"。
+func dimensionSuffix(hasZ, hasM bool) string {
+	switch {
+	case hasZ && hasM:
+		return " ZM"
+	case hasZ:
+		return " Z"
+	case hasM:
+		return " M"
+	}
+	return ""
+}
+
+// buildFeatureWKT 按地块 gtype 构建 WKT：点输出 POINT/MULTIPOINT，线输出 LINESTRING/MULTILINESTRING（每个环为一条线），
+// 其它按面输出 POLYGON/MULTIPOLYGON。
+func buildFeatureWKT(parcel Parcel, decimalPlaces int, axis AxisOrder) (string, error) {
+	kind := parcelGeometryKind(parcel)
+	if kind == kindPolygon {
+		return buildPolygonWKTInternal(parcel, decimalPlaces, axis)
+	}
+	if err := validateParcelPoints(parcel, kind); err != nil {
+		return "", err
+	}
+	hasM := parcelHasM(parcel)
+	hasZ := parcelHasZ(parcel)
+	suffix := dimensionSuffix(hasZ, hasM)
+
+	if kind == kindPoint {
+		var pts []string
+		for _, ring := range parcel.Rings {
+			for _, p := range ring {
+				pts = append(pts, buildRingWKTInternal([]Point{p}, decimalPlaces, axis, hasZ, hasM))
+			}
+		}
+		if len(pts) == 1 {
+			return "POINT" + suffix + " " + pts[0], nil
+		}
+		return fmt.Sprintf("MULTIPOINT%s (%s)", suffix, strings.Join(pts, ", ")), nil
+	}
+
+	lines := make([]string, 0, len(parcel.Rings))
+	for _, ring := range parcel.Rings {
+		lines = append(lines, buildRingWKTInternal(ring, decimalPlaces, axis, hasZ, hasM))
+	}
+	if len(lines) == 1 {
+		return "LINESTRING" + suffix + " " + lines[0], nil
+	}
+	return fmt.Sprintf("MULTILINESTRING%s (%s)", suffix, strings.Join(lines, ", ")), nil
+}
+
+// validateParcelPoints 校验点/线地块至少包含一个环，且每个环的点数满足该几何类型的最低要求。
+func validateParcelPoints(parcel Parcel, kind geometryKind) error {
+	parcelID := parcel.Attributes[KeyPID]
+	if parcelID == "" {
+		parcelID = "(未命名地块)"
+	}
+	if len(parcel.Rings) == 0 {
+		return fmt.Errorf("地块 %s 不包含任何点", parcelID)
+	}
+	for _, ring := range parcel.Rings {
+		if len(ring) < kind.minPoints() {
+			return fmt.Errorf("地块 %s 的一个环点数少于%d, 无法构成有效几何", parcelID, kind.minPoints())
+		}
+	}
+	return nil
+}
+
 // buildPolygonWKTInternal 构建单个地块的WKT
-func buildPolygonWKTInternal(parcel Parcel, decimalPlaces int) (string, error) {
+func buildPolygonWKTInternal(parcel Parcel, decimalPlaces int, axis AxisOrder) (string, error) {
+	if err := validateParcelRings(parcel); err != nil {
+		return "", err
+	}
+	// 仅当所有点都携带测量值时输出 POLYGON M，避免部分点缺失 M 导致维度不一致
+	hasM := parcelHasM(parcel)
+	// 任一点携带高程即输出 POLYGON Z，缺少高程的点按 0 输出
+	hasZ := parcelHasZ(parcel)
+
+	// 按包含关系将环分组为多边形：外环在前，其内的环作为洞
+	polygons := classifyRings(parcel.Rings)
+	polysWKT := make([]string, 0, len(polygons))
+	for _, poly := range polygons {
+		ringsWKT := make([]string, 0, len(poly))
+		for _, ri := range poly {
+			ringsWKT = append(ringsWKT, buildRingWKTInternal(parcel.Rings[ri], decimalPlaces, axis, hasZ, hasM))
+		}
+		polysWKT = append(polysWKT, "("+strings.Join(ringsWKT, ", ")+")")
+	}
+
+	geomType := "POLYGON"
+	if len(polysWKT) > 1 {
+		geomType = "MULTIPOLYGON"
+	}
+	geomType += dimensionSuffix(hasZ, hasM)
+	if len(polysWKT) == 1 {
+		return geomType + " " + polysWKT[0], nil
+	}
+	return fmt.Sprintf("%s (%s)", geomType, strings.Join(polysWKT, ", ")), nil
+}
+
+// validateParcelRings 校验地块至少包含一个环，且每个环点数不少于 4 并首尾闭合。
+func validateParcelRings(parcel Parcel) error {
 	parcelID := parcel.Attributes[KeyPID]
 	if parcelID == "" {
 		parcelID = "(未命名地块)"
 	}
 
 	if len(parcel.Rings) == 0 {
-		return "", fmt.Errorf("地块 %s 不包含任何环", parcelID)
+		return fmt.Errorf("地块 %s 不包含任何环", parcelID)
 	}
-	var ringsWKT []string
 	for _, ring := range parcel.Rings {
 		if len(ring) < 4 {
-			return "", fmt.Errorf("地块 %s 的一个环点数少于4, 无法构成有效多边形", parcelID)
+			return fmt.Errorf("地块 %s 的一个环点数少于4, 无法构成有效多边形", parcelID)
 		}
-		if ring[0].ID != ring[len(ring)-1].ID {
-			return "", fmt.Errorf("地块 %s 的一个环不是闭合的", parcelID)
+		// 以坐标而非点号判断闭合：闭合点可能沿用首点点号，也可能是源文件中的独立点号
+		first, last := ring[0], ring[len(ring)-1]
+		if first.X != last.X || first.Y != last.Y {
+			return fmt.Errorf("地块 %s 的一个环不是闭合的", parcelID)
 		}
-		ringsWKT = append(ringsWKT, buildRingWKTInternal(ring, decimalPlaces))
 	}
-	return fmt.Sprintf("POLYGON (%s)", strings.Join(ringsWKT, ", ")), nil
+	return nil
 }
 
-// buildRingWKTInternal 构建WKT环
-func buildRingWKTInternal(ring []Point, decimalPlaces int) string {
+// classifyRings 按包含关系对闭合环分组，返回每个多边形的环下标（首个为外环，其余为洞）。
+// 环按面积从大到小处理：完全位于某外环内的环成为其洞；位于洞内的环（飞地中的岛）作为新的外环；
+// 不被任何环包含的环作为独立外环，多个外环最终输出为 MULTIPOLYGON。
+func classifyRings(rings []Ring) [][]int {
+	order := make([]int, len(rings))
+	areas := make([]float64, len(rings))
+	for i, ring := range rings {
+		order[i] = i
+		areas[i] = math.Abs(RingArea(ring))
+	}
+	sort.SliceStable(order, func(a, b int) bool { return areas[order[a]] > areas[order[b]] })
+
+	var polygons [][]int
+	placed := make([]int, 0, len(rings))     // 已处理的环，按面积降序
+	isShell := make(map[int]int, len(rings)) // 外环下标 -> polygons 中的位置
+	for _, ri := range order {
+		// 面积降序遍历时，最后一个包含当前环的已处理环即为最内层的容器
+		container := -1
+		for _, pi := range placed {
+			if ringInside(rings[ri], rings[pi]) {
+				container = pi
+			}
+		}
+		placed = append(placed, ri)
+		if pos, ok := isShell[container]; ok {
+			polygons[pos] = append(polygons[pos], ri)
+			continue
+		}
+		isShell[ri] = len(polygons)
+		polygons = append(polygons, []int{ri})
+	}
+	// 洞按源文件中的顺序输出，多边形按外环在源文件中的顺序输出
+	for _, poly := range polygons {
+		slices.Sort(poly[1:])
+	}
+	sort.SliceStable(polygons, func(a, b int) bool { return polygons[a][0] < polygons[b][0] })
+	return polygons
+}
+
+// ringInside 判断环 inner 的所有顶点是否都位于闭合环 outer 内部（射线法）。
+func ringInside(inner, outer Ring) bool {
+	for i := 0; i+1 < len(inner); i++ {
+		if !pointInRing(inner[i], outer) {
+			return false
+		}
+	}
+	return true
+}
+
+// pointInRing 使用射线法判断点是否位于闭合环内部。
+func pointInRing(p Point, ring Ring) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.X > p.X) != (b.X > p.X) && p.Y < (b.Y-a.Y)*(p.X-a.X)/(b.X-a.X)+a.Y {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// parcelBBox 一次遍历地块所有环（含洞）的点，按 axis 顺序返回外包矩形 (minX, minY, maxX, maxY)；无点时返回 nil。
+func parcelBBox(parcel Parcel, axis AxisOrder) *[4]float64 {
+	bbox := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	found := false
+	for _, ring := range parcel.Rings {
+		for _, p := range ring {
+			x, y := axis.ordered(p)
+			bbox[0], bbox[1] = min(bbox[0], x), min(bbox[1], y)
+			bbox[2], bbox[3] = max(bbox[2], x), max(bbox[3], y)
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return &bbox
+}
+
+// parcelHasM 判断地块的所有点是否都携带测量值。
+func parcelHasM(parcel Parcel) bool {
+	found := false
+	for _, ring := range parcel.Rings {
+		for _, p := range ring {
+			if !p.HasM {
+				return false
+			}
+			found = true
+		}
+	}
+	return found
+}
+
+// parcelHasZ 判断地块是否有任一点携带高程。
+func parcelHasZ(parcel Parcel) bool {
+	for _, ring := range parcel.Rings {
+		for _, p := range ring {
+			if p.HasZ {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// buildRingWKTInternal 构建WKT环；hasZ/hasM 为 true 时每个点依次追加高程与测量值 (x y [z] [m])，均按 decimalPlaces 位小数输出
+func buildRingWKTInternal(ring []Point, decimalPlaces int, axis AxisOrder, hasZ, hasM bool) string {
 	if len(ring) == 0 {
 		return "()"
 	}
@@ -168,114 +549,271 @@ func buildRingWKTInternal(ring []Point, decimalPlaces int) string {
 		if i > 0 {
 			builder.WriteString(", ")
 		}
-		y := strconv.FormatFloat(p.Y, 'f', decimalPlaces, 64)
-		x := strconv.FormatFloat(p.X, 'f', decimalPlaces, 64)
-		builder.WriteString(y)
+		first, second := axis.ordered(p)
+		builder.WriteString(strconv.FormatFloat(first, 'f', decimalPlaces, 64))
 		builder.WriteByte(' ')
-		builder.WriteString(x)
+		builder.WriteString(strconv.FormatFloat(second, 'f', decimalPlaces, 64))
+		if hasZ {
+			builder.WriteByte(' ')
+			builder.WriteString(strconv.FormatFloat(p.Z, 'f', decimalPlaces, 64))
+		}
+		if hasM {
+			builder.WriteByte(' ')
+			builder.WriteString(strconv.FormatFloat(p.M, 'f', decimalPlaces, 64))
+		}
 	}
 	builder.WriteByte(')')
 	return builder.String()
 }
 
-// mapAttributes 属性映射
-func mapAttributes(attrs map[string]string) map[string]any {
-	if attrs == nil {
+// ExtraAttrPrefix 是额外字段在要素属性中的键名前缀，依次为 extra_1、extra_2 ...
+const ExtraAttrPrefix = "extra_"
+
+// mapAttributes 属性映射：已知字段原样复制，额外字段按顺序映射为 extra_1、extra_2 ...
+func mapAttributes(parcel Parcel) map[string]any {
+	attrs := parcel.Attributes
+	if attrs == nil && len(parcel.ExtraAttributes) == 0 {
 		return nil
 	}
-	m := make(map[string]any, len(attrs))
+	m := make(map[string]any, len(attrs)+len(parcel.ExtraAttributes))
 	for k, v := range attrs {
 		m[k] = v
 	}
+	for i, v := range parcel.ExtraAttributes {
+		m[ExtraAttrPrefix+strconv.Itoa(i+1)] = v
+	}
 	return m
 }
 
-// postProcessGeometry 对所有地块环进行高性能去重与自动闭合（包内部方法）。
-func postProcessGeometry(data *ParsedData, opts GeometryOptions) error {
+// PostProcessGeometry 就地对所有地块环进行去重、简化、自动闭合与方向统一，是解析之后的几何后处理入口。
+// opts.Precision 未设置时依次采用文件属性 "精度" 与 MaxTolerance。环为空或处理后点数不足时返回错误；
+// opts.SkipInvalid 为 true 时改为将该地块（保留原始点）移入 data.Rejected 并继续处理其余地块。
+// 处理结果可再交给 ValidateGeometry 检查几何问题。
+func PostProcessGeometry(data *ParsedData, opts GeometryOptions) error {
+	if data == nil {
+		return fmt.Errorf("parsed data is nil")
+	}
+	if opts.Precision <= 0 && data.FileAttributes != nil {
+		opts.Precision = parsePrecision(data.FileAttributes["精度"])
+	}
 	prec := normalizePrecision(opts.Precision)
 	scale := precisionToScale(prec)
-	for pi := range data.Parcels {
-		parcelID := data.Parcels[pi].Attributes[KeyPID]
-		if parcelID == "" {
-			parcelID = fmt.Sprintf("#%d", pi+1) // 如果没有地块编号，使用索引
-		}
-		for ri, ring := range data.Parcels[pi].Rings {
-			if len(ring) == 0 {
-				// 空环应该报错，而不是跳过，保证数据完整性
-				return fmt.Errorf("地块 %s 的环 %d 为空", parcelID, ri+1)
+	kept := data.Parcels[:0]
+	for pi, parcel := range data.Parcels {
+		var original Parcel
+		if opts.SkipInvalid {
+			original = cloneParcel(parcel)
+		}
+		if err := postProcessParcel(&parcel, parcelLabel(parcel, pi), scale, prec, opts); err != nil {
+			if !opts.SkipInvalid {
+				return err
 			}
-			processedRing := processRing(ring, scale, prec, opts.Deduplicate, opts.AutoClose)
+			data.Rejected = append(data.Rejected, RejectedParcel{Parcel: original, Reason: err.Error()})
+			continue
+		}
+		kept = append(kept, parcel)
+	}
+	clear(data.Parcels[len(kept):])
+	data.Parcels = kept
+	return nil
+}
 
-			// 验证处理后的环是否仍然有效（至少需要4个点才能构成有效多边形）
-			if len(processedRing) < 4 {
-				return fmt.Errorf("地块 %s 的环 %d 处理后点数不足(原始: %d, 处理后: %d, 需要至少4个点)",
-					parcelID, ri+1, len(ring), len(processedRing))
+// postProcessParcel 就地处理单个地块的所有环，parcelID 用于错误信息。
+func postProcessParcel(parcel *Parcel, parcelID string, scale, prec float64, opts GeometryOptions) error {
+	kind := parcelGeometryKind(*parcel)
+	for ri, ring := range parcel.Rings {
+		if len(ring) == 0 {
+			// 空环应该报错，而不是跳过，保证数据完整性
+			return fmt.Errorf("地块 %s 的环 %d 为空", parcelID, ri+1)
+		}
+		var processedRing []Point
+		if kind == kindPoint {
+			// 点要素只去除连续重复点，不做闭合与简化
+			processedRing = ring
+			if opts.Deduplicate {
+				processedRing = deduplicateConsecutive(ring, prec, opts.MergeLabels)
 			}
+		} else {
+			processedRing = processRing(ring, scale, prec, opts, kind == kindPolygon)
+		}
 
-			data.Parcels[pi].Rings[ri] = processedRing
+		// 验证处理后的环是否仍然有效（面至少需要4个点，线至少2个点，点至少1个点）
+		if need := kind.minPoints(); len(processedRing) < need {
+			return fmt.Errorf("地块 %s 的环 %d 处理后点数不足(原始: %d, 处理后: %d, 需要至少%d个点)",
+				parcelID, ri+1, len(ring), len(processedRing), need)
 		}
+
+		parcel.Rings[ri] = processedRing
+	}
+	if opts.FixWinding && kind == kindPolygon {
+		fixParcelWinding(parcel.Rings)
 	}
 	return nil
 }
 
-// processRing 执行单个环的：可选去重 -> 可选自动闭合 -> 排序（保持闭合点最后）。
-func processRing(ring []Point, scale, prec float64, dedup, autoClose bool) []Point {
+// cloneParcel 深拷贝地块的环，使后续就地处理不影响副本。
+func cloneParcel(parcel Parcel) Parcel {
+	rings := make([]Ring, len(parcel.Rings))
+	for i, ring := range parcel.Rings {
+		rings[i] = slices.Clone(ring)
+	}
+	parcel.Rings = rings
+	return parcel
+}
+
+// parcelLabel 返回用于报告的地块标识：地块编号，缺失时为 "#序号"（index 从 0 开始）。
+func parcelLabel(parcel Parcel, index int) string {
+	if pid := parcel.Attributes[KeyPID]; pid != "" {
+		return pid
+	}
+	return fmt.Sprintf("#%d", index+1)
+}
+
+// fixParcelWinding 按包含关系区分外环与洞，将外环统一为逆时针、洞统一为顺时针。
+func fixParcelWinding(rings []Ring) {
+	for _, poly := range classifyRings(rings) {
+		for i, ri := range poly {
+			rings[ri] = normalizeWinding(rings[ri], i == 0)
+		}
+	}
+}
+
+// normalizeWinding 按有向面积（鞋带公式，以东向为横轴、北向为纵轴）调整环方向：
+// exterior 为 true 时保证逆时针，否则保证顺时针；面积为 0 的退化环原样返回。
+func normalizeWinding(ring []Point, exterior bool) []Point {
+	area := RingArea(ring)
+	if area == 0 || (area > 0) == exterior {
+		return ring
+	}
+	reversed := slices.Clone(ring)
+	slices.Reverse(reversed)
+	return reversed
+}
+
+// processRing 执行单个环的：可选去重 -> 打开环（移除尾部闭合点）-> 可选按点号排序 -> 可选简化 -> 闭合。
+// 闭合按坐标判断而非点号：尾部所有与首点重合的点（无论点号是否相同）都视为闭合点并移除，
+// 之后再补上且仅补上一个首点副本；原本未闭合的环仅在 polygon 且 opts.AutoClose 时闭合。
+// polygon 为 false 时按折线处理：不自动闭合，简化只保留首尾点（至少 2 个点）。
+// 点的顺序即多边形边界的遍历顺序，仅在 opts.SortByID 时按点号重排。
+func processRing(ring []Point, scale, prec float64, opts GeometryOptions, polygon bool) []Point {
 	r := ring
-	if dedup {
-		r = deduplicateRing(r, scale)
+	if opts.Deduplicate {
+		if opts.DedupMode == DedupAggressive {
+			r = deduplicateRing(r, scale, opts.MergeLabels)
+		} else {
+			r = deduplicateConsecutive(r, prec, opts.MergeLabels)
+		}
 	}
-	closed := false
-	if autoClose && len(r) > 1 && !pointsEqual(r[0], r[len(r)-1], prec) {
-		r = autoCloseRing(r, prec)
-		closed = true
+	open, wasClosed := openRing(r, prec)
+	r = open
+	if opts.SortByID && len(r) > 1 {
+		// 稳定排序：点号数字相同（如 J1 与 Z1）时保持原始先后顺序
+		sort.SliceStable(r, func(i, j int) bool { return r[i].ID < r[j].ID })
 	}
-	sortLen := len(r)
-	if closed {
-		sortLen--
+	if !polygon {
+		if wasClosed {
+			r = autoCloseRing(r, prec)
+		}
+		if opts.SimplifyTolerance > 0 {
+			r = simplifyLine(r, opts.SimplifyTolerance)
+		}
+		return r
 	}
-	if sortLen > 1 {
-		sort.Slice(r[:sortLen], func(i, j int) bool { return r[i].ID < r[j].ID })
+	if opts.SimplifyTolerance > 0 {
+		r = simplifyRing(r, opts.SimplifyTolerance)
+	}
+	if wasClosed || opts.AutoClose {
+		r = autoCloseRing(r, prec)
 	}
 	return r
 }
 
-// 八邻域去重，坐标离散化后相邻格点均视为重复点
-func deduplicateRing(ring []Point, scale float64) []Point {
+// openRing 移除环尾部所有与首点在容差内重合的点，返回开放的环以及原环是否闭合。
+// 返回的切片容量被截断，后续 append 不会覆盖原始数据。
+func openRing(ring []Point, tol float64) ([]Point, bool) {
+	end := len(ring)
+	for end > 1 && pointsEqual(ring[0], ring[end-1], tol) {
+		end--
+	}
+	return ring[:end:end], end < len(ring)
+}
+
+// deduplicateConsecutive 移除与前一个保留点的欧氏距离不超过 tol 的点，保留首次出现的点。
+// mergeLabels 为 true 时，被丢弃点的标签会合并到前一个保留点上。
+func deduplicateConsecutive(ring []Point, tol float64, mergeLabels bool) []Point {
+	if len(ring) == 0 {
+		return ring
+	}
+	result := make([]Point, 0, len(ring))
+	result = append(result, ring[0])
+	for _, pt := range ring[1:] {
+		last := &result[len(result)-1]
+		if math.Hypot(pt.X-last.X, pt.Y-last.Y) <= tol {
+			if mergeLabels {
+				last.Label = mergeLabel(last.Label, pt.Label)
+			}
+			continue
+		}
+		result = append(result, pt)
+	}
+	return result
+}
+
+// 八邻域去重，坐标离散化后相邻格点均视为重复点，保留首次出现的点。
+// mergeLabels 为 true 时，被丢弃点的标签会合并到与之重合的保留点上。
+func deduplicateRing(ring []Point, scale float64, mergeLabels bool) []Point {
 	if len(ring) == 0 {
 		return ring
 	}
-	seen := make(map[gridKey]struct{}, len(ring))
+	seen := make(map[gridKey]int, len(ring)) // 格点 -> result 中保留点的下标
 	result := make([]Point, 0, len(ring))
 	// 预计算邻域偏移（含自身）
 	neighbor := [...]gridKey{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 0}, {0, 1}, {1, -1}, {1, 0}, {1, 1}}
 	for _, pt := range ring {
 		gx := int64(math.Round(pt.X * scale))
 		gy := int64(math.Round(pt.Y * scale))
-		skip := false
+		kept := -1
 		for _, off := range neighbor {
-			if _, ok := seen[gridKey{gx + off.x, gy + off.y}]; ok {
-				skip = true
+			if idx, ok := seen[gridKey{gx + off.x, gy + off.y}]; ok {
+				kept = idx
 				break
 			}
 		}
-		if skip {
+		if kept >= 0 {
+			if mergeLabels {
+				result[kept].Label = mergeLabel(result[kept].Label, pt.Label)
+			}
 			continue
 		}
-		seen[gridKey{gx, gy}] = struct{}{}
+		seen[gridKey{gx, gy}] = len(result)
 		result = append(result, pt)
 	}
 	return result
 }
 
+// mergeLabel 将 label 追加到已合并的标签 merged 中，空标签或已存在的标签不重复追加。
+func mergeLabel(merged, label string) string {
+	if label == "" {
+		return merged
+	}
+	if merged == "" {
+		return label
+	}
+	if slices.Contains(strings.Split(merged, LabelSeparator), label) {
+		return merged
+	}
+	return merged + LabelSeparator + label
+}
+
 // 自动闭合环，首尾点不在容差范围内则补首点
 // 注意：此函数假设输入的环至少有1个点，调用前已经过验证
 func autoCloseRing(ring []Point, tol float64) []Point {
 	n := len(ring)
 	if n == 0 {
-		// 理论上不应该到达这里，因为在 postProcessGeometry 中已验证
+		// 理论上不应该到达这里，因为在 PostProcessGeometry 中已验证
 		return ring
 	}
-	if !pointsEqual(ring[0], ring[n-1], tol) {
+	if n == 1 || !pointsEqual(ring[0], ring[n-1], tol) {
 		return append(ring, ring[0])
 	}
 	return ring
@@ -0,0 +1,666 @@
+package domain
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// square 返回以 (x0,y0) 为起点、边长为 size 的闭合正方形环，dir>0 时逆时针（东向为横轴、北向为纵轴），否则顺时针。
+func square(x0, y0, size float64, dir int) Ring {
+	// Point.X 为北向、Y 为东向
+	ring := Ring{
+		{ID: 1, X: x0, Y: y0},
+		{ID: 2, X: x0, Y: y0 + size},
+		{ID: 3, X: x0 + size, Y: y0 + size},
+		{ID: 4, X: x0 + size, Y: y0},
+		{ID: 1, X: x0, Y: y0},
+	}
+	if dir < 0 {
+		for i, j := 1, len(ring)-2; i < j; i, j = i+1, j-1 {
+			ring[i], ring[j] = ring[j], ring[i]
+		}
+	}
+	return ring
+}
+
+func TestNormalizeWinding(t *testing.T) {
+	tests := []struct {
+		name     string
+		ring     Ring
+		exterior bool
+		wantSign int // 期望有向面积的符号
+	}{
+		{"顺时针外环反转为逆时针", square(0, 0, 10, -1), true, 1},
+		{"逆时针外环保持", square(0, 0, 10, 1), true, 1},
+		{"逆时针洞反转为顺时针", square(0, 0, 10, 1), false, -1},
+		{"顺时针洞保持", square(0, 0, 10, -1), false, -1},
+		{"零面积环保持原样", Ring{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 2}, {X: 0, Y: 0}}, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := normalizeWinding(tt.ring, tt.exterior)
+			area := RingArea(got)
+			if sign := signOf(area); sign != tt.wantSign {
+				t.Errorf("RingArea = %v, want sign %d", area, tt.wantSign)
+			}
+			if len(got) != len(tt.ring) || got[0] != got[len(got)-1] {
+				t.Errorf("normalizeWinding 应保持点数与闭合: %v", got)
+			}
+		})
+	}
+}
+
+func TestPostProcessGeometryFixWinding(t *testing.T) {
+	newData := func() *ParsedData {
+		outer, hole := square(0, 0, 100, -1), square(10, 10, 10, 1)
+		for i := range hole {
+			hole[i].RingID = 2
+		}
+		return &ParsedData{Parcels: []Parcel{{Attributes: map[string]string{KeyPID: "1"}, Rings: []Ring{outer, hole}}}}
+	}
+
+	data := newData()
+	if err := PostProcessGeometry(data, GeometryOptions{AutoClose: true, FixWinding: true}); err != nil {
+		t.Fatalf("PostProcessGeometry: %v", err)
+	}
+	rings := data.Parcels[0].Rings
+	if a := RingArea(rings[0]); a <= 0 {
+		t.Errorf("外环有向面积 = %v, want > 0（逆时针）", a)
+	}
+	if a := RingArea(rings[1]); a >= 0 {
+		t.Errorf("洞有向面积 = %v, want < 0（顺时针）", a)
+	}
+
+	// 未开启 FixWinding 时保持源文件方向
+	data = newData()
+	if err := PostProcessGeometry(data, GeometryOptions{AutoClose: true}); err != nil {
+		t.Fatalf("PostProcessGeometry: %v", err)
+	}
+	if a := RingArea(data.Parcels[0].Rings[0]); a >= 0 {
+		t.Errorf("未开启 FixWinding 时外环有向面积 = %v, want < 0（保持源方向）", a)
+	}
+}
+
+func signOf(v float64) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
+
+func TestProcessRingKeepsTraversalOrder(t *testing.T) {
+	// 点号 4,1,2,3 沿边界依次排列：按点号排序会改变遍历起点与顶点顺序
+	ring := Ring{
+		{ID: 4, X: 0, Y: 0},
+		{ID: 1, X: 0, Y: 10},
+		{ID: 2, X: 10, Y: 10},
+		{ID: 3, X: 10, Y: 0},
+		{ID: 4, X: 0, Y: 0},
+	}
+	data := &ParsedData{Parcels: []Parcel{{Attributes: map[string]string{KeyPID: "1"}, Rings: []Ring{slices.Clone(ring)}}}}
+	if err := PostProcessGeometry(data, GeometryOptions{Deduplicate: true, AutoClose: true}); err != nil {
+		t.Fatalf("PostProcessGeometry: %v", err)
+	}
+	got := data.Parcels[0].Rings[0]
+	if !slices.Equal(got, ring) {
+		t.Fatalf("环被重排:\n got %v\nwant %v", got, ring)
+	}
+	if a := math.Abs(RingArea(got)); a != 100 {
+		t.Errorf("|RingArea| = %v, want 100（未自相交）", a)
+	}
+}
+
+func TestProcessRingDedupMode(t *testing.T) {
+	// 顶点 2 与顶点 1 在两个方向各相距约 1 个格点（距离约 1.5 个格点，大于容差）：
+	// 连续去重按距离判断应保留，八邻域去重落在相邻格点应合并
+	prec := MaxTolerance
+	scale := precisionToScale(prec)
+	cell := 1 / scale
+	ring := Ring{
+		{ID: 1, X: 0, Y: 0},
+		{ID: 2, X: cell, Y: 1.1 * cell},
+		{ID: 3, X: 0, Y: 10},
+		{ID: 4, X: 10, Y: 10},
+		{ID: 5, X: 10, Y: 0},
+		{ID: 1, X: 0, Y: 0},
+	}
+	tests := []struct {
+		name    string
+		mode    DedupMode
+		wantIDs []int
+	}{
+		{"连续去重保留", DedupConsecutive, []int{1, 2, 3, 4, 5, 1}},
+		{"八邻域去重合并", DedupAggressive, []int{1, 3, 4, 5, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := GeometryOptions{Deduplicate: true, DedupMode: tt.mode, AutoClose: true}
+			got := processRing(slices.Clone(ring), scale, prec, opts, true)
+			ids := make([]int, len(got))
+			for i, pt := range got {
+				ids[i] = pt.ID
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("点号 = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestPostProcessGeometrySkipInvalid(t *testing.T) {
+	// newData 返回示例地块加一个所有点重合、去重后点数不足的地块 "2"
+	newData := func(t *testing.T) *ParsedData {
+		t.Helper()
+		parsed, err := Parse(sampleContent)
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		attrs := maps.Clone(parsed.Parcels[0].Attributes)
+		attrs[KeyPID] = "2"
+		collapsed := Ring{
+			{ID: 1, X: 3400000, Y: 38500000},
+			{ID: 2, X: 3400000, Y: 38500000},
+			{ID: 3, X: 3400000, Y: 38500000},
+			{ID: 1, X: 3400000, Y: 38500000},
+		}
+		parsed.Parcels = append(parsed.Parcels, Parcel{Attributes: attrs, Rings: []Ring{collapsed}})
+		return parsed
+	}
+	opts := GeometryOptions{Deduplicate: true, AutoClose: true}
+
+	t.Run("未开启 SkipInvalid 时报错", func(t *testing.T) {
+		data := newData(t)
+		err := PostProcessGeometry(data, opts)
+		if err == nil || !strings.Contains(err.Error(), "地块 2") {
+			t.Fatalf("err = %v, want 地块 2 点数不足的错误", err)
+		}
+		if len(data.Rejected) != 0 {
+			t.Errorf("Rejected = %v, want 空", data.Rejected)
+		}
+	})
+
+	t.Run("开启 SkipInvalid 时移入 Rejected", func(t *testing.T) {
+		data := newData(t)
+		skipOpts := opts
+		skipOpts.SkipInvalid = true
+		if err := PostProcessGeometry(data, skipOpts); err != nil {
+			t.Fatalf("PostProcessGeometry: %v", err)
+		}
+		if len(data.Parcels) != 1 || data.Parcels[0].Attributes[KeyPID] != "1" {
+			t.Fatalf("保留的地块 = %v, want 仅地块 1", data.Parcels)
+		}
+		if len(data.Rejected) != 1 {
+			t.Fatalf("Rejected 数 = %d, want 1", len(data.Rejected))
+		}
+		rp := data.Rejected[0]
+		if rp.Parcel.Attributes[KeyPID] != "2" || !strings.Contains(rp.Reason, "点数不足") {
+			t.Errorf("Rejected = %+v, want 地块 2 及点数不足原因", rp)
+		}
+		if n := len(rp.Parcel.Rings[0]); n != 4 {
+			t.Errorf("被剔除地块应保留原始 4 个点，实际 %d 个", n)
+		}
+	})
+
+	t.Run("预处理报告 Skipped", func(t *testing.T) {
+		skipOpts := opts
+		skipOpts.SkipInvalid = true
+		prep, err := BuildGeometryPreprocessData(newData(t), skipOpts)
+		if err != nil {
+			t.Fatalf("BuildGeometryPreprocessData: %v", err)
+		}
+		if len(prep.Features) != 1 {
+			t.Errorf("要素数 = %d, want 1", len(prep.Features))
+		}
+		if len(prep.Skipped) != 1 || prep.Skipped[0].Parcel.Attributes[KeyPID] != "2" || prep.Skipped[0].Reason == "" {
+			t.Errorf("Skipped = %+v, want 地块 2 及原因", prep.Skipped)
+		}
+	})
+}
+
+func TestBuildGeometryPreprocessDataBBox(t *testing.T) {
+	build := func(emit bool) *PreprocessData {
+		t.Helper()
+		parsed, err := Parse(sampleContent)
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		// 在三角形内加入一个洞
+		hole := Ring{
+			{ID: 4, RingID: 2, X: 3400010, Y: 38500050},
+			{ID: 5, RingID: 2, X: 3400010, Y: 38500080},
+			{ID: 6, RingID: 2, X: 3400040, Y: 38500080},
+			{ID: 4, RingID: 2, X: 3400010, Y: 38500050},
+		}
+		parsed.Parcels[0].Rings = append(parsed.Parcels[0].Rings, hole)
+		prep, err := BuildGeometryPreprocessData(parsed, GeometryOptions{AutoClose: true, EmitBBox: emit})
+		if err != nil {
+			t.Fatalf("BuildGeometryPreprocessData: %v", err)
+		}
+		return prep
+	}
+
+	if bbox := build(false).Features[0].BBox; bbox != nil {
+		t.Errorf("未开启 EmitBBox 时 BBox = %v, want nil", *bbox)
+	}
+	bbox := build(true).Features[0].BBox
+	want := [4]float64{38500000, 3400000, 38500100, 3400100}
+	if bbox == nil || *bbox != want {
+		t.Fatalf("BBox = %v, want %v", bbox, want)
+	}
+}
+
+func TestParcelBBox(t *testing.T) {
+	outer := square(0, 0, 10, 1)
+	// 第二个环超出外环范围，用于确认所有环的顶点都参与计算
+	extra := square(-5, 20, 2, -1)
+	tests := []struct {
+		name   string
+		parcel Parcel
+		axis   AxisOrder
+		want   *[4]float64
+	}{
+		{"单环 YX", Parcel{Rings: []Ring{outer}}, AxisOrderYX, &[4]float64{0, 0, 10, 10}},
+		{"含其它环的顶点", Parcel{Rings: []Ring{outer, extra}}, AxisOrderYX, &[4]float64{0, -5, 22, 10}},
+		{"XY 轴顺序", Parcel{Rings: []Ring{outer, extra}}, AxisOrderXY, &[4]float64{-5, 0, 10, 22}},
+		{"无点", Parcel{Rings: []Ring{{}}}, AxisOrderYX, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parcelBBox(tt.parcel, tt.axis)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("parcelBBox = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// polygonParcel 返回由给定环组成的面地块。
+func polygonParcel(rings ...Ring) Parcel {
+	return Parcel{Attributes: map[string]string{KeyPID: "1"}, Rings: rings}
+}
+
+func TestClassifyRings(t *testing.T) {
+	outer, hole, island, other := square(0, 0, 100, 1), square(10, 10, 50, -1), square(20, 20, 10, 1), square(200, 200, 10, 1)
+	tests := []struct {
+		name     string
+		rings    []Ring
+		want     [][]int
+		wantType string
+	}{
+		{"单个外环", []Ring{outer}, [][]int{{0}}, "POLYGON (("},
+		{"带洞的面", []Ring{outer, hole}, [][]int{{0, 1}}, "POLYGON ((0.0 0.0"},
+		{"洞在外环之前", []Ring{hole, outer}, [][]int{{1, 0}}, "POLYGON ((0.0 0.0"},
+		{"两个分离的外环", []Ring{outer, other}, [][]int{{0}, {1}}, "MULTIPOLYGON (((0.0 0.0"},
+		{"洞中的岛为新的外环", []Ring{outer, hole, island}, [][]int{{0, 1}, {2}}, "MULTIPOLYGON ("},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyRings(tt.rings)
+			if !slices.EqualFunc(got, tt.want, slices.Equal[[]int]) {
+				t.Errorf("classifyRings = %v, want %v", got, tt.want)
+			}
+			wkt, err := buildPolygonWKTInternal(polygonParcel(tt.rings...), 1, AxisOrderYX)
+			if err != nil {
+				t.Fatalf("buildPolygonWKTInternal: %v", err)
+			}
+			if !strings.HasPrefix(wkt, tt.wantType) {
+				t.Errorf("WKT = %s, want prefix %q", wkt, tt.wantType)
+			}
+		})
+	}
+
+	wkt, err := buildPolygonWKTInternal(polygonParcel(outer, hole), 0, AxisOrderYX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "POLYGON ((0 0, 100 0, 100 100, 0 100, 0 0), (10 10, 10 60, 60 60, 60 10, 10 10))"; wkt != want {
+		t.Errorf("WKT =\n%s\nwant\n%s", wkt, want)
+	}
+}
+
+func TestBuildPolygonGeoJSONRoundTrip(t *testing.T) {
+	outer, hole := square(3400000, 38500000, 100, 1), square(3400010, 38500010, 10, -1)
+	parcel := polygonParcel(outer, hole)
+	for _, axis := range []AxisOrder{AxisOrderYX, AxisOrderXY} {
+		raw, err := buildPolygonGeoJSON(parcel, 3, axis)
+		if err != nil {
+			t.Fatalf("buildPolygonGeoJSON: %v", err)
+		}
+		var geom struct {
+			Type        string
+			Coordinates [][][2]float64
+		}
+		if err := json.Unmarshal(raw, &geom); err != nil {
+			t.Fatalf("无效的 GeoJSON %s: %v", raw, err)
+		}
+		if geom.Type != "Polygon" || len(geom.Coordinates) != 2 {
+			t.Fatalf("GeoJSON = %s, want Polygon with 2 rings", raw)
+		}
+		for ri, ring := range parcel.Rings {
+			for pi, p := range ring {
+				first, second := axis.ordered(p)
+				if got := geom.Coordinates[ri][pi]; got != [2]float64{first, second} {
+					t.Errorf("axis %d 环 %d 点 %d = %v, want [%v %v]", axis, ri, pi, got, first, second)
+				}
+			}
+		}
+	}
+
+	// 两个分离的外环输出 MultiPolygon
+	raw, err := buildPolygonGeoJSON(polygonParcel(square(0, 0, 10, 1), square(100, 100, 10, 1)), 0, AxisOrderYX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var multi struct {
+		Type        string
+		Coordinates [][][][2]float64
+	}
+	if err := json.Unmarshal(raw, &multi); err != nil || multi.Type != "MultiPolygon" || len(multi.Coordinates) != 2 {
+		t.Errorf("GeoJSON = %s (err %v), want MultiPolygon with 2 polygons", raw, err)
+	}
+}
+
+func TestAxisOrderWKT(t *testing.T) {
+	// Point.X 为北向 3400000、Point.Y 为东向 38500000
+	parcel := polygonParcel(square(3400000, 38500000, 10, 1))
+	tests := []struct {
+		axis AxisOrder
+		want string
+	}{
+		{AxisOrderYX, "POLYGON ((38500000.00 3400000.00, 38500010.00 3400000.00, 38500010.00 3400010.00, 38500000.00 3400010.00, 38500000.00 3400000.00))"},
+		{AxisOrderXY, "POLYGON ((3400000.00 38500000.00, 3400000.00 38500010.00, 3400010.00 38500010.00, 3400010.00 38500000.00, 3400000.00 38500000.00))"},
+	}
+	for _, tt := range tests {
+		got, err := buildFeatureWKT(parcel, 2, tt.axis)
+		if err != nil {
+			t.Fatalf("buildFeatureWKT: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("axis %d:\n got %s\nwant %s", tt.axis, got, tt.want)
+		}
+	}
+	if AxisOrder(0) != AxisOrderYX {
+		t.Error("零值 AxisOrder 应为 AxisOrderYX")
+	}
+}
+
+func TestBuildRejectedFeatures(t *testing.T) {
+	// 被剔除的地块只有两个点，文件精度 0.001 推导出 4 位小数
+	parsed := &ParsedData{
+		FileAttributes: map[string]string{"精度": "0.001"},
+		Rejected: []RejectedParcel{{
+			Parcel: Parcel{
+				Attributes: map[string]string{KeyPID: "9"},
+				Rings:      []Ring{{{ID: 1, X: 3400000.5, Y: 38500000.25}, {ID: 2, X: 3400010, Y: 38500010}}},
+			},
+			Reason: "点数不足",
+		}},
+	}
+	tests := []struct {
+		name string
+		opts GeometryOptions
+		want string
+	}{
+		{"默认 YX 与文件精度", GeometryOptions{}, "MULTIPOINT ((38500000.2500 3400000.5000), (38500010.0000 3400010.0000))"},
+		{"XY 轴顺序", GeometryOptions{AxisOrder: AxisOrderXY}, "MULTIPOINT ((3400000.5000 38500000.2500), (3400010.0000 38500010.0000))"},
+		{"显式小数位", GeometryOptions{DecimalPlaces: 1}, "MULTIPOINT ((38500000.2 3400000.5), (38500010.0 3400010.0))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildRejectedFeatures(parsed, tt.opts)
+			if len(got) != 1 {
+				t.Fatalf("要素数 = %d, want 1", len(got))
+			}
+			if got[0].WKT != tt.want {
+				t.Errorf("WKT =\n%s\nwant\n%s", got[0].WKT, tt.want)
+			}
+			if got[0].Reason != "点数不足" || got[0].Attributes[KeyPID] != "9" {
+				t.Errorf("Reason = %q, Attributes = %v", got[0].Reason, got[0].Attributes)
+			}
+		})
+	}
+}
+
+func TestDetectSelfIntersections(t *testing.T) {
+	tests := []struct {
+		name string
+		ring Ring
+		want []IntersectionReport
+	}{
+		{"正方形", square(0, 0, 10, 1), nil},
+		{"蝴蝶结", Ring{{X: 0, Y: 0}, {X: 10, Y: 10}, {X: 10, Y: 0}, {X: 0, Y: 10}, {X: 0, Y: 0}}, []IntersectionReport{{Segment1: 0, Segment2: 2, X: 5, Y: 5}}},
+		{"未闭合的蝴蝶结补上闭合线段", Ring{{X: 0, Y: 0}, {X: 0, Y: 10}, {X: 10, Y: 0}, {X: 10, Y: 10}}, []IntersectionReport{{Segment1: 1, Segment2: 3, X: 5, Y: 5}}},
+		{"点数过少", Ring{{X: 0, Y: 0}, {X: 1, Y: 1}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectSelfIntersections(tt.ring); !slices.Equal(got, tt.want) {
+				t.Errorf("DetectSelfIntersections = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParcelAreaAndPerimeter(t *testing.T) {
+	triangle := Ring{{X: 0, Y: 0}, {X: 0, Y: 3}, {X: 4, Y: 0}, {X: 0, Y: 0}}
+	tests := []struct {
+		name          string
+		parcel        Parcel
+		wantArea      float64
+		wantPerimeter float64
+	}{
+		{"单位正方形", polygonParcel(square(0, 0, 1, 1)), 1, 4},
+		{"顺时针单位正方形", polygonParcel(square(0, 0, 1, -1)), 1, 4},
+		{"3-4-5 三角形", polygonParcel(triangle), 6, 12},
+		{"带洞的面", polygonParcel(square(0, 0, 10, 1), square(2, 2, 2, 1)), 96, 48},
+		{"两个分离的面", polygonParcel(square(0, 0, 1, 1), square(10, 10, 2, 1)), 5, 12},
+		{"线", Parcel{Attributes: map[string]string{KeyGType: "线"}, Rings: []Ring{triangle[:3]}}, 0, 8},
+		{"点", Parcel{Attributes: map[string]string{KeyGType: "点"}, Rings: []Ring{triangle}}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParcelArea(tt.parcel); math.Abs(got-tt.wantArea) > 1e-9 {
+				t.Errorf("ParcelArea = %v, want %v", got, tt.wantArea)
+			}
+			if got := ParcelPerimeter(tt.parcel); math.Abs(got-tt.wantPerimeter) > 1e-9 {
+				t.Errorf("ParcelPerimeter = %v, want %v", got, tt.wantPerimeter)
+			}
+		})
+	}
+	if a := RingArea(square(0, 0, 1, 1)); a != 1 {
+		t.Errorf("逆时针 RingArea = %v, want 1", a)
+	}
+	if a := RingArea(square(0, 0, 1, -1)); a != -1 {
+		t.Errorf("顺时针 RingArea = %v, want -1", a)
+	}
+}
+
+func TestBuildGeometryPreprocessDataMetrics(t *testing.T) {
+	for _, metrics := range []bool{false, true} {
+		parsed, err := Parse(sampleContent)
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		prep, err := BuildGeometryPreprocessData(parsed, GeometryOptions{AutoClose: true, Metrics: metrics})
+		if err != nil {
+			t.Fatalf("BuildGeometryPreprocessData: %v", err)
+		}
+		attrs := prep.Features[0].Attributes
+		area, hasArea := attrs[KeyComputedArea]
+		perimeter, hasPerimeter := attrs[KeyComputedPerimeter]
+		if !metrics {
+			if hasArea || hasPerimeter {
+				t.Errorf("未开启 Metrics 时不应输出计算字段: %v", attrs)
+			}
+			continue
+		}
+		parcel := parsed.Parcels[0]
+		if want := ParcelArea(parcel); !hasArea || area != want || want <= 0 {
+			t.Errorf("%s = %v, want %v", KeyComputedArea, area, want)
+		}
+		if want := ParcelPerimeter(parcel); !hasPerimeter || perimeter != want || want <= 0 {
+			t.Errorf("%s = %v, want %v", KeyComputedPerimeter, perimeter, want)
+		}
+	}
+}
+
+func TestBuildFeatureWKTByGType(t *testing.T) {
+	line := Ring{{X: 0, Y: 0}, {X: 0, Y: 10}, {X: 10, Y: 10}}
+	tests := []struct {
+		name    string
+		gtype   string
+		rings   []Ring
+		want    string
+		wantErr bool
+	}{
+		{"面", "面", []Ring{square(0, 0, 10, 1)}, "POLYGON ((0 0, 10 0, 10 10, 0 10, 0 0))", false},
+		{"空 gtype 按面处理", "", []Ring{square(0, 0, 10, 1)}, "POLYGON ((0 0, 10 0, 10 10, 0 10, 0 0))", false},
+		{"面点数不足", "面", []Ring{line}, "", true},
+		{"线", "线", []Ring{line}, "LINESTRING (0 0, 10 0, 10 10)", false},
+		{"多条线", "line", []Ring{line, line[:2]}, "MULTILINESTRING ((0 0, 10 0, 10 10), (0 0, 10 0))", false},
+		{"线点数不足", "线", []Ring{line[:1]}, "", true},
+		{"单点", "点", []Ring{line[:1]}, "POINT (0 0)", false},
+		{"多点", "点", []Ring{line}, "MULTIPOINT ((0 0), (10 0), (10 10))", false},
+		{"点无环", "点", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parcel := Parcel{Attributes: map[string]string{KeyPID: "1", KeyGType: tt.gtype}, Rings: tt.rings}
+			got, err := buildFeatureWKT(parcel, 0, AxisOrderYX)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildFeatureWKT err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("buildFeatureWKT = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// 单点地块经完整流程输出 POINT 而不是报错
+	parsed, err := Parse(strings.Replace(sampleContent, `J2,1,3400000.00,38500100.00
+J3,1,3400100.00,38500100.00
+J1,1,3400000.00,38500000.00
+`, "", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed.Parcels[0].Attributes[KeyGType] = "点"
+	prep, err := BuildGeometryPreprocessData(parsed, GeometryOptions{DecimalPlaces: 1})
+	if err != nil {
+		t.Fatalf("BuildGeometryPreprocessData: %v", err)
+	}
+	if got := prep.Features[0].WKT; got != "POINT (38500000.0 3400000.0)" {
+		t.Errorf("WKT = %s, want POINT", got)
+	}
+}
+
+// decodeWKBPolygon 解析小端 2D WKB Polygon，返回各环的 (first, second) 坐标。
+func decodeWKBPolygon(t *testing.T, b []byte) [][][2]float64 {
+	t.Helper()
+	pos := 0
+	u32 := func() uint32 {
+		v := binary.LittleEndian.Uint32(b[pos:])
+		pos += 4
+		return v
+	}
+	f64 := func() float64 {
+		v := math.Float64frombits(binary.LittleEndian.Uint64(b[pos:]))
+		pos += 8
+		return v
+	}
+	if b[0] != 1 {
+		t.Fatalf("字节序标记 = %d, want 1（小端）", b[0])
+	}
+	pos = 1
+	if typ := u32(); typ != wkbPolygon {
+		t.Fatalf("几何类型 = %d, want %d", typ, wkbPolygon)
+	}
+	rings := make([][][2]float64, u32())
+	for i := range rings {
+		rings[i] = make([][2]float64, u32())
+		for j := range rings[i] {
+			rings[i][j] = [2]float64{f64(), f64()}
+		}
+	}
+	if pos != len(b) {
+		t.Fatalf("WKB 剩余 %d 字节未解析", len(b)-pos)
+	}
+	return rings
+}
+
+func TestBuildPolygonWKBRoundTrip(t *testing.T) {
+	// 坐标带有超出 WKT 小数位的精度，WKB 应完整保留
+	ring := square(3400000.123456789, 38500000.987654321, 10.5, 1)
+	wkb, err := buildPolygonWKB(polygonParcel(ring), AxisOrderYX)
+	if err != nil {
+		t.Fatalf("buildPolygonWKB: %v", err)
+	}
+	got := decodeWKBPolygon(t, wkb)
+	if len(got) != 1 || len(got[0]) != len(ring) {
+		t.Fatalf("WKB 环 = %v, want 1 ring of %d points", got, len(ring))
+	}
+	for i, p := range ring {
+		if got[0][i] != [2]float64{p.Y, p.X} {
+			t.Errorf("点 %d = %v, want [%v %v]", i, got[0][i], p.Y, p.X)
+		}
+	}
+
+	// 与 WKT 路径相同的校验
+	if _, err := buildPolygonWKB(polygonParcel(ring[:3]), AxisOrderYX); err == nil {
+		t.Error("点数不足的环应返回错误")
+	}
+	if _, err := buildPolygonWKB(polygonParcel(ring[:4]), AxisOrderYX); err == nil {
+		t.Error("未闭合的环应返回错误")
+	}
+}
+
+func TestDecimalPlaces(t *testing.T) {
+	// 坐标行第 5 列带高程的示例文件，高程本身的小数位与 X/Y 不同
+	zContent := strings.NewReplacer("38500000.00\n", "38500000.00,12.5\n", "38500100.00\n", "38500100.00,13.25\n").Replace(sampleContent)
+	tests := []struct {
+		name    string
+		content string
+		opts    GeometryOptions
+		digits  int
+	}{
+		{"按容差推导", sampleContent, GeometryOptions{}, 4},
+		{"按容差推导（0.00001）", sampleContent, GeometryOptions{Precision: 0.00001}, 5},
+		{"显式 3 位", sampleContent, GeometryOptions{DecimalPlaces: 3}, 3},
+		{"显式 1 位不受容差下限约束", sampleContent, GeometryOptions{DecimalPlaces: 1, Precision: 0.000001}, 1},
+		{"超过上限", sampleContent, GeometryOptions{DecimalPlaces: 40}, maxDecimalPlaces},
+		{"高程与 X/Y 小数位相同", zContent, GeometryOptions{DecimalPlaces: 3}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := Parse(tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.opts.AutoClose = true
+			prep, err := BuildGeometryPreprocessData(parsed, tt.opts)
+			if err != nil {
+				t.Fatalf("BuildGeometryPreprocessData: %v", err)
+			}
+			wkt := prep.Features[0].WKT
+			if z := tt.content != sampleContent; z != strings.HasPrefix(wkt, "POLYGON Z ") {
+				t.Fatalf("WKT = %s, 是否带高程应为 %v", wkt, z)
+			}
+			coords := strings.Fields(strings.Trim(wkt[strings.IndexByte(wkt, '('):], "()"))
+			for _, c := range coords {
+				c = strings.TrimSuffix(c, ",")
+				dot := strings.IndexByte(c, '.')
+				if dot < 0 || len(c)-dot-1 != tt.digits {
+					t.Fatalf("坐标 %q 的小数位不是 %d: %s", c, tt.digits, wkt)
+				}
+			}
+		})
+	}
+}
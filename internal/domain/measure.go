@@ -0,0 +1,51 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package domain
+
+import "math"
+
+// 由几何计算的度量属性键，坐标单位为米，面积单位为平方米。
+const (
+	KeyComputedArea      = "computed_area"      // 计算面积
+	KeyComputedPerimeter = "computed_perimeter" // 计算周长
+)
+
+// RingArea 按鞋带公式计算闭合环的有向面积，以东向 (Y) 为横轴、北向 (X) 为纵轴，逆时针为正。
+// 环须首尾闭合（末点与首点相同），否则缺少最后一条边。
+func RingArea(ring []Point) float64 {
+	var sum float64
+	for i := 0; i+1 < len(ring); i++ {
+		sum += ring[i].Y*ring[i+1].X - ring[i+1].Y*ring[i].X
+	}
+	return sum / 2
+}
+
+// ParcelArea 计算地块面积：各外环面积之和减去其洞的面积，与环的方向无关；点、线地块面积为 0。
+func ParcelArea(parcel Parcel) float64 {
+	if parcelGeometryKind(parcel) != kindPolygon {
+		return 0
+	}
+	var area float64
+	for _, poly := range classifyRings(parcel.Rings) {
+		area += math.Abs(RingArea(parcel.Rings[poly[0]]))
+		for _, hole := range poly[1:] {
+			area -= math.Abs(RingArea(parcel.Rings[hole]))
+		}
+	}
+	return area
+}
+
+// ParcelPerimeter 计算地块所有环（含洞）的边长之和；线地块为各线长度之和，点地块为 0。
+func ParcelPerimeter(parcel Parcel) float64 {
+	if parcelGeometryKind(parcel) == kindPoint {
+		return 0
+	}
+	var perimeter float64
+	for _, ring := range parcel.Rings {
+		for i := 0; i+1 < len(ring); i++ {
+			perimeter += math.Hypot(ring[i+1].X-ring[i].X, ring[i+1].Y-ring[i].Y)
+		}
+	}
+	return perimeter
+}
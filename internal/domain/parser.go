@@ -5,11 +5,15 @@ package domain
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"maps"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
+	"txt2geo/pkg/charset"
+	"unicode/utf8"
 )
 
 // --- 公共数据结构 ---
@@ -18,9 +22,24 @@ import (
 type Point struct {
 	// Point 表示一个二维平面坐标点，包含点号、圈号、X、Y。点号和圈号不能混用。
 	ID     int     // 点号（唯一标识该点，通常与原始数据点号一致）
+	Label  string  // 原始点号文本（如 "J1"、"Z5"），保留界址点/转折点等前缀
 	RingID int     // 圈号（标识该点所属的环）
 	X      float64 // X坐标
 	Y      float64 // Y坐标
+	Z      float64 // 高程，仅当 HasZ 为 true 时有效
+	HasZ   bool    // 是否携带高程（坐标行第 5 列为数值时）
+	M      float64 // 测量值（如导线里程），仅当 HasM 为 true 时有效
+	HasM   bool    // 是否携带测量值
+}
+
+// Prefix 返回点号标签中首个数字之前的前缀（如 "J1" -> "J"），无前缀时返回空串。
+func (p Point) Prefix() string {
+	for i := 0; i < len(p.Label); i++ {
+		if p.Label[i] >= '0' && p.Label[i] <= '9' {
+			return p.Label[:i]
+		}
+	}
+	return p.Label
 }
 
 // Ring 表示一个多边形环（首尾相连的点序列），每个点有独立点号和圈号。最后一个点与第一个点可相同以显式闭合。
@@ -29,7 +48,9 @@ type Ring []Point
 // Parcel 表示一个地块实体，包含其属性字段与几何（一个或多个环）。
 type Parcel struct {
 	Attributes map[string]string
-	Rings      []Ring
+	// ExtraAttributes 起始行中超出 8 个已知字段的额外字段（按原始顺序），避免源数据被静默丢弃
+	ExtraAttributes []string
+	Rings           []Ring
 }
 
 // ParsedData 是 ParseGeoContent 返回的完整结构化结果。
@@ -39,6 +60,70 @@ type ParsedData struct {
 	// FileAttributes 文件级属性键值对（来自 [属性描述] 部分）。
 	// 至少包含: "坐标系", "投影类型", "几度分带", "带号", "精度"（若源文件提供）。
 	FileAttributes map[string]string
+	// Warnings 解析过程中产生的非致命警告（如被丢弃的截断地块）。
+	Warnings []string
+	// Rejected 被容错逻辑剔除的地块，保留原始几何与原因以便复核。
+	Rejected []RejectedParcel
+}
+
+// RejectedParcel 记录一个被剔除的地块及剔除原因。
+type RejectedParcel struct {
+	Parcel Parcel
+	Reason string
+}
+
+// ParseOptions 控制解析器的容错行为，零值即为默认的严格模式。
+type ParseOptions struct {
+	// RecoverTruncated 为 true 时，若文件末尾的地块明显被截断
+	// （最后一个环未闭合且点数未达到界址点数），则丢弃该地块并记录警告，而不是保留无效几何。
+	RecoverTruncated bool
+	// MeasureColumn 坐标行中测量值 (M) 所在的列号（从 1 开始，须 ≥5）；0 表示不读取测量值。
+	// 指定后该列缺失或为空的点视为无测量值，无法解析时报错。
+	MeasureColumn int
+	// AttrSection / GeomSection 文件属性与地块坐标部分的标记行，空表示默认的 "[属性描述]" / "[地块坐标]"。
+	// 用于兼容使用 [Attributes]/[Coordinates] 等其它写法的数据提供方。
+	AttrSection string
+	GeomSection string
+	// Delimiter 坐标行与地块起始行的字段分隔符，零值表示逗号；地块起始行的结尾相应变为 "<分隔符>@"。
+	// [属性描述] 部分按 "=" 拆分键值，不受影响。
+	Delimiter rune
+}
+
+// delimiter 返回生效的字段分隔符（零值回退为逗号）。
+func (o ParseOptions) delimiter() string {
+	if o.Delimiter == 0 {
+		return ","
+	}
+	return string(o.Delimiter)
+}
+
+// sections 返回生效的部分标记（空值回退为默认标记）。
+func (o ParseOptions) sections() (attr, geom string) {
+	attr, geom = strings.TrimSpace(o.AttrSection), strings.TrimSpace(o.GeomSection)
+	if attr == "" {
+		attr = secAttr
+	}
+	if geom == "" {
+		geom = secGeom
+	}
+	return attr, geom
+}
+
+// Validate 检查选项是否有效：测量值列号须为 0 或 ≥5，两个部分标记不能相同。
+func (o ParseOptions) Validate() error {
+	if o.MeasureColumn != 0 && o.MeasureColumn < 5 {
+		return fmt.Errorf("测量值列号必须 ≥5，当前: %d", o.MeasureColumn)
+	}
+	if attr, geom := o.sections(); attr == geom {
+		return fmt.Errorf("属性部分与坐标部分的标记不能相同: %s", attr)
+	}
+	switch d := o.Delimiter; {
+	case d == '=' || d == '@' || d == '.' || d == '-' || d == '+' || d == '\n' || d == '\r' || (d >= '0' && d <= '9'):
+		return fmt.Errorf("不能使用 %q 作为字段分隔符", d)
+	case d < 0 || d == utf8.RuneError:
+		return fmt.Errorf("无效的字段分隔符: %q", d)
+	}
+	return nil
 }
 
 // --- 解析器实现 ---
@@ -68,8 +153,32 @@ var parcelAttrKeys = [...]string{KeyBPCnt, KeyArea, KeyPID, KeyPName, KeyGType,
 const (
 	CodeMissingParcelHeader = "MISSING_PARCEL_HEADER"
 	CodeInvalidPointFormat  = "INVALID_POINT_FORMAT"
+	// CodeNonContiguousRing 为提示性诊断：同一圈号在地块内不连续出现，该行仍照常解析
+	CodeNonContiguousRing = "NON_CONTIGUOUS_RING"
 )
 
+// ParseIssue 描述宽松解析模式下发现的问题：行级错误（该行被跳过），
+// 或提示性诊断（如 CodeNonContiguousRing，该行照常解析）。
+type ParseIssue struct {
+	Line    int    // 行号（从 1 开始）
+	Code    string // 诊断代码，如 CodeInvalidPointFormat
+	Message string // 错误描述（不含代码前缀）
+	Raw     string // 原始行文本（已去除首尾空白）
+}
+
+// lineError 是携带诊断代码的可恢复行级错误；Error() 格式为 "CODE: message"。
+type lineError struct {
+	code string
+	msg  string
+}
+
+func (e *lineError) Error() string { return e.code + ": " + e.msg }
+
+// newLineError 构造一个带诊断代码的行级错误。
+func newLineError(code, format string, args ...any) error {
+	return &lineError{code: code, msg: fmt.Sprintf(format, args...)}
+}
+
 type parseState int
 
 const (
@@ -87,6 +196,16 @@ type parseContext struct {
 	currentParcel *Parcel
 	ringPoints    map[int][]Point // 临时存储当前地块的环点，key是圈号
 	ringFirstLine map[int]int     // 记录每个环首个坐标出现的行号
+	lastRingID    int             // 最近一次写入坐标点的圈号
+	warnings      []string
+	rejected      []RejectedParcel
+	opts          ParseOptions
+	secAttr       string       // 生效的属性部分标记
+	secGeom       string       // 生效的坐标部分标记
+	delim         string       // 生效的字段分隔符
+	parcelSuffix  string       // 地块起始行结尾标记（delim + "@"）
+	lenient       bool         // 宽松模式：可恢复的行级错误记入 issues 并跳过该行
+	issues        []ParseIssue // 宽松模式下收集的问题
 }
 
 // Parse 解析原始文本为结构化地块数据（语法层面）。
@@ -100,45 +219,83 @@ type parseContext struct {
 //
 // 成功返回时（error == nil）：语法有效；属性完整；几何仍为“原始形态”。
 func Parse(content string) (*ParsedData, error) {
+	return ParseWith(content, ParseOptions{})
+}
+
+// ParseWith 与 Parse 相同，但允许通过 opts 配置节标题、分隔符并开启容错行为。
+func ParseWith(content string, opts ParseOptions) (*ParsedData, error) {
+	data, _, err := parse(content, opts, false)
+	return data, err
+}
+
+// ParseLenient 以宽松模式解析：可恢复的行级错误（坐标格式错误、缺少地块起始行等）
+// 不会中止解析，而是跳过该行并记入返回的 ParseIssue 列表，以便一次性得到完整的诊断报告。
+// 结构性问题（缺少 [属性描述] / [地块坐标] 部分、必需文件属性缺失）仍返回错误。
+// opts 与 ParseWith 相同（节标题、分隔符等），零值即默认格式。流水线默认仍应使用严格的 Parse。
+func ParseLenient(content string, opts ParseOptions) (*ParsedData, []ParseIssue, error) {
+	return parse(content, opts, true)
+}
+
+// parse 是 Parse 系列函数的共同实现；lenient 为 true 时收集行级问题而不是立即返回错误。
+func parse(content string, opts ParseOptions, lenient bool) (*ParsedData, []ParseIssue, error) {
 	ctx := &parseContext{
 		state:         stateInitial,
 		attrs:         make(map[string]string),
 		ringPoints:    make(map[int][]Point),
 		ringFirstLine: make(map[int]int),
+		opts:          opts,
+		lenient:       lenient,
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, nil, err
 	}
+	ctx.secAttr, ctx.secGeom = opts.sections()
+	ctx.delim = opts.delimiter()
+	ctx.parcelSuffix = ctx.delim + "@"
 
 	scanner := bufio.NewScanner(strings.NewReader(content))
 	for scanner.Scan() {
 		ctx.lineNo++
-		line := strings.TrimSpace(scanner.Text())
+		// 零宽字符与文本中间的 BOM 会导致坐标解析失败并污染属性键名，解析前统一移除
+		raw, _ := charset.StripInvisible(scanner.Text())
+		line := strings.TrimSpace(raw)
 		if line == "" {
 			continue
 		}
 		if err := ctx.processLine(line); err != nil {
-			return nil, fmt.Errorf("line %d: %w", ctx.lineNo, err)
+			return nil, ctx.issues, fmt.Errorf("line %d: %w", ctx.lineNo, err)
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("reading content failed: %w", err)
+		return nil, ctx.issues, fmt.Errorf("reading content failed: %w", err)
 	}
 
-	// 文件结束时，处理最后一个地块
+	// 文件结束时，处理最后一个地块；容错模式下丢弃明显被截断的尾部地块
+	if opts.RecoverTruncated && ctx.isTruncatedTail() {
+		pid := ctx.currentParcel.Attributes[KeyPID]
+		if pid == "" {
+			pid = fmt.Sprintf("#%d", len(ctx.parcels)+1)
+		}
+		reason := fmt.Sprintf("末尾地块 %s 疑似被截断（环 %d 未闭合且点数不足），已丢弃", pid, ctx.lastRingID)
+		ctx.warnings = append(ctx.warnings, reason)
+		ctx.rejected = append(ctx.rejected, RejectedParcel{Parcel: *ctx.takeCurrentParcel(), Reason: reason})
+	}
 	if err := ctx.finalizeCurrentParcel(); err != nil {
-		return nil, err
+		return nil, ctx.issues, err
 	}
 
 	// 检查并报告缺失的关键部分
 	switch ctx.state {
 	case stateInitial:
-		return nil, fmt.Errorf("文件缺少 %s 部分", secAttr)
+		return nil, ctx.issues, fmt.Errorf("文件缺少 %s 部分", ctx.secAttr)
 	case stateAttributes:
-		return nil, fmt.Errorf("文件缺少 %s 部分", secGeom)
+		return nil, ctx.issues, fmt.Errorf("文件缺少 %s 部分", ctx.secGeom)
 	}
 
 	// 验证必需的文件属性（键名在属性阶段已即时规范化）
 	if err := validateFileAttributes(ctx.attrs); err != nil {
-		return nil, err
+		return nil, ctx.issues, err
 	}
 
 	// 复制文件级属性（防止调用方修改内部原 map）
@@ -148,7 +305,9 @@ func Parse(content string) (*ParsedData, error) {
 	return &ParsedData{
 		Parcels:        ctx.parcels,
 		FileAttributes: copied,
-	}, nil
+		Warnings:       ctx.warnings,
+		Rejected:       ctx.rejected,
+	}, ctx.issues, nil
 }
 
 // processLine 根据当前解析状态处理单行文本。
@@ -156,17 +315,17 @@ func Parse(content string) (*ParsedData, error) {
 func (c *parseContext) processLine(line string) error {
 	switch c.state {
 	case stateInitial:
-		if line == secAttr {
+		if line == c.secAttr {
 			c.state = stateAttributes
 		}
 		// 在找到[属性描述]之前忽略所有其他行
 	case stateAttributes:
-		if line == secGeom {
+		if line == c.secGeom {
 			c.state = stateCoordinates
 			return nil
 		}
 		// 如果再次遇到 [属性描述] 说明是重复的文件头，按照新需求：忽略其内容，不再重置 attrs。
-		if line == secAttr { // 再次出现，停留在 attributes 状态但不做任何处理
+		if line == c.secAttr { // 再次出现，停留在 attributes 状态但不做任何处理
 			return nil
 		}
 		parts := strings.SplitN(line, "=", 2)
@@ -188,14 +347,14 @@ func (c *parseContext) processLine(line string) error {
 		}
 	case stateCoordinates:
 		// 新需求：后续再次出现 [属性描述] / [地块坐标] 均忽略（不再解析新的文件属性也不改变现有状态）
-		if line == secAttr || line == secGeom {
+		if line == c.secAttr || line == c.secGeom {
 			return nil
 		}
-		// 逻辑：坐标行必须含逗号；重复属性行一般是 key=value 且不含逗号
-		if strings.Contains(line, "=") && !strings.Contains(line, ",") {
+		// 逻辑：坐标行必须含分隔符；重复属性行一般是 key=value 且不含分隔符
+		if strings.Contains(line, "=") && !strings.Contains(line, c.delim) {
 			return nil
 		}
-		if strings.HasSuffix(line, ",@") {
+		if strings.HasSuffix(line, c.parcelSuffix) {
 			// 这是一个新的地块属性行，严格模式下若上一个地块存在错误直接返回
 			if err := c.finalizeCurrentParcel(); err != nil {
 				return err
@@ -204,6 +363,12 @@ func (c *parseContext) processLine(line string) error {
 		} else {
 			// 尝试解析为坐标点
 			if err := c.addPointToCurrentParcel(line); err != nil {
+				var le *lineError
+				if c.lenient && errors.As(err, &le) {
+					// 宽松模式：记录问题并跳过该行
+					c.issues = append(c.issues, ParseIssue{Line: c.lineNo, Code: le.code, Message: le.msg, Raw: line})
+					return nil
+				}
 				// 严格模式：直接返回错误终止
 				return fmt.Errorf("line %d: %w", c.lineNo, err)
 			}
@@ -221,7 +386,14 @@ func (c *parseContext) finalizeCurrentParcel() error {
 	if c.currentParcel == nil || len(c.ringPoints) == 0 {
 		return nil
 	}
+	// 即使某些 ring 不满足最小点数或未闭合，也先保留，由后处理决定取舍
+	c.parcels = append(c.parcels, *c.takeCurrentParcel())
+	return nil
+}
 
+// takeCurrentParcel 将暂存的 ringPoints 按圈号顺序转换为 Ring 附加到当前地块，
+// 返回该地块并重置缓存。调用方需保证 currentParcel 非空。
+func (c *parseContext) takeCurrentParcel() *Parcel {
 	ids := make([]int, 0, len(c.ringPoints))
 	for id := range c.ringPoints {
 		ids = append(ids, id)
@@ -237,54 +409,110 @@ func (c *parseContext) finalizeCurrentParcel() error {
 		c.currentParcel.Rings = append(c.currentParcel.Rings, Ring(pts))
 	}
 
-	// 即使某些 ring 不满足最小点数或未闭合，也先保留，由后处理决定取舍
-	c.parcels = append(c.parcels, *c.currentParcel)
+	p := c.currentParcel
 	c.currentParcel = nil
 	c.ringPoints = make(map[int][]Point)
 	c.ringFirstLine = make(map[int]int)
-	return nil
+	return p
+}
+
+// isTruncatedTail 判断当前（即文件末尾）地块是否明显被截断：
+// 最后写入的环首尾不闭合，且地块总点数未达到起始行声明的界址点数。
+// 界址点数缺失或无法解析时无法判定，返回 false。
+func (c *parseContext) isTruncatedTail() bool {
+	if c.currentParcel == nil || len(c.ringPoints) == 0 {
+		return false
+	}
+	expected, err := strconv.Atoi(strings.TrimSpace(c.currentParcel.Attributes[KeyBPCnt]))
+	if err != nil || expected <= 0 {
+		return false
+	}
+	total := 0
+	for _, pts := range c.ringPoints {
+		total += len(pts)
+	}
+	if total >= expected {
+		return false
+	}
+	last := c.ringPoints[c.lastRingID]
+	if len(last) == 0 {
+		return false
+	}
+	return len(last) == 1 || !pointsEqual(last[0], last[len(last)-1], MaxTolerance)
 }
 
 // startNewParcel 初始化一个新地块并重置环缓存。
 func (c *parseContext) startNewParcel(line string) {
-	attrs := parseParcelAttributes(line)
-	p := &Parcel{Attributes: attrs, Rings: []Ring{}}
+	attrs, extras := parseParcelAttributes(line, c.delim)
+	p := &Parcel{Attributes: attrs, ExtraAttributes: extras, Rings: []Ring{}}
 	c.currentParcel = p
 	c.ringPoints = make(map[int][]Point)
 	c.ringFirstLine = make(map[int]int)
 }
 
 // addPointToCurrentParcel 解析一条坐标记录并加入当前地块环缓存。
-// 期望格式: 点号,ringID,x,y,... 至少 4 个逗号分隔字段。
+// 期望格式: 点号,ringID,x,y,... 至少 4 个以分隔符（默认逗号）分隔的字段。
 // 错误：圈号或坐标无法解析时返回格式错误。
 func (c *parseContext) addPointToCurrentParcel(line string) error {
 	if c.currentParcel == nil {
 		// 严格模式：直接返回错误
-		return fmt.Errorf("%s: 在[地块坐标]部分发现坐标点，但之前缺少以@结尾的地块起始行", CodeMissingParcelHeader)
+		return newLineError(CodeMissingParcelHeader, "在[地块坐标]部分发现坐标点，但之前缺少以@结尾的地块起始行")
 	}
 
-	parts := strings.Split(line, ",")
+	// 中文文字处理软件编辑过的文件常含全角数字、小数点、负号与逗号，拆分前统一转为半角；纯 ASCII 行跳过转换
+	if !isASCII(line) {
+		line = FullWidthStrToHalfWidthStr(line)
+	}
+	parts := strings.Split(line, c.delim)
 	if len(parts) < 4 {
-		return fmt.Errorf("%s: 坐标行格式错误，字段不足", CodeInvalidPointFormat)
+		return newLineError(CodeInvalidPointFormat, "坐标行格式错误，字段不足")
 	}
 	// 点号支持任意前缀，提取数字部分，圈号为环分组依据，点号和圈号不能混用
-	pointID := extractFirstInt(parts[0])
+	// 完整标签另行保留，避免 "J1" 与 "Z1" 之类不同序列的点号被混为一谈
+	label := strings.TrimSpace(parts[0])
+	pointID := extractFirstInt(label)
 	ringID, err := strconv.Atoi(strings.TrimSpace(parts[1]))
 	if err != nil {
-		return fmt.Errorf("%s: 无效的圈号: %s", CodeInvalidPointFormat, parts[1])
+		return newLineError(CodeInvalidPointFormat, "无效的圈号: %s", parts[1])
 	}
 	x, err := strconv.ParseFloat(strings.TrimSpace(parts[2]), 64)
 	if err != nil {
-		return fmt.Errorf("%s: 无效的X坐标: %s", CodeInvalidPointFormat, parts[2])
+		return newLineError(CodeInvalidPointFormat, "无效的X坐标: %s", parts[2])
 	}
 	y, err := strconv.ParseFloat(strings.TrimSpace(parts[3]), 64)
 	if err != nil {
-		return fmt.Errorf("%s: 无效的Y坐标: %s", CodeInvalidPointFormat, parts[3])
+		return newLineError(CodeInvalidPointFormat, "无效的Y坐标: %s", parts[3])
+	}
+	pt := Point{ID: pointID, Label: label, RingID: ringID, X: x, Y: y}
+	// 第 5 列为高程；为空或非数值时视为无高程而非错误。被指定为测量值列时不再读取为高程
+	if len(parts) > 4 && c.opts.MeasureColumn != 5 {
+		if z, err := strconv.ParseFloat(strings.TrimSpace(parts[4]), 64); err == nil && !math.IsNaN(z) && !math.IsInf(z, 0) {
+			pt.Z, pt.HasZ = z, true
+		}
+	}
+	if col := c.opts.MeasureColumn; col > 0 && col <= len(parts) {
+		if raw := strings.TrimSpace(parts[col-1]); raw != "" {
+			m, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return newLineError(CodeInvalidPointFormat, "无效的测量值: %s", parts[col-1])
+			}
+			pt.M, pt.HasM = m, true
+		}
 	}
 	if c.ringPoints[ringID] == nil {
 		c.ringPoints[ringID] = make([]Point, 0)
+		c.ringFirstLine[ringID] = c.lineNo
+	} else if ringID != c.lastRingID && c.lenient {
+		// 同一圈号在地块内分成不连续的多段（如 1,2,1），严格模式照常合并；宽松模式额外给出提示，便于发现录入错误
+		c.issues = append(c.issues, ParseIssue{
+			Line:    c.lineNo,
+			Code:    CodeNonContiguousRing,
+			Message: fmt.Sprintf("圈号 %d 出现在不连续的块中（首次位于第 %d 行，再次出现于第 %d 行）", ringID, c.ringFirstLine[ringID], c.lineNo),
+			Raw:     line,
+		})
 	}
-	c.ringPoints[ringID] = append(c.ringPoints[ringID], Point{ID: pointID, RingID: ringID, X: x, Y: y})
+	c.ringPoints[ringID] = append(c.ringPoints[ringID], pt)
+	c.lastRingID = ringID
 	return nil
 }
 
@@ -304,9 +532,46 @@ func extractFirstInt(s string) int {
 	return 0
 }
 
+// ValidatePointCounts 校验每个地块起始行声明的界址点数（bp_cnt）与实际解析出的点数是否一致，
+// 用于发现被截断或漏录的文件。实际点数为各环内坐标不重复的点数之和（闭合点与重复点不计）。
+// bp_cnt 缺失或不是正整数的地块不参与校验。该函数需显式调用，Parse 不会自动执行；
+// 应在几何去重之前对原始解析结果调用。
+func ValidatePointCounts(pd *ParsedData) []error {
+	if pd == nil {
+		return nil
+	}
+	var errs []error
+	for i, parcel := range pd.Parcels {
+		expected, err := strconv.Atoi(strings.TrimSpace(parcel.Attributes[KeyBPCnt]))
+		if err != nil || expected <= 0 {
+			continue
+		}
+		actual := 0
+		for _, ring := range parcel.Rings {
+			seen := make(map[[2]float64]struct{}, len(ring))
+			for _, p := range ring {
+				seen[[2]float64{p.X, p.Y}] = struct{}{}
+			}
+			actual += len(seen)
+		}
+		if actual != expected {
+			pid := parcel.Attributes[KeyPID]
+			if pid == "" {
+				pid = fmt.Sprintf("#%d", i+1)
+			}
+			errs = append(errs, fmt.Errorf("地块 %s 的界址点数为 %d，实际解析出 %d 个不重复的点", pid, expected, actual))
+		}
+	}
+	return errs
+}
+
 // validateFileAttributes 校验文件级必选属性是否存在。
 // 若缺少返回错误列出全部缺失项。
+// 若属性中提供了 proj4 / prj 投影定义，则中文坐标系字段不再是必选项。
 func validateFileAttributes(attrs map[string]string) error {
+	if hasProjDefinition(attrs) {
+		return nil
+	}
 	// 必填属性键（中文名）
 	required := []string{"坐标系", "投影类型", "几度分带", "带号"}
 	missing := []string{}
@@ -324,20 +589,21 @@ func validateFileAttributes(attrs map[string]string) error {
 	return nil
 }
 
-// parseParcelAttributes 解析以 "...,@" 结尾的地块起始行
-func parseParcelAttributes(line string) map[string]string {
+// parseParcelAttributes 解析以 "<delim>@" 结尾的地块起始行（默认分隔符为逗号，即 "...,@"），
+// 返回已知字段映射以及超出已知字段的额外字段。
+func parseParcelAttributes(line, delim string) (map[string]string, []string) {
 	// 直接预分配完整容量，避免 map 扩容
 	attrs := make(map[string]string, len(parcelAttrKeys))
-	core := strings.TrimSpace(strings.TrimSuffix(line, ",@"))
+	core := strings.TrimSpace(strings.TrimSuffix(line, delim+"@"))
 
 	if core == "" { // 全部为空，填充所有键为 ""
 		for _, k := range parcelAttrKeys {
 			attrs[k] = ""
 		}
-		return attrs
+		return attrs, nil
 	}
 
-	parts := strings.Split(core, ",")
+	parts := strings.Split(core, delim)
 	// 顺序: bp_cnt,area,pid,pname,gtype,sheet,usage,code
 	for i, k := range parcelAttrKeys {
 		if i < len(parts) {
@@ -348,7 +614,24 @@ func parseParcelAttributes(line string) map[string]string {
 			attrs[k] = "" // 补齐缺失字段
 		}
 	}
-	return attrs
+	var extras []string
+	if len(parts) > len(parcelAttrKeys) {
+		extras = make([]string, 0, len(parts)-len(parcelAttrKeys))
+		for _, v := range parts[len(parcelAttrKeys):] {
+			extras = append(extras, strings.TrimSpace(v))
+		}
+	}
+	return attrs, extras
+}
+
+// isASCII 判断字符串是否仅包含 ASCII 字符。
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
 }
 
 // 高性能全角转半角字符串（支持常见全角标点和英文符号）
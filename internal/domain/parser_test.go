@@ -0,0 +1,276 @@
+package domain
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// sampleContent 是一个最小的合法源文件：CGCS2000 3 度带第 38 带，一个 100m×100m 的三角形地块。
+// 内容来自 testdata/sample.txt，与 export 包的测试共用。
+var sampleContent = func() string {
+	data, err := os.ReadFile(filepath.Join("testdata", "sample.txt"))
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}()
+
+// withSections 将 sampleContent 中的默认部分标记替换为 attr / geom。
+func withSections(attr, geom string) string {
+	return strings.NewReplacer(secAttr, attr, secGeom, geom).Replace(sampleContent)
+}
+
+func TestParseWithSections(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		opts    ParseOptions
+		wantErr string
+	}{
+		{"默认标记", sampleContent, ParseOptions{}, ""},
+		{"英文标记", withSections("[Attributes]", "[Coordinates]"), ParseOptions{AttrSection: "[Attributes]", GeomSection: "[Coordinates]"}, ""},
+		{"未配置自定义标记", withSections("[Attributes]", "[Coordinates]"), ParseOptions{}, "缺少 [属性描述]"},
+		{"仅坐标部分不同", withSections(secAttr, "[界址点坐标]"), ParseOptions{GeomSection: "[界址点坐标]"}, ""},
+		{"标记相同", sampleContent, ParseOptions{AttrSection: "[X]", GeomSection: "[X]"}, "不能相同"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := ParseWith(tt.content, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWith: %v", err)
+			}
+			if len(data.Parcels) != 1 || len(data.Parcels[0].Rings[0]) != 4 {
+				t.Errorf("parcels = %+v, want 1 parcel with 4 points", data.Parcels)
+			}
+		})
+	}
+}
+
+func TestParseLenientHonorsOptions(t *testing.T) {
+	content := withSections("[Attributes]", "[Coordinates]")
+	content = strings.Replace(content, "J2,1,3400000.00,38500100.00", "J2,1,bad,38500100.00", 1)
+
+	data, issues, err := ParseLenient(content, ParseOptions{AttrSection: "[Attributes]", GeomSection: "[Coordinates]"})
+	if err != nil {
+		t.Fatalf("ParseLenient: %v", err)
+	}
+	if len(issues) != 1 {
+		t.Errorf("issues = %v, want 1 issue for the malformed coordinate line", issues)
+	}
+	if got := len(data.Parcels[0].Rings[0]); got != 3 {
+		t.Errorf("points = %d, want 3 after skipping the malformed line", got)
+	}
+
+	if _, _, err := ParseLenient(content, ParseOptions{}); err == nil {
+		t.Error("未配置自定义标记时应报告缺少部分")
+	}
+}
+
+func TestParseDelimiter(t *testing.T) {
+	want, err := Parse(sampleContent)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	// [属性描述] 部分按 "=" 拆分，只替换其后的坐标部分
+	withDelim := func(d string) string {
+		i := strings.Index(sampleContent, secGeom)
+		return sampleContent[:i] + strings.ReplaceAll(sampleContent[i:], ",", d)
+	}
+	tests := []struct {
+		name  string
+		delim rune
+	}{
+		{"制表符", '\t'},
+		{"分号", ';'},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := withDelim(string(tt.delim))
+			got, err := ParseWith(content, ParseOptions{Delimiter: tt.delim})
+			if err != nil {
+				t.Fatalf("ParseWith: %v", err)
+			}
+			if len(got.Parcels) != 1 || !slices.Equal(got.Parcels[0].Rings[0], want.Parcels[0].Rings[0]) {
+				t.Errorf("rings = %v, want %v", got.Parcels[0].Rings, want.Parcels[0].Rings)
+			}
+			if !maps.Equal(got.Parcels[0].Attributes, want.Parcels[0].Attributes) {
+				t.Errorf("attributes = %v, want %v", got.Parcels[0].Attributes, want.Parcels[0].Attributes)
+			}
+			// 未指定分隔符时整行无法拆分
+			if _, err := Parse(content); err == nil {
+				t.Error("未配置分隔符时应解析失败")
+			}
+		})
+	}
+
+	for _, d := range []rune{'=', '@', '.', '5', -1} {
+		if err := (ParseOptions{Delimiter: d}).Validate(); err == nil {
+			t.Errorf("Delimiter %q 应校验失败", d)
+		}
+	}
+}
+
+func TestParseZ(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		wantZ   float64
+		wantHas bool
+	}{
+		{"无第 5 列", "J2,1,3400000.00,38500100.00", 0, false},
+		{"数值高程", "J2,1,3400000.00,38500100.00,12.5", 12.5, true},
+		{"负高程", "J2,1,3400000.00,38500100.00,-3", -3, true},
+		{"空第 5 列", "J2,1,3400000.00,38500100.00,", 0, false},
+		{"非数值第 5 列", "J2,1,3400000.00,38500100.00,备注", 0, false},
+		{"NaN", "J2,1,3400000.00,38500100.00,NaN", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := strings.Replace(sampleContent, "J2,1,3400000.00,38500100.00", tt.line, 1)
+			data, err := Parse(content)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			p := data.Parcels[0].Rings[0][1]
+			if p.HasZ != tt.wantHas || p.Z != tt.wantZ {
+				t.Errorf("Z = %v, HasZ = %v, want %v, %v", p.Z, p.HasZ, tt.wantZ, tt.wantHas)
+			}
+			if p.X != 3400000 || p.Y != 38500100 {
+				t.Errorf("XY = (%v, %v)，第 5 列不应影响平面坐标", p.X, p.Y)
+			}
+		})
+	}
+}
+
+func TestParseExtraAttributes(t *testing.T) {
+	content := strings.Replace(sampleContent, "4,123.45,1,地块1,面,,,,@", "4,123.45,1,地块1,面,H50,011,A01, 备注 ,2024,@", 1)
+	data, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	parcel := data.Parcels[0]
+	if want := []string{"备注", "2024"}; !slices.Equal(parcel.ExtraAttributes, want) {
+		t.Errorf("ExtraAttributes = %q, want %q", parcel.ExtraAttributes, want)
+	}
+	want := map[string]string{
+		KeyBPCnt: "4", KeyArea: "123.45", KeyPID: "1", KeyPName: "地块1",
+		KeyGType: "面", KeySheet: "H50", KeyUsage: "011", KeyCode: "A01",
+	}
+	if !maps.Equal(parcel.Attributes, want) {
+		t.Errorf("Attributes = %v, want %v", parcel.Attributes, want)
+	}
+
+	// 8 个字段时没有额外字段
+	data, err = Parse(sampleContent)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if extras := data.Parcels[0].ExtraAttributes; extras != nil {
+		t.Errorf("ExtraAttributes = %q, want nil", extras)
+	}
+}
+
+func TestParseFullWidthCoordinates(t *testing.T) {
+	want, err := Parse(sampleContent)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	content := strings.Replace(sampleContent, "J2,1,3400000.00,38500100.00", "Ｊ２，１，３４０００００．００，３８５００１００．００", 1)
+	got, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Parse 全角坐标: %v", err)
+	}
+	if !slices.Equal(got.Parcels[0].Rings[0], want.Parcels[0].Rings[0]) {
+		t.Errorf("rings = %v, want %v", got.Parcels[0].Rings[0], want.Parcels[0].Rings[0])
+	}
+
+	// 全角负号
+	content = strings.Replace(sampleContent, "J2,1,3400000.00,38500100.00", "J2,1,3400000.00,38500100.00,－１２．５", 1)
+	got, err = Parse(content)
+	if err != nil {
+		t.Fatalf("Parse 全角负号: %v", err)
+	}
+	if p := got.Parcels[0].Rings[0][1]; !p.HasZ || p.Z != -12.5 {
+		t.Errorf("Z = %v (HasZ=%v), want -12.5", p.Z, p.HasZ)
+	}
+}
+
+func TestParseNonContiguousRing(t *testing.T) {
+	content := strings.Replace(sampleContent, `J1,1,3400000.00,38500000.00
+J2,1,3400000.00,38500100.00
+J3,1,3400100.00,38500100.00
+J1,1,3400000.00,38500000.00`, `J1,1,3400000.00,38500000.00
+J2,1,3400000.00,38500100.00
+K1,2,3400010.00,38500050.00
+K2,2,3400010.00,38500080.00
+J3,1,3400100.00,38500100.00
+J1,1,3400000.00,38500000.00`, 1)
+
+	// 严格模式照常合并，不报告问题
+	data, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := len(data.Parcels[0].Rings[0]); got != 4 {
+		t.Errorf("环 1 点数 = %d, want 4（两段合并）", got)
+	}
+
+	data, issues, err := ParseLenient(content, ParseOptions{})
+	if err != nil {
+		t.Fatalf("ParseLenient: %v", err)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("issues = %v, want 1", issues)
+	}
+	issue := issues[0]
+	if issue.Code != CodeNonContiguousRing || issue.Line != 14 || !strings.Contains(issue.Message, "第 10 行") {
+		t.Errorf("issue = %+v, want %s at line 14 referencing line 10", issue, CodeNonContiguousRing)
+	}
+	if got := len(data.Parcels[0].Rings[0]); got != 4 {
+		t.Errorf("宽松模式环 1 点数 = %d, want 4（提示性诊断不跳过该行）", got)
+	}
+}
+
+func TestValidatePointCounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		wantErr string // 为空表示一致
+	}{
+		// 三角形 4 个点中闭合点与首点重复，不重复点为 3 个
+		{"一致", "3,123.45,1,地块1,面,,,,@", ""},
+		{"不一致", "4,123.45,1,地块1,面,,,,@", "地块 1 的界址点数为 4，实际解析出 3 个"},
+		{"缺少地块编号", "5,123.45,,地块1,面,,,,@", "地块 #1"},
+		{"界址点数为空", ",123.45,1,地块1,面,,,,@", ""},
+		{"界址点数非数值", "abc,123.45,1,地块1,面,,,,@", ""},
+		{"界址点数为 0", "0,123.45,1,地块1,面,,,,@", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Parse(strings.Replace(sampleContent, "4,123.45,1,地块1,面,,,,@", tt.header, 1))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			errs := ValidatePointCounts(data)
+			if tt.wantErr == "" {
+				if len(errs) != 0 {
+					t.Errorf("ValidatePointCounts = %v, want none", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErr) {
+				t.Errorf("ValidatePointCounts = %v, want containing %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
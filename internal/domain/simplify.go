@@ -0,0 +1,77 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package domain
+
+import "math"
+
+// simplifyLine 使用 Douglas-Peucker 算法简化折线，首尾点始终保留，结果至少 2 个点；不做任何闭合处理。
+func simplifyLine(points []Point, tol float64) []Point {
+	if len(points) <= 2 {
+		return points
+	}
+	return douglasPeucker(points, tol)
+}
+
+// simplifyRing 使用 Douglas-Peucker 算法简化开放形式的环（不含闭合点），首点始终保留。
+// 简化按闭合后的边界进行（末尾追加首点），结果少于 3 个点（闭合后不足 4 点）时返回原环，保证多边形有效。
+func simplifyRing(open []Point, tol float64) []Point {
+	if len(open) <= 3 {
+		return open
+	}
+	closed := make([]Point, len(open)+1)
+	copy(closed, open)
+	closed[len(open)] = open[0]
+	simplified := douglasPeucker(closed, tol)
+	simplified = simplified[:len(simplified)-1] // 移除闭合点，由调用方按需重新闭合
+	if len(simplified) < 3 {
+		return open
+	}
+	return simplified
+}
+
+// douglasPeucker 简化折线，保留首尾点，移除到所在简化线段距离不超过 tol 的中间点。
+func douglasPeucker(points []Point, tol float64) []Point {
+	n := len(points)
+	if n <= 2 {
+		return points
+	}
+	keep := make([]bool, n)
+	keep[0], keep[n-1] = true, true
+	// 使用显式栈代替递归，避免长折线导致过深的调用栈
+	stack := [][2]int{{0, n - 1}}
+	for len(stack) > 0 {
+		seg := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		first, last := seg[0], seg[1]
+		maxDist, index := 0.0, -1
+		for i := first + 1; i < last; i++ {
+			if d := segmentDistance(points[i], points[first], points[last]); d > maxDist {
+				maxDist, index = d, i
+			}
+		}
+		if index >= 0 && maxDist > tol {
+			keep[index] = true
+			stack = append(stack, [2]int{first, index}, [2]int{index, last})
+		}
+	}
+	result := make([]Point, 0, n)
+	for i, p := range points {
+		if keep[i] {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// segmentDistance 计算点 p 到线段 ab 的最短距离；a、b 重合时为到该点的距离。
+func segmentDistance(p, a, b Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	lenSq := dx*dx + dy*dy
+	if lenSq == 0 {
+		return math.Hypot(p.X-a.X, p.Y-a.Y)
+	}
+	t := ((p.X-a.X)*dx + (p.Y-a.Y)*dy) / lenSq
+	t = max(0, min(1, t))
+	return math.Hypot(p.X-(a.X+t*dx), p.Y-(a.Y+t*dy))
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"testing"
+)
+
+// collinear 返回沿东向排列的 n 个共线点，点号从 1 开始。
+func collinear(n int) Ring {
+	ring := make(Ring, n)
+	for i := range ring {
+		ring[i] = Point{ID: i + 1, X: 0, Y: float64(i)}
+	}
+	return ring
+}
+
+func TestSimplifyLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		points Ring
+		want   int
+	}{
+		{"10 个共线点简化为 2 个", collinear(10), 2},
+		{"两点线保持", collinear(2), 2},
+		{"折点保留", Ring{{X: 0, Y: 0}, {X: 0, Y: 5}, {X: 5, Y: 5}}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := simplifyLine(tt.points, 0.01)
+			if len(got) != tt.want {
+				t.Fatalf("len(simplifyLine) = %d, want %d: %v", len(got), tt.want, got)
+			}
+			if got[0] != tt.points[0] || got[len(got)-1] != tt.points[len(tt.points)-1] {
+				t.Errorf("首尾点未保留: %v", got)
+			}
+		})
+	}
+}
+
+func TestSimplifyPolygonRing(t *testing.T) {
+	// 每条边中点插入共线点的正方形
+	withMidpoints := Ring{
+		{ID: 1, X: 0, Y: 0}, {ID: 2, X: 0, Y: 5}, {ID: 3, X: 0, Y: 10}, {ID: 4, X: 5, Y: 10},
+		{ID: 5, X: 10, Y: 10}, {ID: 6, X: 10, Y: 5}, {ID: 7, X: 10, Y: 0}, {ID: 8, X: 5, Y: 0},
+		{ID: 1, X: 0, Y: 0},
+	}
+	tests := []struct {
+		name string
+		ring Ring
+		want int
+	}{
+		{"正方形保持", square(0, 0, 10, 1), 5},
+		{"边上共线点被移除", withMidpoints, 5},
+		{"简化后不足 4 点时保留原环", Ring{{X: 0, Y: 0}, {X: 0, Y: 5}, {X: 0.001, Y: 10}, {X: 0, Y: 0}}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := processRing(tt.ring, 1e3, 0.001, GeometryOptions{AutoClose: true, SimplifyTolerance: 0.01}, true)
+			if len(got) != tt.want {
+				t.Fatalf("len(processRing) = %d, want %d: %v", len(got), tt.want, got)
+			}
+			if got[0] != got[len(got)-1] {
+				t.Errorf("面环未闭合: %v", got)
+			}
+		})
+	}
+}
+
+func TestPostProcessGeometrySimplifyLine(t *testing.T) {
+	data := &ParsedData{Parcels: []Parcel{{
+		Attributes: map[string]string{KeyPID: "1", KeyGType: "线"},
+		Rings:      []Ring{collinear(10)},
+	}}}
+	if err := PostProcessGeometry(data, GeometryOptions{AutoClose: true, SimplifyTolerance: 0.01}); err != nil {
+		t.Fatalf("PostProcessGeometry: %v", err)
+	}
+	got := data.Parcels[0].Rings[0]
+	if len(got) != 2 || got[0].ID != 1 || got[1].ID != 10 {
+		t.Fatalf("线简化结果 = %v, want 首尾两点且不闭合", got)
+	}
+}
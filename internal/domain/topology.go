@@ -0,0 +1,142 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package domain
+
+import "fmt"
+
+// IntersectionReport 描述环中一对相交的线段。
+// 线段 i 为 ring[i] -> ring[i+1]；X/Y 为交点坐标（共线重叠时为重叠部分的一个端点）。
+type IntersectionReport struct {
+	Segment1 int
+	Segment2 int
+	X        float64
+	Y        float64
+}
+
+// DetectSelfIntersections 检测环的自相交，返回所有相交的线段对（Segment1 < Segment2）。
+// 使用 O(n²) 两两比较，地块环点数通常较少；相邻线段（含闭合环首尾两段）共享端点不视为相交。
+// 首尾不重合的环按隐含的闭合线段（末点 -> 首点）参与检测，该线段序号为 len(ring)-1。
+func DetectSelfIntersections(ring []Point) []IntersectionReport {
+	return detectIntersections(ring, true)
+}
+
+// detectIntersections 两两比较 pts 中的线段。asRing 为 true 时按环处理，必要时补上隐含的闭合线段；
+// 为 false 时按开放路径（线）处理，不补闭合线段，仅当首尾点本身重合时忽略首尾两段在该点的接触。
+func detectIntersections(pts []Point, asRing bool) []IntersectionReport {
+	if asRing && len(pts) > 0 && (pts[0].X != pts[len(pts)-1].X || pts[0].Y != pts[len(pts)-1].Y) {
+		pts = append(pts[:len(pts):len(pts)], pts[0])
+	}
+	n := len(pts) - 1 // 线段数
+	if n < 3 {
+		return nil
+	}
+	closed := pts[0].X == pts[n].X && pts[0].Y == pts[n].Y
+	var reports []IntersectionReport
+	for i := 0; i < n; i++ {
+		for j := i + 2; j < n; j++ {
+			if closed && i == 0 && j == n-1 {
+				continue // 首尾两段在闭合点相邻
+			}
+			if x, y, ok := segmentIntersection(pts[i], pts[i+1], pts[j], pts[j+1]); ok {
+				reports = append(reports, IntersectionReport{Segment1: i, Segment2: j, X: x, Y: y})
+			}
+		}
+	}
+	return reports
+}
+
+// IssueKind 标识几何问题的类型。
+type IssueKind string
+
+const (
+	IssueTooFewPoints     IssueKind = "too_few_points"    // 面的环少于 4 个点
+	IssueUnclosed         IssueKind = "unclosed"          // 面的环首尾不闭合
+	IssueZeroArea         IssueKind = "zero_area"         // 面的环面积为 0（点全部共线或重合）
+	IssueSelfIntersection IssueKind = "self_intersection" // 环自相交
+)
+
+// GeometryIssue 描述一个地块环的几何问题，实现 error 接口以便直接记录日志。
+type GeometryIssue struct {
+	ParcelID string    // 地块编号；源文件未提供时为 "#序号"
+	Ring     int       // 环序号（从 1 开始）
+	Kind     IssueKind // 问题类型
+	Detail   string    // 问题说明
+}
+
+func (i GeometryIssue) Error() string {
+	return fmt.Sprintf("地块 %s 的环 %d %s", i.ParcelID, i.Ring, i.Detail)
+}
+
+// ValidateGeometry 检查所有地块环的几何问题：面的环点数不足 4 个、首尾不闭合、面积为 0，以及面的环与线的自相交。
+// 点地块（gtype 为 "点"）的各点互不相连，不做检查；线按开放路径检测自相交，不补首尾闭合线段。
+// 该函数为诊断用途，需显式调用，应在几何后处理（PostProcessGeometry 去重、闭合）之后对结果调用。
+func ValidateGeometry(pd *ParsedData) []GeometryIssue {
+	if pd == nil {
+		return nil
+	}
+	var issues []GeometryIssue
+	for pi, parcel := range pd.Parcels {
+		kind := parcelGeometryKind(parcel)
+		if kind == kindPoint {
+			continue
+		}
+		pid := parcelLabel(parcel, pi)
+		for ri, ring := range parcel.Rings {
+			issue := func(k IssueKind, format string, args ...any) {
+				issues = append(issues, GeometryIssue{ParcelID: pid, Ring: ri + 1, Kind: k, Detail: fmt.Sprintf(format, args...)})
+			}
+			if kind == kindPolygon {
+				n := len(ring)
+				if n < kindPolygon.minPoints() {
+					issue(IssueTooFewPoints, "点数不足：%d 个点，面至少需要 %d 个点", n, kindPolygon.minPoints())
+				}
+				if n > 0 && (ring[0].X != ring[n-1].X || ring[0].Y != ring[n-1].Y) {
+					issue(IssueUnclosed, "未闭合：首点 (X=%.3f, Y=%.3f) 与末点 (X=%.3f, Y=%.3f) 不重合", ring[0].X, ring[0].Y, ring[n-1].X, ring[n-1].Y)
+				}
+				if n >= kindPolygon.minPoints() && RingArea(ring) == 0 {
+					issue(IssueZeroArea, "面积为 0（点全部共线或重合）")
+				}
+			}
+			for _, r := range detectIntersections(ring, kind == kindPolygon) {
+				issue(IssueSelfIntersection, "自相交：线段 %d 与线段 %d 相交于 (X=%.3f, Y=%.3f)", r.Segment1+1, r.Segment2+1, r.X, r.Y)
+			}
+		}
+	}
+	return issues
+}
+
+// segmentIntersection 判断线段 p1p2 与 p3p4 是否相交（含端点接触与共线重叠），相交时返回一个交点。
+func segmentIntersection(p1, p2, p3, p4 Point) (x, y float64, ok bool) {
+	d1 := orientation(p3, p4, p1)
+	d2 := orientation(p3, p4, p2)
+	d3 := orientation(p1, p2, p3)
+	d4 := orientation(p1, p2, p4)
+
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		t := d1 / (d1 - d2)
+		return p1.X + t*(p2.X-p1.X), p1.Y + t*(p2.Y-p1.Y), true
+	}
+	switch {
+	case d1 == 0 && onSegment(p3, p4, p1):
+		return p1.X, p1.Y, true
+	case d2 == 0 && onSegment(p3, p4, p2):
+		return p2.X, p2.Y, true
+	case d3 == 0 && onSegment(p1, p2, p3):
+		return p3.X, p3.Y, true
+	case d4 == 0 && onSegment(p1, p2, p4):
+		return p4.X, p4.Y, true
+	}
+	return 0, 0, false
+}
+
+// orientation 返回向量 ab 与 ac 的叉积：>0 表示 c 在 ab 左侧，<0 在右侧，=0 共线。
+func orientation(a, b, c Point) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+// onSegment 判断与线段 ab 共线的点 c 是否位于 ab 的包围盒内。
+func onSegment(a, b, c Point) bool {
+	return min(a.X, b.X) <= c.X && c.X <= max(a.X, b.X) &&
+		min(a.Y, b.Y) <= c.Y && c.Y <= max(a.Y, b.Y)
+}
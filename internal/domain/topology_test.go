@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+// pts 将 (x,y) 坐标对依次转换为点序列。
+func pts(xy ...float64) Ring {
+	ring := make(Ring, 0, len(xy)/2)
+	for i := 0; i+1 < len(xy); i += 2 {
+		ring = append(ring, Point{ID: i/2 + 1, X: xy[i], Y: xy[i+1]})
+	}
+	return ring
+}
+
+func TestValidateGeometry(t *testing.T) {
+	bowtie := pts(0, 0, 10, 10, 0, 10, 20, 0, 0, 0)
+	// 仅隐含的闭合线段 (10,12)->(0,0) 与第 2 段相交
+	openZ := pts(0, 0, 0, 10, 20, 0, 10, 12)
+
+	tests := []struct {
+		name  string
+		gtype string
+		ring  Ring
+		want  []IssueKind
+	}{
+		{"面：合法正方形", "面", square(0, 0, 10, 1), nil},
+		{"面：点数不足", "面", pts(0, 0, 1, 1, 0, 0), []IssueKind{IssueTooFewPoints}},
+		{"面：未闭合", "面", pts(0, 0, 0, 10, 10, 10, 10, 0), []IssueKind{IssueUnclosed}},
+		{"面：面积为 0", "面", pts(0, 0, 1, 1, 2, 2, 0, 0), []IssueKind{IssueZeroArea}},
+		{"面：自相交", "面", bowtie, []IssueKind{IssueSelfIntersection}},
+		{"面：隐含闭合线段自相交", "", openZ, []IssueKind{IssueUnclosed, IssueSelfIntersection}},
+		{"线：开放路径不补闭合线段", "线", openZ, nil},
+		{"线：不检查点数与闭合", "线", pts(0, 0, 5, 5), nil},
+		{"线：自相交", "线", pts(0, 0, 10, 10, 0, 10, 10, 0), []IssueKind{IssueSelfIntersection}},
+		{"线：首尾重合的闭合线", "line", square(0, 0, 10, 1), nil},
+		{"点：散点不视为线段", "点", pts(0, 0, 10, 10, 0, 10, 10, 0, 0, 0), nil},
+		{"点：单点", "point", pts(3, 4), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pd := &ParsedData{Parcels: []Parcel{{
+				Attributes: map[string]string{KeyPID: "P1", KeyGType: tt.gtype},
+				Rings:      []Ring{tt.ring},
+			}}}
+			issues := ValidateGeometry(pd)
+			var got []IssueKind
+			for _, issue := range issues {
+				got = append(got, issue.Kind)
+				if issue.ParcelID != "P1" || issue.Ring != 1 {
+					t.Errorf("问题应标注地块 P1 的环 1: %+v", issue)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ValidateGeometry = %v, want %v", issues, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateGeometryRingIndex(t *testing.T) {
+	pd := &ParsedData{Parcels: []Parcel{
+		{Rings: []Ring{square(0, 0, 10, 1)}},
+		{Rings: []Ring{square(0, 0, 10, 1), pts(0, 0, 1, 1, 0, 0)}},
+	}}
+	issues := ValidateGeometry(pd)
+	if len(issues) != 1 {
+		t.Fatalf("ValidateGeometry = %v, want 1 个问题", issues)
+	}
+	if got := issues[0]; got.ParcelID != "#2" || got.Ring != 2 || got.Kind != IssueTooFewPoints {
+		t.Errorf("问题 = %+v, want 地块 #2 环 2 点数不足", got)
+	}
+	if ValidateGeometry(nil) != nil {
+		t.Error("ValidateGeometry(nil) 应返回 nil")
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package domain
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// WKB 几何类型代码（ISO/OGC），带 Z/M 维度时分别加 1000/2000，ZM 加 3000。
+const (
+	wkbPoint           uint32 = 1
+	wkbLineString      uint32 = 2
+	wkbPolygon         uint32 = 3
+	wkbMultiPoint      uint32 = 4
+	wkbMultiLineString uint32 = 5
+	wkbMultiPolygon    uint32 = 6
+)
+
+// wkbWriter 以小端字节序 (NDR) 追加 WKB 内容。
+type wkbWriter struct {
+	buf        []byte
+	axis       AxisOrder
+	hasZ, hasM bool
+}
+
+// header 写入字节序标记与带维度的几何类型代码。
+func (w *wkbWriter) header(geomType uint32) {
+	if w.hasZ {
+		geomType += 1000
+	}
+	if w.hasM {
+		geomType += 2000
+	}
+	w.buf = append(w.buf, 1) // 1 = 小端
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, geomType)
+}
+
+func (w *wkbWriter) uint32(n int) {
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(n))
+}
+
+// point 按 axis 顺序写入一个点的坐标，hasZ/hasM 时依次追加高程与测量值。
+func (w *wkbWriter) point(p Point) {
+	first, second := w.axis.ordered(p)
+	coords := []float64{first, second}
+	if w.hasZ {
+		coords = append(coords, p.Z)
+	}
+	if w.hasM {
+		coords = append(coords, p.M)
+	}
+	for _, c := range coords {
+		w.buf = binary.LittleEndian.AppendUint64(w.buf, math.Float64bits(c))
+	}
+}
+
+// ring 写入点数与所有点。
+func (w *wkbWriter) ring(ring []Point) {
+	w.uint32(len(ring))
+	for _, p := range ring {
+		w.point(p)
+	}
+}
+
+// buildFeatureWKB 按地块 gtype 构建 ISO WKB（小端），几何类型规则与 buildFeatureWKT 一致。
+// 与 WKT 不同，坐标以完整的 float64 精度写出，不按小数位数取整。
+func buildFeatureWKB(parcel Parcel, axis AxisOrder) ([]byte, error) {
+	kind := parcelGeometryKind(parcel)
+	if kind == kindPolygon {
+		return buildPolygonWKB(parcel, axis)
+	}
+	if err := validateParcelPoints(parcel, kind); err != nil {
+		return nil, err
+	}
+	w := &wkbWriter{axis: axis, hasZ: parcelHasZ(parcel), hasM: parcelHasM(parcel)}
+
+	if kind == kindPoint {
+		var pts []Point
+		for _, ring := range parcel.Rings {
+			pts = append(pts, ring...)
+		}
+		if len(pts) == 1 {
+			w.header(wkbPoint)
+			w.point(pts[0])
+			return w.buf, nil
+		}
+		w.header(wkbMultiPoint)
+		w.uint32(len(pts))
+		for _, p := range pts {
+			w.header(wkbPoint)
+			w.point(p)
+		}
+		return w.buf, nil
+	}
+
+	if len(parcel.Rings) == 1 {
+		w.header(wkbLineString)
+		w.ring(parcel.Rings[0])
+		return w.buf, nil
+	}
+	w.header(wkbMultiLineString)
+	w.uint32(len(parcel.Rings))
+	for _, ring := range parcel.Rings {
+		w.header(wkbLineString)
+		w.ring(ring)
+	}
+	return w.buf, nil
+}
+
+// buildPolygonWKB 构建单个地块的 WKB（Polygon 或 MultiPolygon），环的校验与分组与 buildPolygonWKTInternal 一致。
+func buildPolygonWKB(parcel Parcel, axis AxisOrder) ([]byte, error) {
+	if err := validateParcelRings(parcel); err != nil {
+		return nil, err
+	}
+	w := &wkbWriter{axis: axis, hasZ: parcelHasZ(parcel), hasM: parcelHasM(parcel)}
+
+	polygons := classifyRings(parcel.Rings)
+	polygon := func(poly []int) {
+		w.header(wkbPolygon)
+		w.uint32(len(poly))
+		for _, ri := range poly {
+			w.ring(parcel.Rings[ri])
+		}
+	}
+	if len(polygons) == 1 {
+		polygon(polygons[0])
+		return w.buf, nil
+	}
+	w.header(wkbMultiPolygon)
+	w.uint32(len(polygons))
+	for _, poly := range polygons {
+		polygon(poly)
+	}
+	return w.buf, nil
+}
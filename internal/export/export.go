@@ -4,10 +4,17 @@ Copyright © 2025 TheMachine <592858548@qq.com>
 package export
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
 	"txt2geo/internal/domain"
 	"txt2geo/internal/process"
+	"txt2geo/internal/util"
 	"txt2geo/pkg/charset"
 	"txt2geo/pkg/logger"
 	"txt2geo/pkg/pathx"
@@ -15,9 +22,19 @@ import (
 
 var filterExtensions = []string{".txt"}
 
+// outputSizeFactor 是输出文件大小相对 JSON 负载大小的保守估算倍数（含空间索引等额外开销）。
+const outputSizeFactor = 2
+
+// GeneratedMarker 是本工具生成的文本文件必须写在首行的标记。
+// 收集输入时默认跳过带有该标记的文件，防止输出与输入共用目录和扩展名时被反复处理。
+const GeneratedMarker = "# Generated by TXT2GEO"
+
 // ErrNoInputFiles 表示未找到任何可用于导出的输入文件。
 var ErrNoInputFiles = errors.New("未找到可导出的输入文件")
 
+// ErrTargetExists 表示输出目标已存在且未开启 --overwrite。
+var ErrTargetExists = errors.New("目标文件已存在，需要 --overwrite")
+
 type FileCache struct {
 	Path    string
 	Content []byte
@@ -30,15 +47,31 @@ type ProcessedFile struct {
 	Features  []map[string]any
 	CRS       string
 	EPSG      int
+	CRSName   string // 投影坐标系名称，用于名称模板 {crs}
 }
 
 // Exporter 是负责执行整个导出流程的协调器。
 type Exporter struct {
 	Config        ExportConfig
 	History       *process.ProcessHistory
+	Manifest      *process.Manifest // 已导出哈希与输出位置的对应清单
 	FileCache     map[string]FileCache
 	ProcessedData map[string]*ProcessedFile // 存储已处理成功的文件数据
 	UsedNames     map[string]struct{}
+	// Progress 可选的进度回调，供 GUI/TUI 等嵌入方展示进度；为 nil 时不回调
+	Progress ProgressFunc
+
+	progressMu sync.Mutex
+	progress   map[string]*stageProgress // 各阶段进度
+
+	qgisCheck    sync.Once // 保证 QGIS Python 可用性检查只执行一次
+	qgisCheckErr error
+	runner       commandRunner // 创建 Python 子进程，nil 时使用 exec.CommandContext
+
+	results *ndjsonWriter                   // 逐文件结果流（未配置时为 nil）
+	rejects *ndjsonWriter                   // 被剔除要素输出（未配置时为 nil）
+	timings map[string]*fileTiming          // 按路径记录的逐文件阶段耗时
+	targets map[string]process.OutputTarget // 本次运行中哈希对应的输出位置
 }
 
 // NewExporter 创建一个新的导出器实例。
@@ -50,62 +83,274 @@ func NewExporter(config ExportConfig) (*Exporter, error) {
 		return nil, fmt.Errorf("环境配置失败: %w", err)
 	}
 
-	history, err := process.NewProcessHistory(config.ProcessFilePath())
+	history, err := process.NewProcessHistoryWithOptions(config.ProcessFilePath(), process.HistoryOptions{JSON: config.HistoryJSON})
 	if err != nil {
 		return nil, fmt.Errorf("无法初始化处理历史: %w", err)
 	}
+	if config.HistoryTTL > 0 && !config.DryRun {
+		pruned, err := history.PruneOlderThan(config.HistoryTTL)
+		if err != nil {
+			return nil, fmt.Errorf("清理过期处理历史失败: %w", err)
+		}
+		if pruned > 0 {
+			logger.Log().Info("[历史] 已清理过期的处理历史", "数量", pruned, "有效期", config.HistoryTTL)
+		}
+	}
+	manifest, err := process.NewManifest(config.ManifestFilePath())
+	if err != nil {
+		return nil, fmt.Errorf("无法初始化输出清单: %w", err)
+	}
 	return &Exporter{
 		Config:        config,
 		History:       history,
+		Manifest:      manifest,
 		FileCache:     make(map[string]FileCache),
 		ProcessedData: make(map[string]*ProcessedFile),
 		UsedNames:     make(map[string]struct{}),
 	}, nil
 }
 
+// checkExtent 检查坐标范围是否合理（发现 X/Y 列互换等错误）。
+// 指定了 SkipExtentCheck 或投影覆盖参数（地方/工程坐标网的坐标不在标准范围内）时跳过。
+func (e *Exporter) checkExtent(parsed *domain.ParsedData) error {
+	if e.Config.SkipExtentCheck || e.Config.Projection != (domain.ProjectionOptions{}) {
+		return nil
+	}
+	return domain.SanityCheckExtent(parsed)
+}
+
+// logPerFile 输出逐文件日志；SummaryOnly 模式下统一降级为 Debug，仅保留汇总信息。
+func (e *Exporter) logPerFile(level slog.Level, msg string, args ...any) {
+	if e.Config.SummaryOnly {
+		level = slog.LevelDebug
+	}
+	logger.Log().Log(context.Background(), level, msg, args...)
+}
+
 // processSingleFileResult 存储单个文件成功处理后的结果（内部使用）
 type processSingleFileResult struct {
 	Features []map[string]any
 	CRS      string
 	EPSG     int
+	CRSName  string
+	Encoding string // 检测到的原始编码
+	Parcels  int    // 解析出的地块数
+	Rejected []domain.RejectedFeature
 }
 
 // processSingleFile 封装了处理单个文件的完整逻辑。
+// 出错时返回的结果仍可能携带已知的元信息（编码、地块数），仅供统计使用。
+// 结果中 Features 为空表示没有错误，但也没有要素。
 func (e *Exporter) processSingleFile(fileData FileCache) (*processSingleFileResult, error) {
-	logger.Log().Debug("  [处理] 处理文件", "路径", fileData.Path, "大小", fmt.Sprintf("%d bytes", len(fileData.Content)))
-	text, _, err := charset.Decode(fileData.Content)
+	logger.Log().Debug("  [处理] 处理文件", "路径", fileData.Path, "大小", util.HumanBytes(int64(len(fileData.Content))))
+	res := &processSingleFileResult{}
+	var (
+		text string
+		err  error
+	)
+	if e.Config.Encoding != "" {
+		res.Encoding = e.Config.Encoding
+		text, err = charset.DecodeWith(fileData.Content, e.Config.Encoding)
+	} else {
+		text, res.Encoding, err = charset.Decode(fileData.Content)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("文件解码失败: %w", err)
+		return res, fmt.Errorf("文件解码失败: %w", err)
+	}
+	if crlf, lf, cr := charset.LineEndingStats([]byte(text)); mixedLineEndings(crlf, lf, cr) {
+		e.logPerFile(slog.LevelWarn, "[警告] 换行符混用", "文件", fileData.Path, "CRLF", crlf, "LF", lf, "CR", cr)
 	}
-	parsed, err := domain.Parse(text)
+	parsed, err := domain.ParseWith(text, e.Config.ParseOptions())
 	if err != nil {
-		return nil, fmt.Errorf("文件解析失败: %w", err)
+		return res, fmt.Errorf("文件解析失败: %w", err)
+	}
+	res.Parcels = len(parsed.Parcels)
+	geomOpts := domain.GeometryOptions{
+		Deduplicate:       true,
+		AutoClose:         true,
+		MergeLabels:       true,
+		FixWinding:        e.Config.FixWinding,
+		EmitBBox:          e.Config.EmitBBox,
+		GeoJSON:           e.Config.FormatDetails.Code == "GEOJSON",
+		Metrics:           e.Config.ComputeMetrics,
+		SimplifyTolerance: e.Config.SimplifyTolerance,
+		Projection:        e.Config.Projection,
+		SkipInvalid:       e.Config.SkipInvalid,
+	}
+	res.Rejected = domain.BuildRejectedFeatures(parsed, geomOpts)
+	for _, w := range parsed.Warnings {
+		e.logPerFile(slog.LevelWarn, "[警告] 解析警告", "文件", fileData.Path, "原因", w)
+	}
+	if err := e.checkExtent(parsed); err != nil {
+		return res, fmt.Errorf("坐标范围检查失败: %w", err)
 	}
-	prepData, err := domain.BuildGeometryPreprocessData(parsed, domain.GeometryOptions{Deduplicate: true, AutoClose: true})
+	if e.Config.CheckPointCount {
+		for _, perr := range domain.ValidatePointCounts(parsed) {
+			e.logPerFile(slog.LevelWarn, "[警告] 界址点数不符", "文件", fileData.Path, "原因", perr)
+		}
+	}
+	warnBefore := len(parsed.Warnings)
+	prepData, err := domain.BuildGeometryPreprocessData(parsed, geomOpts)
 	if err != nil {
-		return nil, fmt.Errorf("几何预处理数据构建失败: %w", err)
+		return res, fmt.Errorf("几何预处理数据构建失败: %w", err)
 	}
-
-	if len(prepData.Features) == 0 {
-		return nil, nil // 没有错误，但也没有要素
+	for _, w := range parsed.Warnings[warnBefore:] {
+		e.logPerFile(slog.LevelWarn, "[警告] 坐标系警告", "文件", fileData.Path, "原因", w)
+	}
+	if len(prepData.Skipped) > 0 {
+		for _, rp := range prepData.Skipped {
+			e.logPerFile(slog.LevelWarn, "[警告] 跳过无效地块", "文件", fileData.Path, "原因", rp.Reason) // 原因中已包含地块编号
+		}
+		// 被跳过的地块已追加到 parsed.Rejected，重新生成以便写入 --rejects-ndjson
+		res.Rejected = domain.BuildRejectedFeatures(parsed, geomOpts)
+	}
+	if e.Config.CheckGeometry {
+		// BuildGeometryPreprocessData 已就地完成去重与闭合，此处检查的即为实际输出的环
+		for _, issue := range domain.ValidateGeometry(parsed) {
+			e.logPerFile(slog.LevelWarn, "[警告] 几何问题", "文件", fileData.Path, "原因", issue)
+		}
 	}
 
 	featList := make([]map[string]any, 0, len(prepData.Features))
 	for _, feat := range prepData.Features {
-		featList = append(featList, map[string]any{"wkt": feat.WKT, "properties": feat.Attributes})
+		item := map[string]any{"wkt": feat.WKT, "properties": e.Config.mapFields(feat.Attributes)}
+		if feat.BBox != nil {
+			item["bbox"] = feat.BBox[:]
+		}
+		if feat.GeoJSON != nil {
+			item["geojson"] = feat.GeoJSON
+		}
+		featList = append(featList, item)
 	}
 
-	return &processSingleFileResult{
-		Features: featList,
-		CRS:      prepData.CRS,
-		EPSG:     prepData.EPSG,
-	}, nil
+	res.Features = featList
+	res.CRS = prepData.CRS
+	res.EPSG = prepData.EPSG
+	res.CRSName = prepData.CRSName
+	return res, nil
+}
+
+// processOutput 是单个文件的预处理结果。
+type processOutput struct {
+	hash    string
+	file    FileCache
+	result  *processSingleFileResult
+	err     error
+	elapsed time.Duration
+}
+
+// processFiles 使用 Config.Concurrency 个 worker 并发预处理 FileCache 中的所有文件，结果按完成顺序发送到返回的通道。
+// worker 只调用 processSingleFile；缓存、历史与结果记录等共享状态由消费方在单个 goroutine 中更新。
+func (e *Exporter) processFiles() <-chan processOutput {
+	// 先取快照：消费方会在处理结果时从 FileCache 中删除失败的文件
+	files := slices.Collect(maps.Values(e.FileCache))
+	jobs := make(chan FileCache)
+	outs := make(chan processOutput)
+	workers := max(1, min(e.Config.Concurrency, len(files)))
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for fileData := range jobs {
+				start := time.Now()
+				result, err := e.processSingleFile(fileData)
+				outs <- processOutput{hash: fileData.Hash, file: fileData, result: result, err: err, elapsed: time.Since(start)}
+			}
+		})
+	}
+	go func() {
+		for _, fileData := range files {
+			jobs <- fileData
+		}
+		close(jobs)
+		wg.Wait()
+		close(outs)
+	}()
+	return outs
+}
+
+// checkFreeSpace 在调用 Python 前预估输出所需空间并与目标卷剩余空间比较，空间不足时返回明确错误。
+// 估算值为负载大小乘以 outputSizeFactor；无法查询剩余空间时只记录警告，不阻止导出。
+func (e *Exporter) checkFreeSpace(result *ExecutionResult) error {
+	need := uint64(len(result.Payload)) * outputSizeFactor
+	free, err := pathx.FreeSpace(e.Config.OutputDir)
+	if err != nil {
+		logger.Log().Warn("[警告] 无法检查磁盘剩余空间", "输出", e.Config.OutputDir, "原因", err)
+		return nil
+	}
+	logger.Log().Debug("  [检查] 磁盘剩余空间", "输出", e.Config.OutputDir, "剩余", free, "预估需要", need)
+	if free < need {
+		return fmt.Errorf("磁盘剩余空间不足: %s 剩余 %d bytes，预估需要 %d bytes", e.Config.OutputDir, free, need)
+	}
+	return nil
+}
+
+// mixedLineEndings 判断是否有多于一种换行符。
+func mixedLineEndings(counts ...int) bool {
+	kinds := 0
+	for _, c := range counts {
+		if c > 0 {
+			kinds++
+		}
+	}
+	return kinds > 1
+}
+
+// recordRejects 写入单个文件中被剔除的要素；写入失败只记录警告，不影响导出流程。
+func (e *Exporter) recordRejects(path string, rejected []domain.RejectedFeature) {
+	for _, r := range rejected {
+		rec := RejectRecord{SourcePath: path, Reason: r.Reason, WKT: r.WKT, Properties: r.Attributes}
+		if err := e.rejects.Write(rec); err != nil {
+			logger.Log().Warn("[警告] 写入剔除记录失败", "文件", path, "原因", err)
+			return
+		}
+	}
+}
+
+// recordResult 写入一条逐文件结果；写入失败只记录警告，不影响导出流程。
+func (e *Exporter) recordResult(r FileResult) {
+	if err := e.results.Write(r); err != nil {
+		logger.Log().Warn("[警告] 写入结果记录失败", "文件", r.Path, "原因", err)
+	}
 }
 
 func (e *Exporter) Execute() error {
+	results, err := newNDJSONWriter(e.Config.ResultsNDJSONPath)
+	if err != nil {
+		return err
+	}
+	e.results = results
+	defer func() {
+		e.results.Close()
+		e.results = nil
+	}()
+	rejects, err := newNDJSONWriter(e.Config.RejectsNDJSONPath)
+	if err != nil {
+		return err
+	}
+	e.rejects = rejects
+	defer func() {
+		e.rejects.Close()
+		e.rejects = nil
+	}()
+	defer e.reportSlowest()
+
 	// 1. 收集所有源文件
 	logger.Log().Info("[开始] 处理导出任务", "预览模式", e.Config.DryRun, "强制刷新", e.Config.ForceRefresh)
-	sourceFiles, err := pathx.CollectFiles(e.Config.InputPaths, e.Config.Depth, filterExtensions, true)
+	collectOpts := pathx.CollectOptions{
+		MaxDepth:       e.Config.Depth,
+		Extensions:     filterExtensions,
+		Sort:           true,
+		Excludes:       e.Config.Excludes,
+		MinSize:        e.Config.MinSize,
+		MaxSize:        e.Config.MaxSize,
+		ModifiedAfter:  e.Config.ModifiedAfterTime,
+		ModifiedBefore: e.Config.ModifiedBeforeTime,
+	}
+	if !e.Config.IncludeGenerated {
+		collectOpts.SkipMarker = GeneratedMarker
+	}
+	sourceFiles, err := pathx.CollectFilesWithOptions(e.Config.InputPaths, collectOpts)
 	if err != nil {
 		return fmt.Errorf("收集文件失败: %w", err)
 	}
@@ -113,32 +358,48 @@ func (e *Exporter) Execute() error {
 		return ErrNoInputFiles
 	}
 
+	if e.Config.Resume {
+		if e.Config.DryRun {
+			logger.Log().Debug("[恢复] 预览模式，跳过处理历史核对")
+		} else if err := e.reconcileHistory(); err != nil {
+			return fmt.Errorf("恢复失败: %w", err)
+		}
+	}
+
 	// 2. 读取文件，计算哈希，准备内容缓存，去重（ForceRefresh 可强制重新处理）
 	var skipped, processed int
 	force := e.Config.ForceRefresh
 
+	e.startStage(StageCollect, len(sourceFiles))
 	for _, file := range sourceFiles {
+		e.advanceStage(StageCollect)
+		readStart := time.Now()
 		content, hash, err := pathx.ReadFile(file)
 		if err != nil {
 			return fmt.Errorf("读取文件 %s 失败: %w", file, err)
 		}
+		timing := e.timingFor(file)
+		timing.Read = time.Since(readStart)
+		readMs := durationMs(timing.Read)
 		if !e.Config.DryRun {
 			if !force { // 正常模式：检查历史决定是否跳过
-				if isNew, herr := e.History.CheckAndRecord(hash); herr != nil {
+				if isNew, herr := e.History.CheckAndRecordMeta(hash, file, e.Config.FormatKey); herr != nil {
 					return fmt.Errorf("检查文件 %s 的历史记录失败: %w", file, herr)
 				} else if !isNew { // 已存在
 					logger.Log().Debug("[跳过] 已处理文件", "文件", file)
+					e.recordResult(FileResult{Path: file, Hash: hash, Status: ResultSkipped, Error: "已处理文件", ReadMs: readMs})
 					skipped++
 					continue
 				}
 			} else { // ForceRefresh: 总是记录（写入历史），不跳过
-				if _, herr := e.History.CheckAndRecord(hash); herr != nil {
+				if _, herr := e.History.CheckAndRecordMeta(hash, file, e.Config.FormatKey); herr != nil {
 					return fmt.Errorf("强制记录文件 %s 失败: %w", file, herr)
 				}
 			}
 		}
 		if _, exists := e.FileCache[hash]; exists {
 			logger.Log().Debug("[跳过] 内容相同文件", "文件", file)
+			e.recordResult(FileResult{Path: file, Hash: hash, Status: ResultSkipped, Error: "内容相同文件", ReadMs: readMs})
 			skipped++
 			continue
 		}
@@ -156,25 +417,46 @@ func (e *Exporter) Execute() error {
 	// 3. 预处理所有文件，只保留成功处理的文件
 	logger.Log().Info("[处理] 开始预处理文件...")
 	var processFailed int
-	for hash, fileData := range e.FileCache {
-		result, err := e.processSingleFile(fileData)
+	e.startStage(StagePreprocess, processed)
+	for out := range e.processFiles() {
+		e.advanceStage(StagePreprocess)
+		hash, fileData, result, err := out.hash, out.file, out.result, out.err
+		timing := e.timingFor(fileData.Path)
+		timing.Process = out.elapsed
+		e.recordRejects(fileData.Path, result.Rejected)
+		rec := FileResult{
+			Path:       fileData.Path,
+			Hash:       hash,
+			Encoding:   result.Encoding,
+			Parcels:    result.Parcels,
+			Features:   len(result.Features),
+			Status:     ResultProcessed,
+			ReadMs:     durationMs(timing.Read),
+			DurationMs: durationMs(timing.Process),
+		}
 		if err != nil {
-			logger.Log().Error("[失败] 预处理失败", "文件", fileData.Path, "原因", err)
+			e.logPerFile(slog.LevelError, "[失败] 预处理失败", "文件", fileData.Path, "原因", err)
+			rec.Status, rec.Error = ResultFailed, err.Error()
+			e.recordResult(rec)
 			processFailed++
 			delete(e.FileCache, hash) // 从缓存中移除失败的文件
 			continue
 		}
-		if result == nil {
-			logger.Log().Warn("[警告] 文件无有效地块", "文件", fileData.Path)
+		if len(result.Features) == 0 {
+			e.logPerFile(slog.LevelWarn, "[警告] 文件无有效地块", "文件", fileData.Path)
+			rec.Status = ResultEmpty
+			e.recordResult(rec)
 			processFailed++
 			delete(e.FileCache, hash)
 			continue
 		}
+		e.recordResult(rec)
 		e.ProcessedData[hash] = &ProcessedFile{
 			FileCache: fileData,
 			Features:  result.Features,
 			CRS:       result.CRS,
 			EPSG:      result.EPSG,
+			CRSName:   result.CRSName,
 		}
 	}
 
@@ -207,10 +489,22 @@ func (e *Exporter) Execute() error {
 
 		e.previewPlans(plans)
 
+		// 预览模式下同样组装负载，以便写出调试文件
+		if e.Config.DumpPayload != "" && !e.Config.FormatDetails.Native {
+			if _, err := e.executePlans(plans); err != nil {
+				return fmt.Errorf("执行计划失败: %w", err)
+			}
+		}
+
 		logger.Log().Info("[预览] 预览模式，未执行实际导出操作")
 		return nil
 	}
 
+	// 原生格式直接在 Go 中写出，不调用 Python
+	if e.Config.FormatDetails.Native {
+		return e.executeNativePlans(plans)
+	}
+
 	result, err := e.executePlans(plans)
 	if err != nil {
 		return fmt.Errorf("执行计划失败: %w", err)
@@ -227,7 +521,10 @@ func (e *Exporter) Execute() error {
 		logger.Log().Info("[导出] 调用 QGIS Python 导出器",
 			"格式", e.Config.FormatKey,
 			"输出目录", e.Config.OutputDir)
-		err = e.InvokePythonExporter(result.Payload, result.LayerCount, result.FeatureCount)
+		if err := e.checkFreeSpace(result); err != nil {
+			return err
+		}
+		err = e.runPythonExport(result)
 		if err != nil {
 			return fmt.Errorf("调用 Python 导出失败: %w", err)
 		}
@@ -236,5 +533,5 @@ func (e *Exporter) Execute() error {
 	} else {
 		logger.Log().Warn("[警告] 没有可导出的数据")
 	}
-	return nil
+	return e.reportPerFile(result.PerFile)
 }
@@ -0,0 +1,115 @@
+package export
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"txt2geo/internal/domain"
+)
+
+// sampleContent 是一个最小的合法源文件：CGCS2000 3 度带第 38 带，一个闭合的三角形地块。
+// 与 domain 包的测试共用同一份夹具。
+var sampleContent = func() string {
+	data, err := os.ReadFile(filepath.Join("..", "domain", "testdata", "sample.txt"))
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}()
+
+// writeSample 在 dir 中写入名为 name 的示例源文件并返回其路径。
+func writeSample(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(sampleContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheckExtent(t *testing.T) {
+	parsed, err := domain.Parse(sampleContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 交换坐标列，使范围检查失败
+	for _, ring := range parsed.Parcels[0].Rings {
+		for i := range ring {
+			ring[i].X, ring[i].Y = ring[i].Y, ring[i].X
+		}
+	}
+	scale := 0.9996
+	tests := []struct {
+		name    string
+		config  ExportConfig
+		wantErr bool
+	}{
+		{"默认检查", ExportConfig{}, true},
+		{"skip-extent-check", ExportConfig{SkipExtentCheck: true}, false},
+		{"投影覆盖参数", ExportConfig{Projection: domain.ProjectionOptions{ScaleFactor: &scale}}, false},
+		{"北偏移", ExportConfig{Projection: domain.ProjectionOptions{FalseNorthing: 10000000}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Exporter{Config: tt.config}
+			if err := e.checkExtent(parsed); (err != nil) != tt.wantErr {
+				t.Errorf("checkExtent() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProcessFilesConcurrency(t *testing.T) {
+	cfg := newTestConfig(t)
+	if err := cfg.Verify(); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	// 200 个小文件，每 7 个中有一个缺少坐标部分，预处理失败
+	fileCache := make(map[string]FileCache, 200)
+	for i := range 200 {
+		content := strings.Replace(sampleContent, "地块1", fmt.Sprintf("地块%d", i), 1)
+		if i%7 == 0 {
+			content = strings.Replace(content, "[地块坐标]", "", 1)
+		}
+		hash := fmt.Sprintf("h%03d", i)
+		fileCache[hash] = FileCache{Path: fmt.Sprintf("f%03d.txt", i), Content: []byte(content), Hash: hash}
+	}
+
+	// run 以 concurrency 个 worker 预处理所有文件，返回 哈希 -> 要素数（失败为 -1）
+	run := func(concurrency int) map[string]int {
+		cfg.Concurrency = concurrency
+		e := &Exporter{Config: cfg, FileCache: fileCache}
+		got := make(map[string]int, len(fileCache))
+		for out := range e.processFiles() {
+			if out.err != nil {
+				got[out.hash] = -1
+				continue
+			}
+			got[out.hash] = len(out.result.Features)
+		}
+		return got
+	}
+
+	want := run(1)
+	if len(want) != len(fileCache) {
+		t.Fatalf("顺序处理结果数 = %d, want %d", len(want), len(fileCache))
+	}
+	failed := 0
+	for _, n := range want {
+		if n < 0 {
+			failed++
+		}
+	}
+	if failed != 29 {
+		t.Fatalf("顺序处理失败数 = %d, want 29", failed)
+	}
+	for _, concurrency := range []int{4, 16} {
+		if got := run(concurrency); !maps.Equal(got, want) {
+			t.Errorf("Concurrency=%d 的结果与顺序处理不一致:\n got %v\nwant %v", concurrency, got, want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// filterOperators 按匹配优先级排列（双字符运算符在前，避免 ">=" 被识别为 ">"）。
+var filterOperators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// featureFilter 是形如 "area>1000" 或 "usage==耕地" 的单条属性谓词。
+type featureFilter struct {
+	Key      string
+	Op       string
+	Value    string
+	Number   float64
+	IsNumber bool // 比较值可解析为数字时按数值比较，否则按字符串比较
+}
+
+// parseFilter 解析过滤表达式；值两侧的引号会被去除，值为数字时按数值比较。
+func parseFilter(expr string) (*featureFilter, error) {
+	idx, op := -1, ""
+	for _, candidate := range filterOperators {
+		if i := strings.Index(expr, candidate); i >= 0 && (idx < 0 || i < idx) {
+			idx, op = i, candidate
+		}
+	}
+	if idx < 0 {
+		return nil, fmt.Errorf("无效的过滤表达式 '%s'，支持 ==、!=、>、<、>=、<=", expr)
+	}
+	key := strings.TrimSpace(expr[:idx])
+	value := strings.TrimSpace(expr[idx+len(op):])
+	if key == "" {
+		return nil, fmt.Errorf("无效的过滤表达式 '%s'：缺少属性名", expr)
+	}
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	f := &featureFilter{Key: key, Op: op, Value: value}
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		f.Number, f.IsNumber = n, true
+	}
+	return f, nil
+}
+
+// Match 判断属性表是否满足谓词；属性缺失时不匹配，数值比较时属性无法解析为数字也不匹配。
+func (f *featureFilter) Match(props map[string]any) bool {
+	v, ok := props[f.Key]
+	if !ok || v == nil {
+		return false
+	}
+	var cmp int
+	if f.IsNumber {
+		n, ok := toFloat(v)
+		if !ok {
+			return false
+		}
+		switch {
+		case n < f.Number:
+			cmp = -1
+		case n > f.Number:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(strings.TrimSpace(fmt.Sprint(v)), f.Value)
+	}
+	switch f.Op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	case ">=":
+		return cmp >= 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// toFloat 将属性值转换为数字，支持数值类型与可解析为数字的字符串。
+func toFloat(v any) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case int:
+		return float64(val), true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// filterFeatures 返回满足 Config.Filter 的要素；未配置过滤时原样返回。
+func (e *Exporter) filterFeatures(features []map[string]any) []map[string]any {
+	f := e.Config.filter
+	if f == nil {
+		return features
+	}
+	kept := make([]map[string]any, 0, len(features))
+	for _, feat := range features {
+		props, _ := feat["properties"].(map[string]any)
+		if f.Match(props) {
+			kept = append(kept, feat)
+		}
+	}
+	return kept
+}
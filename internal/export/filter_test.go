@@ -0,0 +1,78 @@
+package export
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFeatureFilterMatch(t *testing.T) {
+	props := map[string]any{"area": 1000.0, "bp_cnt": 4, "code": "0101", "usage": "耕地", "pid": " 12 "}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"area==1000", true},
+		{"area!=1000", false},
+		{"area>999.5", true},
+		{"area>1000", false},
+		{"area<1000", false},
+		{"area<1000.5", true},
+		{"area>=1000", true},
+		{"area<=1000", true},
+		{"area<=999", false},
+		{"bp_cnt>=4", true},
+		{"pid==12", true}, // 可解析为数字的字符串按数值比较
+		{"usage==耕地", true},
+		{"usage=='耕地'", true},
+		{`usage!="耕地"`, false},
+		{"usage>耕", true}, // 非数字按字符串比较
+		{"code==101", true},
+		{"code=='0101'", true},
+		{"usage>1", false},    // 数值比较时属性无法解析为数字
+		{"missing==1", false}, // 属性缺失
+		{"missing!=1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := parseFilter(tt.expr)
+			if err != nil {
+				t.Fatalf("parseFilter: %v", err)
+			}
+			if got := f.Match(props); got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantKey string
+		wantOp  string
+		wantErr string
+	}{
+		{" area >= 10 ", "area", ">=", ""},
+		{"a<=b", "a", "<=", ""},
+		{"note==x>y", "note", "==", ""}, // 取最靠前的运算符
+		{"area", "", "", "支持"},
+		{"==1", "", "", "缺少属性名"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := parseFilter(tt.expr)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFilter: %v", err)
+			}
+			if f.Key != tt.wantKey || f.Op != tt.wantOp {
+				t.Errorf("Key/Op = %q/%q, want %q/%q", f.Key, f.Op, tt.wantKey, tt.wantOp)
+			}
+		})
+	}
+}
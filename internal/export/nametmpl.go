@@ -4,25 +4,48 @@ Copyright © 2025 TheMachine <592858548@qq.com>
 package export
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	_ "time/tzdata" // Windows 上不一定有系统时区库，内嵌以支持 {date::Asia/Shanghai} 等时区名
 	"txt2geo/internal/util"
 )
 
+// nameTemplateData 汇总渲染名称模板所需的上下文。
+type nameTemplateData struct {
+	BaseName string         // 基础名称
+	Index    int            // 当前序号（从 1 开始）
+	SeqStart int            // {seq} 的起始值，对应 Index 为 1 的输出
+	Count    int            // 总数量
+	Parent   string         // 源文件所在目录名；合并模式为各源文件的共同上级目录名，没有时为空
+	EPSG     string         // {epsg} 取值：EPSG 代码、自定义投影为 custom、合并模式下不一致为 mixed
+	CRS      string         // {crs} 取值：如 EPSG4526 或自定义投影名称，合并模式下不一致为 mixed
+	Hash     string         // 源文件内容哈希（SHA-256 十六进制）；合并模式为各哈希排序拼接后的哈希
+	Location *time.Location // {date} 的默认时区；nil 表示本地时区
+}
+
 // renderNameTemplate 负责将名称模板中的占位符替换为实际值。
 // 支持占位符:
 //
 //	{name}   				基础名称 (分散: 源文件名规范化; 合并: merged_output 或外部传入)
 //	{index[:width]}        	当前序号。
+//	{seq[:width]}			延续编号 SeqStart+index-1，可跨多次运行连续
 //	{count}                	总数量
-//	{date[:layout]} 		日期 (默认 20060102, 可指定 Go time layout)
+//	{date[:layout[:tz]]} 	日期 (默认 20060102, 可指定 Go time layout 与 IANA 时区名, 如 {date:20060102:UTC})
 //	{uuid}                 	随机 UUID v4
 //	{rand[:len]} 			随机字符串 (默认 8 位)
+//	{parent}				源文件所在目录名 (合并模式为共同上级目录名，可能为空)
+//	{epsg}					坐标系 EPSG 代码 (自定义投影为 custom，合并模式下不一致为 mixed)
+//	{crs}					坐标系简称 (如 EPSG4526，自定义投影为投影名称，不一致为 mixed)
+//	{hash[:len]}			源文件内容哈希 (默认 64 位完整 SHA-256，可截取前 len 位)
 //  :lower|upper|title    可用于所有占位符，表示转换结果的大小写。
 
-func renderNameTemplate(tmpl string, baseName string, index int, count int) string {
+func renderNameTemplate(tmpl string, data nameTemplateData) string {
 	var out strings.Builder
 	for len(tmpl) > 0 {
 		start := strings.IndexByte(tmpl, '{')
@@ -39,12 +62,127 @@ func renderNameTemplate(tmpl string, baseName string, index int, count int) stri
 		}
 		full := tmpl[:end]
 		tmpl = tmpl[end+1:]
-		out.WriteString(resolveToken(full, baseName, index, count))
+		out.WriteString(resolveToken(full, data))
 	}
 	return out.String()
 }
 
-func resolveToken(token string, baseName string, index int, count int) string {
+// ValidateTemplate 检查名称模板中的占位符：未知名称（如拼写错误的 {naem}）、参数无效（如 {index:abc}）
+// 以及未闭合的 "{" 都会报错，错误中列出所有问题，以便在长时间运行前发现模板错误。
+func ValidateTemplate(tmpl string) error {
+	var problems []string
+	for len(tmpl) > 0 {
+		start := strings.IndexByte(tmpl, '{')
+		if start == -1 {
+			break
+		}
+		tmpl = tmpl[start+1:]
+		end := strings.IndexByte(tmpl, '}')
+		if end == -1 {
+			problems = append(problems, fmt.Sprintf("未闭合的占位符 {%s", tmpl))
+			break
+		}
+		if err := validateToken(tmpl[:end]); err != nil {
+			problems = append(problems, err.Error())
+		}
+		tmpl = tmpl[end+1:]
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("名称模板无效: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validateToken 按 resolveToken 的规则检查单个占位符的名称与参数。
+func validateToken(token string) error {
+	parts := strings.Split(token, ":")
+	name := strings.ToLower(parts[0])
+	var args []string
+	for _, a := range parts[1:] {
+		switch strings.ToLower(a) {
+		case "lower", "upper", "title":
+		default:
+			args = append(args, a)
+		}
+	}
+
+	// maxArgs 为各占位符允许的参数个数（不含大小写修饰符）
+	maxArgs := map[string]int{"name": 0, "count": 0, "uuid": 0, "parent": 0, "epsg": 0, "crs": 0, "index": 1, "seq": 1, "rand": 1, "hash": 1, "date": 2}
+	limit, ok := maxArgs[name]
+	if !ok {
+		return fmt.Errorf("未知占位符 {%s}", token)
+	}
+	if len(args) > limit {
+		return fmt.Errorf("占位符 {%s} 的参数过多", token)
+	}
+	if len(args) == 0 || args[0] == "" {
+		return nil
+	}
+
+	arg := args[0]
+	switch name {
+	case "index":
+		if _, err := strconv.Atoi(arg); err != nil {
+			return fmt.Errorf("占位符 {%s} 的宽度 %q 不是整数", token, arg)
+		}
+	case "seq":
+		if strings.Trim(arg, "0123456789") != "" {
+			return fmt.Errorf("占位符 {%s} 的宽度 %q 只能由数字组成", token, arg)
+		}
+	case "rand", "hash":
+		if n, err := strconv.Atoi(arg); err != nil || n <= 0 {
+			return fmt.Errorf("占位符 {%s} 的长度 %q 必须为正整数", token, arg)
+		}
+	case "date":
+		if len(args) > 1 && args[1] != "" {
+			if _, err := time.LoadLocation(args[1]); err != nil {
+				return fmt.Errorf("占位符 {%s} 的时区 %q 无效", token, args[1])
+			}
+		}
+	}
+	return nil
+}
+
+// parentName 返回 {parent} 的取值：所有源文件共同上级目录的名称（单个源文件即其所在目录），
+// 没有共同目录（如位于不同盘符）或共同目录为根目录时返回空。比较时不区分大小写。
+func parentName(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+	common := filepath.Dir(filepath.Clean(paths[0]))
+	for _, p := range paths[1:] {
+		dir := filepath.Dir(filepath.Clean(p))
+		for !isWithinDir(dir, common) {
+			parent := filepath.Dir(common)
+			if parent == common {
+				return ""
+			}
+			common = parent
+		}
+	}
+	if filepath.Dir(common) == common {
+		return ""
+	}
+	return filepath.Base(common)
+}
+
+// isWithinDir 判断 dir 是否为 ancestor 本身或其子目录（不区分大小写）。
+func isWithinDir(dir, ancestor string) bool {
+	rel, err := filepath.Rel(strings.ToLower(ancestor), strings.ToLower(dir))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// combinedHash 返回计划的 {hash} 取值：单个源文件时即其哈希，多个源文件时为各哈希排序拼接后的 SHA-256，与源文件顺序无关。
+func combinedHash(hashes []string) string {
+	if len(hashes) == 1 {
+		return hashes[0]
+	}
+	sorted := slices.Sorted(slices.Values(hashes))
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+func resolveToken(token string, data nameTemplateData) string {
 	parts := strings.Split(token, ":")
 	if len(parts) == 0 {
 		return "{" + token + "}"
@@ -78,7 +216,7 @@ func resolveToken(token string, baseName string, index int, count int) string {
 
 	switch name {
 	case "name":
-		result = baseName
+		result = data.BaseName
 	case "index":
 		width := len(firstArg)
 		offset := 0
@@ -87,15 +225,42 @@ func resolveToken(token string, baseName string, index int, count int) string {
 				offset = v
 			}
 		}
-		result = fmt.Sprintf("%0*d", width, index+offset)
+		result = fmt.Sprintf("%0*d", width, data.Index+offset)
+	case "seq":
+		width := 0
+		if firstArg != "" {
+			width = len(firstArg)
+		}
+		result = fmt.Sprintf("%0*d", width, data.SeqStart+data.Index-1)
 	case "count":
-		result = fmt.Sprintf("%d", count)
+		result = fmt.Sprintf("%d", data.Count)
 	case "date":
 		layout := "20060102"
 		if firstArg != "" {
 			layout = firstArg
 		}
-		result = time.Now().Format(layout)
+		now := time.Now()
+		if data.Location != nil {
+			now = now.In(data.Location)
+		}
+		// 第二个参数为时区，优先于全局时区；无法识别时沿用全局时区
+		if len(args) > 1 && args[1] != "" {
+			if loc, err := time.LoadLocation(args[1]); err == nil {
+				now = now.In(loc)
+			}
+		}
+		result = now.Format(layout)
+	case "parent":
+		result = data.Parent
+	case "epsg":
+		result = data.EPSG
+	case "crs":
+		result = data.CRS
+	case "hash":
+		result = data.Hash
+		if n, err := strconv.Atoi(firstArg); err == nil && n > 0 && n < len(result) {
+			result = result[:n]
+		}
 	case "uuid":
 		result, _ = util.GetUUIDv4()
 	case "rand":
@@ -0,0 +1,188 @@
+package export
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRenderNameTemplate(t *testing.T) {
+	const hash = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	data := nameTemplateData{
+		BaseName: "地块A",
+		Index:    3,
+		SeqStart: 100,
+		Count:    12,
+		Parent:   "2024",
+		EPSG:     "4526",
+		CRS:      "EPSG4526",
+		Hash:     hash,
+	}
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{"{name}", "地块A"},
+		{"{name}_{index}_{count}", "地块A_3_12"},
+		{"{index:000}", "003"},
+		{"{index:10}", "13"}, // 参数兼作偏移量与宽度
+		{"{seq}", "102"},
+		{"{seq:00000}", "00102"},
+		{"{parent}/{name}", "2024/地块A"},
+		{"{epsg}_{crs:lower}", "4526_epsg4526"},
+		{"{hash}", hash},
+		{"{hash:8}", "01234567"},
+		{"{hash:8:upper}", "01234567"},
+		{"{hash:100}", hash},
+		{"{hash:0}", hash},
+		{"{hash:abc}", hash},
+		{"{NAME:upper}", "地块A"},
+		{"{crs:title}", "EPSG4526"},
+		{"{unknown}", "{unknown}"},
+		{"{}", "{}"},
+		{"a{name", "a{name"},
+	}
+	for _, tt := range tests {
+		if got := renderNameTemplate(tt.tmpl, data); got != tt.want {
+			t.Errorf("renderNameTemplate(%q) = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+
+	if got := renderNameTemplate("{rand:12}", data); len(got) != 12 {
+		t.Errorf("{rand:12} 长度 = %d, want 12", len(got))
+	}
+	if got := renderNameTemplate("{date:2006::UTC}", data); len(got) != 4 {
+		t.Errorf("{date:2006::UTC} = %q, want 4 位年份", got)
+	}
+}
+
+func TestSeqStartAcrossRuns(t *testing.T) {
+	// 两次运行各 3 个文件：第二次从 SeqStart=100 延续编号
+	var got []string
+	for _, start := range []int{1, 100} {
+		for i := 1; i <= 3; i++ {
+			got = append(got, renderNameTemplate("{name}_{seq:0000}", nameTemplateData{BaseName: "f", Index: i, SeqStart: start}))
+		}
+	}
+	want := []string{"f_0001", "f_0002", "f_0003", "f_0100", "f_0101", "f_0102"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl    string
+		wantErr string // 为空表示合法
+	}{
+		{"{name}_{index:000}_{seq:0000}", ""},
+		{"{date:20060102:Asia/Shanghai}_{rand:4}_{hash:8:upper}", ""},
+		{"{parent}_{epsg}_{crs:lower}_{uuid}_{count}", ""},
+		{"plain", ""},
+		{"{naem}", "未知占位符 {naem}"},
+		{"{index:abc}", "不是整数"},
+		{"{seq:-1}", "只能由数字组成"},
+		{"{hash:0}", "必须为正整数"},
+		{"{rand:x}", "必须为正整数"},
+		{"{date:2006:Mars/Base}", "时区"},
+		{"{name:x}", "参数过多"},
+		{"{name", "未闭合"},
+	}
+	for _, tt := range tests {
+		err := ValidateTemplate(tt.tmpl)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("ValidateTemplate(%q) = %v, want nil", tt.tmpl, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ValidateTemplate(%q) = %v, want containing %q", tt.tmpl, err, tt.wantErr)
+		}
+	}
+
+	// 所有问题一次列出
+	err := ValidateTemplate("{naem}_{index:abc}")
+	if err == nil || !strings.Contains(err.Error(), "{naem}") || !strings.Contains(err.Error(), "{index:abc}") {
+		t.Errorf("ValidateTemplate 应列出所有问题: %v", err)
+	}
+}
+
+func TestGeneratePlansParent(t *testing.T) {
+	a := filepath.Join("data", "east", "plan.txt")
+	b := filepath.Join("data", "west", "plan.txt")
+	fileCache := map[string]FileCache{"ha": {Path: a, Hash: "ha"}, "hb": {Path: b, Hash: "hb"}}
+
+	e := &Exporter{Config: ExportConfig{NameTemplate: "{parent}_{name}", FormatDetails: supportedFormats["FGB"]}}
+	plans, err := e.generatePlans(fileCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range plans {
+		names = append(names, p.OutputName)
+	}
+	if want := []string{"east_plan.fgb", "west_plan.fgb"}; !slices.Equal(names, want) {
+		t.Errorf("分散模式输出名称 = %v, want %v", names, want)
+	}
+
+	e = &Exporter{Config: ExportConfig{NameTemplate: "{parent}_{name}", Merge: true, FormatDetails: supportedFormats["FGB"]}}
+	plans, err = e.generatePlans(fileCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 1 || plans[0].OutputName != "data_merged_output.fgb" {
+		t.Errorf("合并模式计划 = %+v, want 单个 data_merged_output.fgb", plans)
+	}
+}
+
+func TestParentName(t *testing.T) {
+	tests := []struct {
+		paths []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{filepath.Join("x", "a", "f.txt")}, "a"},
+		{[]string{filepath.Join("x", "a", "f.txt"), filepath.Join("x", "b", "f.txt")}, "x"},
+		{[]string{filepath.Join("x", "A", "f.txt"), filepath.Join("x", "a", "sub", "g.txt")}, "A"},
+		{[]string{string(filepath.Separator) + "f.txt"}, ""},
+	}
+	for _, tt := range tests {
+		if got := parentName(tt.paths); got != tt.want {
+			t.Errorf("parentName(%v) = %q, want %q", tt.paths, got, tt.want)
+		}
+	}
+}
+
+func TestCRSLabels(t *testing.T) {
+	e := &Exporter{ProcessedData: map[string]*ProcessedFile{
+		"std1":   {EPSG: 4526, CRSName: "CGCS2000 / 3-degree Gauss-Kruger zone 38"},
+		"std2":   {EPSG: 4526},
+		"std3":   {EPSG: 4527},
+		"custom": {CRSName: "CGCS2000_3_Degree_GK_CM_114.5E"},
+		"noname": {},
+	}}
+	tests := []struct {
+		name     string
+		hashes   []string
+		wantEPSG string
+		wantCRS  string
+	}{
+		{"标准投影", []string{"std1"}, "4526", "EPSG4526"},
+		{"相同标准投影", []string{"std1", "std2"}, "4526", "EPSG4526"},
+		{"自定义投影", []string{"custom"}, "custom", "CGCS2000_3_Degree_GK_CM_114_5E"},
+		{"无名称的自定义投影", []string{"noname"}, "custom", "custom"},
+		{"不同 EPSG", []string{"std1", "std3"}, "mixed", "mixed"},
+		{"标准与自定义混合", []string{"std1", "custom"}, "mixed", "mixed"},
+		{"未处理的哈希被忽略", []string{"missing", "std3"}, "4527", "EPSG4527"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			epsg, crs := e.crsLabels(tt.hashes)
+			if epsg != tt.wantEPSG || crs != tt.wantCRS {
+				t.Errorf("crsLabels(%v) = (%q, %q), want (%q, %q)", tt.hashes, epsg, crs, tt.wantEPSG, tt.wantCRS)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"txt2geo/internal/process"
+	"txt2geo/internal/util"
+	"txt2geo/pkg/logger"
+	"txt2geo/pkg/pathx"
+)
+
+// sourcePathKey 是原生导出时记录源文件路径的属性名。
+const sourcePathKey = "source_path"
+
+// nativeFeature 是原生写出器使用的单个要素：源文件路径与预处理后的要素数据。
+type nativeFeature struct {
+	SourcePath string
+	Data       map[string]any
+}
+
+// executeNativePlans 在 Go 中直接写出原生格式（不调用 QGIS / Python），并按计划更新处理历史与输出清单。
+// 原生写出不做坐标转换，合并模式下所有源文件的坐标系必须一致（自定义投影与标准 EPSG 同样视为不一致）。
+func (e *Exporter) executeNativePlans(plans []ExportPlan) error {
+	total := len(plans)
+	logger.Log().Info("[导出] 原生写出", "格式", e.Config.FormatKey, "计划数", total, "输出目录", e.Config.OutputDir)
+	width := util.IntDigits(total)
+	var written, failed, featureTotal int
+	perFile := make(map[string]int, len(e.ProcessedData))
+
+	e.startStage(StageWrite, total)
+	for i, plan := range plans {
+		e.advanceStage(StageWrite)
+		var (
+			features []nativeFeature
+			epsg     int
+			first    *crsSource // 计划中首个源文件的坐标系，其余源文件必须与之相同
+			hashes   []string
+		)
+		for _, hash := range plan.SourceHashes {
+			processedFile, ok := e.ProcessedData[hash]
+			if !ok {
+				continue
+			}
+			src := crsSource{crs: processedFile.CRS, path: processedFile.FileCache.Path}
+			if first == nil {
+				first, epsg = &src, processedFile.EPSG
+			} else if src.crs != first.crs {
+				return fmt.Errorf("合并的源文件坐标系不一致: %s；原生格式不支持坐标转换", describeCRSSources([]crsSource{*first, src}))
+			}
+			filtered := e.filterFeatures(processedFile.Features)
+			for _, feat := range filtered {
+				features = append(features, nativeFeature{SourcePath: processedFile.FileCache.Path, Data: feat})
+			}
+			perFile[processedFile.FileCache.Path] = len(filtered)
+			hashes = append(hashes, hash)
+		}
+		if len(features) == 0 {
+			if len(hashes) > 0 {
+				e.logPerFile(slog.LevelInfo, "  [跳过] 过滤后无要素", "输出", plan.displayTarget(false))
+			}
+			continue
+		}
+
+		target := plan.displayTarget(false)
+		progress := fmt.Sprintf("  [%0*d/%d]", width, i+1, total)
+		err := e.writeNative(target, strings.TrimSuffix(plan.OutputName, e.Config.FormatDetails.Extension), epsg, features)
+		if err != nil {
+			logger.Log().Error("[失败] 写出失败", "输出", target, "原因", err)
+			failed++
+		} else {
+			e.logPerFile(slog.LevelInfo, progress, "要素", len(features), "输出", target)
+			written++
+			featureTotal += len(features)
+		}
+		for _, hash := range hashes {
+			e.recordExported(hash, plan.outputTarget(false), err == nil)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d 个输出文件写出失败", failed)
+	}
+	logger.Log().Info("[完成] 导出任务全部完成!", "写入文件", written, "地块", featureTotal)
+	return e.reportPerFile(perFile)
+}
+
+// writeNative 按格式代码分派到具体的原生写出器，先写入同目录临时文件再重命名，避免留下不完整的输出。
+// 目标已存在且未开启 Overwrite 时返回 ErrTargetExists。
+// 临时文件由 os.CreateTemp 以 0600 创建，重命名前改为 0644，与常规创建的输出文件权限一致。
+func (e *Exporter) writeNative(target, layerName string, epsg int, features []nativeFeature) error {
+	if !e.Config.Overwrite {
+		exists, err := pathx.Exists(target)
+		if err != nil {
+			return fmt.Errorf("检查目标文件失败: %w", err)
+		}
+		if exists {
+			return fmt.Errorf("%w: %s", ErrTargetExists, target)
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".txt2geo-*.tmp")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	switch e.Config.FormatDetails.Code {
+	case "GEOJSON":
+		err = writeGeoJSON(tmp, layerName, epsg, features)
+	case "CSV":
+		err = writeCSV(tmp, features)
+	default:
+		err = fmt.Errorf("格式 %s 没有原生写出器", e.Config.FormatDetails.Code)
+	}
+	if err == nil {
+		err = tmp.Chmod(0o644)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// recordExported 记录一个数据集的导出结果：无论成败都写入处理历史（与 Python 导出一致），
+// 仅成功时写入输出清单，供 --resume 核对。
+func (e *Exporter) recordExported(hash string, target process.OutputTarget, ok bool) {
+	if e.History != nil {
+		e.History.CheckAndRecordMeta(hash, e.FileCache[hash].Path, e.Config.FormatKey)
+	}
+	if !ok || e.Manifest == nil {
+		return
+	}
+	if err := e.Manifest.Record(hash, target); err != nil {
+		logger.Log().Warn("[警告] 写入输出清单失败", "哈希", hash, "原因", err)
+	}
+}
+
+// nativeProperties 返回要素属性的副本，并附加源文件路径。
+func nativeProperties(f nativeFeature) map[string]any {
+	props, _ := f.Data["properties"].(map[string]any)
+	props = maps.Clone(props)
+	if props == nil {
+		props = make(map[string]any, 1)
+	}
+	props[sourcePathKey] = f.SourcePath
+	return props
+}
+
+type geoJSONCRS struct {
+	Type       string            `json:"type"`
+	Properties map[string]string `json:"properties"`
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	BBox       []float64       `json:"bbox,omitempty"`
+	Properties map[string]any  `json:"properties"`
+	Geometry   json.RawMessage `json:"geometry"`
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Name     string           `json:"name,omitempty"`
+	CRS      *geoJSONCRS      `json:"crs,omitempty"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+// writeGeoJSON 将要素写出为 GeoJSON FeatureCollection。
+// 坐标为源投影坐标，epsg > 0 时以命名 crs 成员 (urn:ogc:def:crs:EPSG::N) 标注；无 EPSG（自定义中央经线）时省略 crs。
+func writeGeoJSON(f *os.File, layerName string, epsg int, features []nativeFeature) error {
+	fc := geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Name:     layerName,
+		Features: make([]geoJSONFeature, 0, len(features)),
+	}
+	if epsg > 0 {
+		fc.CRS = &geoJSONCRS{Type: "name", Properties: map[string]string{"name": fmt.Sprintf("urn:ogc:def:crs:EPSG::%d", epsg)}}
+	}
+	for _, feat := range features {
+		geom, _ := feat.Data["geojson"].(json.RawMessage)
+		if len(geom) == 0 {
+			geom = json.RawMessage("null")
+		}
+		bbox, _ := feat.Data["bbox"].([]float64)
+		fc.Features = append(fc.Features, geoJSONFeature{
+			Type:       "Feature",
+			BBox:       bbox,
+			Properties: nativeProperties(feat),
+			Geometry:   geom,
+		})
+	}
+	return json.NewEncoder(f).Encode(fc)
+}
+
+// csvGeometryColumn 是 CSV 输出中 WKT 几何列的列名。
+const csvGeometryColumn = "wkt"
+
+// utf8BOM 写在 CSV 开头，便于 Excel 正确识别中文。
+const utf8BOM = "\ufeff"
+
+// writeCSV 将要素写出为 CSV：表头为所有要素属性键的并集（按名称排序）加 wkt 列，每个要素一行。
+// 引号与分隔符转义由 encoding/csv 处理；缺失的属性输出为空。
+func writeCSV(f *os.File, features []nativeFeature) error {
+	rows := make([]map[string]any, 0, len(features))
+	keySet := make(map[string]struct{})
+	for _, feat := range features {
+		props := nativeProperties(feat)
+		for k := range props {
+			keySet[k] = struct{}{}
+		}
+		rows = append(rows, props)
+	}
+	delete(keySet, csvGeometryColumn)
+	header := slices.Sorted(maps.Keys(keySet))
+	header = append(header, csvGeometryColumn)
+
+	if _, err := f.WriteString(utf8BOM); err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	record := make([]string, len(header))
+	for i, props := range rows {
+		for j, key := range header[:len(header)-1] {
+			record[j] = csvValue(props[key])
+		}
+		record[len(header)-1], _ = features[i].Data["wkt"].(string)
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// csvValue 将属性值格式化为 CSV 单元格文本；浮点数不使用科学计数法，nil 为空串。
+func csvValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,238 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// customMeridianContent 是中央经线为 114.5 的自定义投影示例源文件，没有 EPSG 码。
+var customMeridianContent = strings.Replace(sampleContent, "坐标系=2000国家大地坐标系", "坐标系=2000国家大地坐标系(114.5)", 1)
+
+// newNativeExporter 返回以 code 格式原生写出的 Exporter。
+func newNativeExporter(code string) *Exporter {
+	return &Exporter{Config: ExportConfig{FormatDetails: exportFormat{Code: code}}}
+}
+
+var testNativeFeatures = []nativeFeature{{
+	SourcePath: "a.txt",
+	Data: map[string]any{
+		"wkt":        "POINT (1 2)",
+		"geojson":    json.RawMessage(`{"type":"Point","coordinates":[1,2]}`),
+		"properties": map[string]any{"pid": "1"},
+	},
+}}
+
+func TestWriteNativeFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 不支持 Unix 文件权限位")
+	}
+	for _, code := range []string{"GEOJSON", "CSV"} {
+		t.Run(code, func(t *testing.T) {
+			target := filepath.Join(t.TempDir(), "out")
+			if err := newNativeExporter(code).writeNative(target, "out", 4526, testNativeFeatures); err != nil {
+				t.Fatalf("writeNative: %v", err)
+			}
+			info, err := os.Stat(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := info.Mode().Perm(); perm != 0o644 {
+				t.Errorf("输出文件权限 = %o, want 644", perm)
+			}
+		})
+	}
+}
+
+func TestWriteNativeExistingTarget(t *testing.T) {
+	tests := []struct {
+		name      string
+		overwrite bool
+		wantErr   error
+	}{
+		{"未开启 overwrite", false, ErrTargetExists},
+		{"开启 overwrite", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := filepath.Join(t.TempDir(), "out.csv")
+			if err := os.WriteFile(target, []byte("old"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			e := newNativeExporter("CSV")
+			e.Config.Overwrite = tt.overwrite
+			err := e.writeNative(target, "out", 4526, testNativeFeatures)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("writeNative err = %v, want %v", err, tt.wantErr)
+			}
+			data, err := os.ReadFile(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kept := string(data) == "old"; kept != (tt.wantErr != nil) {
+				t.Errorf("目标内容 = %q，overwrite=%v 时不应如此", data, tt.overwrite)
+			}
+		})
+	}
+}
+
+func TestExecuteNativePlansMixedCRS(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents map[string]string
+		wantErr  string
+	}{
+		{"标准 EPSG 在前", map[string]string{"a.txt": sampleContent, "b.txt": customMeridianContent}, "EPSG:4526 (a.txt), 自定义投影 (b.txt)"},
+		{"自定义投影在前", map[string]string{"a.txt": customMeridianContent, "b.txt": sampleContent}, "自定义投影 (a.txt), EPSG:4526 (b.txt)"},
+		{"不同 EPSG", map[string]string{"a.txt": sampleContent, "b.txt": zone39Content}, "EPSG:4526 (a.txt), EPSG:4527 (b.txt)"},
+		{"坐标系相同", map[string]string{"a.txt": sampleContent, "b.txt": sampleContent}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(t)
+			cfg.FormatKey, cfg.Merge = "GEOJSON", true
+			e := newProcessedExporter(t, cfg, tt.contents)
+			plans, err := e.generatePlans(e.FileCache)
+			if err != nil {
+				t.Fatalf("generatePlans: %v", err)
+			}
+			err = e.executeNativePlans(plans)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("executeNativePlans: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+			}
+			entries, err := os.ReadDir(cfg.OutputDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("坐标系不一致时不应写出文件，输出目录含 %d 项", len(entries))
+			}
+		})
+	}
+}
+
+func TestWriteNativeGeoJSONContent(t *testing.T) {
+	features := []nativeFeature{
+		testNativeFeatures[0],
+		{
+			SourcePath: "b.txt",
+			Data: map[string]any{
+				"geojson":    json.RawMessage(`{"type":"LineString","coordinates":[[3,4],[5,6]]}`),
+				"bbox":       []float64{3, 4, 5, 6},
+				"properties": map[string]any{"name": "地块2", "area": 12.5},
+			},
+		},
+	}
+	wantFeatures := []map[string]any{
+		{
+			"geometry":   map[string]any{"type": "Point", "coordinates": []any{1.0, 2.0}},
+			"properties": map[string]any{"pid": "1", sourcePathKey: "a.txt"},
+		},
+		{
+			"geometry":   map[string]any{"type": "LineString", "coordinates": []any{[]any{3.0, 4.0}, []any{5.0, 6.0}}},
+			"properties": map[string]any{"name": "地块2", "area": 12.5, sourcePathKey: "b.txt"},
+			"bbox":       []any{3.0, 4.0, 5.0, 6.0},
+		},
+	}
+	tests := []struct {
+		name    string
+		epsg    int
+		wantCRS any
+	}{
+		{"标准 EPSG 带 crs", 4526, map[string]any{"type": "name", "properties": map[string]any{"name": "urn:ogc:def:crs:EPSG::4526"}}},
+		{"自定义投影省略 crs", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := filepath.Join(t.TempDir(), "out.geojson")
+			if err := newNativeExporter("GEOJSON").writeNative(target, "out", tt.epsg, features); err != nil {
+				t.Fatalf("writeNative: %v", err)
+			}
+			data, err := os.ReadFile(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fc map[string]any
+			if err := json.Unmarshal(data, &fc); err != nil {
+				t.Fatalf("输出不是合法 JSON: %v", err)
+			}
+			if fc["type"] != "FeatureCollection" || fc["name"] != "out" {
+				t.Errorf("type = %v, name = %v, want FeatureCollection, out", fc["type"], fc["name"])
+			}
+			if crs, ok := fc["crs"]; ok != (tt.wantCRS != nil) || !reflect.DeepEqual(crs, tt.wantCRS) {
+				t.Errorf("crs = %v（存在=%v），want %v", crs, ok, tt.wantCRS)
+			}
+			got, _ := fc["features"].([]any)
+			if len(got) != len(wantFeatures) {
+				t.Fatalf("要素数 = %d, want %d", len(got), len(wantFeatures))
+			}
+			for i, want := range wantFeatures {
+				feat, _ := got[i].(map[string]any)
+				if feat["type"] != "Feature" {
+					t.Errorf("要素 %d type = %v, want Feature", i, feat["type"])
+				}
+				for _, key := range []string{"geometry", "properties", "bbox"} {
+					if !reflect.DeepEqual(feat[key], want[key]) {
+						t.Errorf("要素 %d %s = %v, want %v", i, key, feat[key], want[key])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestWriteNativeCSVContent(t *testing.T) {
+	features := []nativeFeature{
+		{
+			SourcePath: "a.txt",
+			Data: map[string]any{
+				"wkt":        "POINT (1 2)",
+				"properties": map[string]any{"pid": "1", "note": "a,b"},
+			},
+		},
+		{
+			SourcePath: "b.txt",
+			Data: map[string]any{
+				"wkt":        "LINESTRING (3 4, 5 6)",
+				"properties": map[string]any{"name": `他说"好"`, "area": 12.5},
+			},
+		},
+	}
+	want := [][]string{
+		{"area", "name", "note", "pid", sourcePathKey, "wkt"},
+		{"", "", "a,b", "1", "a.txt", "POINT (1 2)"},
+		{"12.5", `他说"好"`, "", "", "b.txt", "LINESTRING (3 4, 5 6)"},
+	}
+
+	target := filepath.Join(t.TempDir(), "out.csv")
+	if err := newNativeExporter("CSV").writeNative(target, "out", 4526, features); err != nil {
+		t.Fatalf("writeNative: %v", err)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, ok := strings.CutPrefix(string(data), utf8BOM)
+	if !ok {
+		t.Fatalf("CSV 缺少 UTF-8 BOM: %q", data)
+	}
+	got, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	if err != nil {
+		t.Fatalf("读取 CSV 失败: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CSV 内容 =\n%q\nwant\n%q", got, want)
+	}
+}
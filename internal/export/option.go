@@ -6,19 +6,28 @@ package export
 import (
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"time"
+	"txt2geo/internal/domain"
+	"txt2geo/pkg/charset"
 	"txt2geo/pkg/logger"
 	"txt2geo/pkg/pathx"
+	"unicode/utf8"
 )
 
 // exportFormat 描述一种输出格式的特征
 type exportFormat struct {
-	Code        string // 简短格式代码 (SHP / FGB / GPKG / GDB)
-	Driver      string // 完整驱动名称 (ESRI Shapefile / FlatGeobuf / GPKG / OpenFileGDB)
-	Extension   string // 主文件扩展名 (.shp / .fgb / .gpkg / .gdb)
-	IsContainer bool   // 是否容器格式（目录/单文件多图层）
+	Code           string   // 简短格式代码 (SHP / FGB / GPKG / GDB / SPATIALITE)
+	Driver         string   // 完整驱动名称 (ESRI Shapefile / FlatGeobuf / GPKG / OpenFileGDB / SQLite)
+	Extension      string   // 主文件扩展名 (.shp / .fgb / .gpkg / .gdb / .sqlite)
+	IsContainer    bool     // 是否容器格式（目录/单文件多图层）
+	Native         bool     // 是否由 Go 直接写出（不调用 QGIS / Python）
+	DatasetOptions []string // GDAL 数据集创建选项
 }
 
 var supportedFormats = map[string]exportFormat{
@@ -26,25 +35,136 @@ var supportedFormats = map[string]exportFormat{
 	"FGB":  {Code: "FGB", Driver: "FlatGeobuf", Extension: ".fgb", IsContainer: false},
 	"GPKG": {Code: "GPKG", Driver: "GPKG", Extension: ".gpkg", IsContainer: true},
 	"GDB":  {Code: "GDB", Driver: "OpenFileGDB", Extension: ".gdb", IsContainer: true},
+	// SQLite 驱动需显式开启 SPATIALITE=YES，否则只会生成普通 SQLite 数据库
+	"SPATIALITE": {Code: "SPATIALITE", Driver: "SQLite", Extension: ".sqlite", IsContainer: true, DatasetOptions: []string{"SPATIALITE=YES"}},
+	"TAB":        {Code: "TAB", Driver: "MapInfo File", Extension: ".tab", IsContainer: false},
+	// KML 要求 WGS84 经纬度坐标，写出时由 QGIS 转换
+	"KML": {Code: "KML", Driver: "KML", Extension: ".kml", IsContainer: false},
+	// GeoJSON 由 Go 直接写出，不依赖 QGIS
+	"GEOJSON": {Code: "GEOJSON", Driver: "GeoJSON", Extension: ".geojson", Native: true},
+	// CSV 由 Go 直接写出：属性列 + wkt 几何列
+	"CSV": {Code: "CSV", Driver: "CSV", Extension: ".csv", Native: true},
 }
 
 // ExportConfig 汇集了从命令行接收到的所有导出参数。
 type ExportConfig struct {
-	InputPaths   []string
+	InputPaths []string
+	// InputList 输入清单文件：每个非空、非 # 开头的行是一个路径或通配符，相对路径以清单所在目录为基准；与 InputPaths 合并
+	InputList    string
 	Depth        int
 	FormatKey    string
 	OutputDir    string //文件夹或数据库
 	Merge        bool
 	NameTemplate string
+	// SeqStart 名称模板 {seq} 的起始值，第 N 个输出为 SeqStart+N-1，便于多次运行间延续编号
+	SeqStart     int
 	DryRun       bool
 	Overwrite    bool
 	ForceRefresh bool
+	// Append 将要素追加到已存在的目标图层（图层不存在时新建），与 Overwrite 互斥
+	Append bool
+	// Reproject 合并模式下源文件坐标系不一致时，统一重投影到第一个标准 EPSG 坐标系，而不是报错
+	Reproject bool
+	// OutputEncoding 属性文本编码（UTF-8|GBK|GB18030），空表示 UTF-8；SHP 写入对应的 DBF 代码页
+	OutputEncoding string
+	// RecoverTruncated 丢弃文件末尾被截断的地块而不是让整个文件失败
+	RecoverTruncated bool
+	// SkipInvalid 跳过无法构成合法几何的地块（逐个输出警告），保留其余地块而不是让整个文件失败
+	SkipInvalid bool
+	// SummaryOnly 将逐文件日志降级为 Debug，仅在 Info 级别保留汇总
+	SummaryOnly bool
+	// ResultsNDJSONPath 非空时，将逐文件处理结果以 NDJSON 写入该文件
+	ResultsNDJSONPath string
+	// Concurrency 预处理阶段（解码、解析、几何处理）的并发数，0 表示使用 GOMAXPROCS
+	Concurrency int
+	// ExportConcurrency Python 导出阶段的并发进程数（默认 1）；仅对分散模式的非容器格式生效
+	ExportConcurrency int
+	// ExecTimeout 单个 Python 导出进程的执行超时，0 表示不限制
+	ExecTimeout time.Duration
+	// QGISPath 显式指定的 QGIS 安装目录，空表示依次尝试环境变量 TXT2GEO_QGIS、QGIS_PREFIX_PATH 与自动查找
+	QGISPath string
+	// TimeZone 名称模板中 {date} 使用的时区（IANA 名称，如 UTC、Asia/Shanghai），空表示本地时区
+	TimeZone string
+	// IncludeGenerated 为 true 时不跳过首行带有 GeneratedMarker 的工具生成文件
+	IncludeGenerated bool
+	// MeasureColumn 坐标行中测量值 (M) 的列号（从 1 开始，≥5），0 表示不读取
+	MeasureColumn int
+	// Encoding 强制使用的源文件编码（utf-8、utf-8-sig、utf-16-le、utf-16-be、gb18030），空表示自动探测
+	Encoding string
+	// RejectsNDJSONPath 非空时，将容错处理中被剔除的要素（含原因与原始点集 WKT）以 NDJSON 写入该文件
+	RejectsNDJSONPath string
+	// DumpPayload 非空时，将发送给 Python 的 JSON 负载以缩进格式写入该文件（预览模式同样生效）
+	DumpPayload string
+	// ReportPath 非空时，将逐文件要素统计以 JSON 写入该文件
+	ReportPath string
+	// SlowestFiles 任务结束时输出耗时最长的前 N 个文件及其阶段耗时，0 表示不输出
+	SlowestFiles int
+	// Resume 恢复中断的任务：先核对处理历史与磁盘上的实际输出，移除输出缺失的历史记录后再继续
+	Resume bool
+	// HistoryFileName 处理历史文件名，为空时使用 ProcessedFileName
+	HistoryFileName string
+	// NoHistory 为 true 时不记录处理历史，每次运行都重新处理
+	NoHistory bool
+	// HistoryTTL 处理历史的有效期：早于该时长的记录在启动时被清理，对应文件重新处理；0 表示永不过期
+	HistoryTTL time.Duration
+	// HistoryJSON 为 true 时以 JSON 行记录处理历史（含源文件路径、输出格式与时间），便于审计
+	HistoryJSON bool
+	// Excludes 收集输入时排除的 glob 模式，匹配基础名称（或含分隔符时匹配相对路径），匹配的目录整体跳过
+	Excludes []string
+	// MinSize/MaxSize 按文件大小（字节）过滤输入，0 表示不限制
+	MinSize int64
+	MaxSize int64
+	// ModifiedAfter/ModifiedBefore 按修改时间过滤输入：日期（2006-01-02）、日期时间（2006-01-02 15:04:05）、
+	// RFC3339，或相对天数（如 7d 表示最近 7 天）；空表示不限制
+	ModifiedAfter  string
+	ModifiedBefore string
+	// AttrSection/GeomSection 源文件中属性部分与坐标部分的标记行，空表示默认的 [属性描述] / [地块坐标]
+	AttrSection string
+	GeomSection string
+	// Delimiter 坐标行字段分隔符（单个字符，"tab" 或 "\t" 表示制表符），空表示逗号
+	Delimiter string
+	// CheckPointCount 为 true 时校验地块声明的界址点数与实际点数是否一致，不一致时输出警告
+	CheckPointCount bool
+	// CheckGeometry 为 true 时检查几何后处理后的地块环的几何问题（自相交、点数不足等），发现时输出警告
+	CheckGeometry bool
+	// SkipExtentCheck 为 true 时不检查坐标范围（北向坐标是否落在中国纬度范围、坐标列是否互换），用于地方/工程坐标网；
+	// 配置了 Projection 覆盖参数时同样跳过
+	SkipExtentCheck bool
+	// ComputeMetrics 为 true 时为每个要素输出由几何计算的面积与周长字段
+	ComputeMetrics bool
+	// SimplifyTolerance 为 Douglas-Peucker 简化容差（米），0 表示不简化
+	SimplifyTolerance float64
+	// FixWinding 为 true 时统一面的环方向（外环逆时针、洞顺时针），默认保持源文件中的点序
+	FixWinding bool
+	// EmitBBox 为 true 时为每个要素计算外包矩形（含洞的顶点）并随要素传给导出脚本，默认不计算
+	EmitBBox bool
+	// Projection 高斯-克吕格投影参数覆盖（原点纬度、比例因子、北偏移），零值为标准参数；任一参数被覆盖时不再输出 EPSG 码
+	Projection domain.ProjectionOptions
+	// Fields 字段映射规则（来自 --field），形如 "源键=目标键"，"源键=" 表示删除，"源键" 表示原名保留；在 Verify 中合并到 FieldMap
+	Fields []string
+	// FieldMap 要素属性映射：源键 -> 目标键，目标为空表示删除；为空时不做映射
+	FieldMap map[string]string
+	// KeepUnmapped 配置了 FieldMap 时，未列出的属性是否原样保留（默认删除）
+	KeepUnmapped bool
+	// Filter 要素属性过滤表达式，如 "area>1000" 或 "usage==耕地"；空表示不过滤
+	Filter string
+	// CreationArgs GDAL 图层创建选项（来自 --co），形如 "KEY=VALUE"；在 Verify 中合并到 CreationOptions
+	CreationArgs []string
+	// CreationOptions GDAL 图层创建选项：键 -> 值，原样传给 QgsVectorFileWriter，同名时覆盖内置的默认选项
+	CreationOptions map[string]string
 
 	//派生
-	FormatDetails exportFormat
+	FormatDetails      exportFormat
+	Location           *time.Location
+	ModifiedAfterTime  time.Time
+	ModifiedBeforeTime time.Time
+	filter             *featureFilter // 由 Filter 解析
 }
 
-const ProcessedFileName = ".processed"
+const (
+	ProcessedFileName = ".processed"
+	ManifestFileName  = ".manifest" // 哈希 -> 输出位置清单，与处理历史位于同一目录
+)
 
 // GetFormatDetails 根据格式键（如 "SHP"）返回格式的详细信息。
 // 如果找不到对应的格式，将返回一个零值的 exportFormat 和 false。
@@ -58,6 +178,16 @@ func GetFormatDetails(key string) (exportFormat, error) {
 		key = "GPKG"
 	case strings.EqualFold(key, "GDB") || strings.EqualFold(key, "OpenFileGDB") || strings.EqualFold(key, ".gdb"):
 		key = "GDB"
+	case strings.EqualFold(key, "SPATIALITE") || strings.EqualFold(key, "SQLite") || strings.EqualFold(key, ".sqlite"):
+		key = "SPATIALITE"
+	case strings.EqualFold(key, "TAB") || strings.EqualFold(key, "MapInfo") || strings.EqualFold(key, "MapInfo File") || strings.EqualFold(key, ".tab"):
+		key = "TAB"
+	case strings.EqualFold(key, "KML") || strings.EqualFold(key, "Google Earth") || strings.EqualFold(key, ".kml"):
+		key = "KML"
+	case strings.EqualFold(key, "GEOJSON") || strings.EqualFold(key, ".geojson") || strings.EqualFold(key, "JSON"):
+		key = "GEOJSON"
+	case strings.EqualFold(key, "CSV") || strings.EqualFold(key, ".csv"):
+		key = "CSV"
 	}
 	format, ok := supportedFormats[key]
 
@@ -67,11 +197,39 @@ func GetFormatDetails(key string) (exportFormat, error) {
 	return format, nil
 }
 
+// outputEncodings 支持的属性文本编码，键为规范化前的大写写法。
+var outputEncodings = map[string]string{
+	"UTF-8":   "UTF-8",
+	"UTF8":    "UTF-8",
+	"GBK":     "GBK",
+	"CP936":   "GBK",
+	"GB18030": "GB18030",
+}
+
+// normalizeOutputEncoding 规范化输出编码名称（不区分大小写），空表示 UTF-8。
+func normalizeOutputEncoding(name string) (string, error) {
+	key := strings.ToUpper(strings.TrimSpace(name))
+	if key == "" {
+		return "UTF-8", nil
+	}
+	if enc, ok := outputEncodings[key]; ok {
+		return enc, nil
+	}
+	return "", fmt.Errorf("不支持的输出编码: %s (可选 UTF-8、GBK、GB18030)", name)
+}
+
 // Verify validates and normalizes the export configuration.
 func (c *ExportConfig) Verify() error {
 	// 1. 验证输入文件
+	if list := strings.TrimSpace(c.InputList); list != "" {
+		inputs, err := readInputList(list)
+		if err != nil {
+			return err
+		}
+		c.InputPaths = append(c.InputPaths, inputs...)
+	}
 	if len(c.InputPaths) == 0 {
-		return errors.New("至少提供一个 --input / -i")
+		return errors.New("至少提供一个 --input / -i 或 --input-list")
 	}
 	for i, input := range c.InputPaths {
 		trimmed := strings.TrimSpace(input)
@@ -85,12 +243,93 @@ func (c *ExportConfig) Verify() error {
 		return errors.New("depth 不能小于 -1")
 	}
 
+	if enc := strings.ToLower(strings.TrimSpace(c.Encoding)); enc != "" {
+		if !charset.IsSupported(enc) {
+			return fmt.Errorf("不支持的编码: %s", c.Encoding)
+		}
+		c.Encoding = enc
+	}
+	if c.MeasureColumn != 0 && c.MeasureColumn < 5 {
+		return errors.New("measure-column 必须为 0 或 ≥5")
+	}
+	c.AttrSection, c.GeomSection = strings.TrimSpace(c.AttrSection), strings.TrimSpace(c.GeomSection)
+	switch d := c.Delimiter; strings.ToLower(d) {
+	case "", ",":
+		c.Delimiter = ""
+	case "tab", `\t`, "\t":
+		c.Delimiter = "\t"
+	default:
+		if utf8.RuneCountInString(d) != 1 {
+			return fmt.Errorf("字段分隔符必须为单个字符: %q", d)
+		}
+	}
+	if err := c.ParseOptions().Validate(); err != nil {
+		return err
+	}
+	if c.Concurrency < 0 {
+		return errors.New("concurrency 不能小于 0")
+	}
+	if c.Concurrency == 0 {
+		c.Concurrency = runtime.GOMAXPROCS(0)
+	}
+	if c.ExportConcurrency < 0 {
+		return errors.New("export-concurrency 不能小于 0")
+	}
+	if c.ExportConcurrency == 0 {
+		c.ExportConcurrency = 1
+	}
+	if c.ExecTimeout < 0 {
+		return errors.New("timeout 不能小于 0")
+	}
+	if err := pathx.ValidatePatterns(c.Excludes); err != nil {
+		return err
+	}
+	if c.Resume && c.ForceRefresh {
+		return errors.New("resume 与 force-refresh 不能同时使用")
+	}
+	c.HistoryFileName = strings.TrimSpace(c.HistoryFileName)
+	if c.HistoryFileName != "" && c.HistoryFileName != filepath.Base(c.HistoryFileName) {
+		return fmt.Errorf("history-file 只能是文件名，不能包含目录: %s", c.HistoryFileName)
+	}
+	if c.Resume && c.NoHistory {
+		return errors.New("resume 需要处理历史，不能与 no-history 同时使用")
+	}
+	switch {
+	case c.NoHistory:
+		c.HistoryFileName = ""
+	case c.HistoryFileName == "":
+		c.HistoryFileName = ProcessedFileName
+	}
+	if c.HistoryTTL < 0 {
+		return errors.New("history-ttl 不能小于 0")
+	}
+	if c.Append && c.Overwrite {
+		return errors.New("append 与 overwrite 不能同时使用")
+	}
+	if c.SlowestFiles < 0 {
+		return errors.New("slowest 不能小于 0")
+	}
+
 	// 3. 验证并规范化导出格式
 	formatDetails, err := GetFormatDetails(c.FormatKey)
 	if err != nil {
 		return fmt.Errorf("未能获取到 %s 格式的详细信息: %w", c.FormatKey, err)
 	}
 	c.FormatDetails = formatDetails
+	if c.Append && formatDetails.Native {
+		return fmt.Errorf("%s 格式不支持 append", formatDetails.Code)
+	}
+	if c.Reproject && formatDetails.Native {
+		return fmt.Errorf("%s 格式不支持坐标转换，不能使用 reproject", formatDetails.Code)
+	}
+	encoding, err := normalizeOutputEncoding(c.OutputEncoding)
+	if err != nil {
+		return err
+	}
+	if encoding != "UTF-8" && formatDetails.Native {
+		return fmt.Errorf("%s 格式始终以 UTF-8 写出，不支持 output-encoding=%s", formatDetails.Code, encoding)
+	}
+	c.OutputEncoding = encoding
 
 	// 4. 验证并规范化输出目录
 	outputdir := strings.TrimSpace(c.OutputDir)
@@ -147,7 +386,80 @@ func (c *ExportConfig) Verify() error {
 		}
 		nameTemplate = stem
 	}
+	if err := ValidateTemplate(nameTemplate); err != nil {
+		return err
+	}
 	c.NameTemplate = nameTemplate
+	if c.SeqStart < 0 {
+		return fmt.Errorf("seq-start 不能为负数: %d", c.SeqStart)
+	}
+
+	// 验证时区
+	if tz := strings.TrimSpace(c.TimeZone); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return fmt.Errorf("无效的时区 '%s': %w", tz, err)
+		}
+		c.TimeZone = tz
+		c.Location = loc
+	}
+
+	// 验证文件过滤条件（时间按 --tz 或本地时区解析）
+	if c.MinSize < 0 || c.MaxSize < 0 {
+		return errors.New("min-size/max-size 不能小于 0")
+	}
+	if c.MaxSize > 0 && c.MinSize > c.MaxSize {
+		return errors.New("min-size 不能大于 max-size")
+	}
+	loc := c.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	for _, f := range []struct {
+		raw *string
+		out *time.Time
+	}{{&c.ModifiedAfter, &c.ModifiedAfterTime}, {&c.ModifiedBefore, &c.ModifiedBeforeTime}} {
+		if v := strings.TrimSpace(*f.raw); v != "" {
+			t, err := parseTimeFilter(v, loc)
+			if err != nil {
+				return err
+			}
+			*f.raw, *f.out = v, t
+		}
+	}
+	if !c.ModifiedAfterTime.IsZero() && !c.ModifiedBeforeTime.IsZero() && !c.ModifiedAfterTime.Before(c.ModifiedBeforeTime) {
+		return errors.New("modified-after 必须早于 modified-before")
+	}
+
+	if err := c.parseFields(); err != nil {
+		return err
+	}
+	if err := c.parseCreationOptions(); err != nil {
+		return err
+	}
+	if expr := strings.TrimSpace(c.Filter); expr != "" {
+		f, err := parseFilter(expr)
+		if err != nil {
+			return err
+		}
+		c.Filter, c.filter = expr, f
+	}
+	if c.SimplifyTolerance < 0 || math.IsNaN(c.SimplifyTolerance) || math.IsInf(c.SimplifyTolerance, 0) {
+		return errors.New("simplify 容差必须为不小于 0 的有限数")
+	}
+	if err := c.Projection.Validate(); err != nil {
+		return fmt.Errorf("投影参数无效: %w", err)
+	}
+
+	// 7. 规范化结果文件路径
+	for _, p := range []*string{&c.ResultsNDJSONPath, &c.RejectsNDJSONPath, &c.DumpPayload, &c.ReportPath} {
+		if v := strings.TrimSpace(*p); v != "" {
+			if abs, err := filepath.Abs(v); err == nil {
+				v = abs
+			}
+			*p = v
+		}
+	}
 	return nil
 }
 
@@ -178,7 +490,161 @@ func (c *ExportConfig) ProcessFileDir() string {
 	return processFile
 }
 
-// ProcessFilePath 返回处理历史记录文件的完整路径
+// ParseOptions 返回解析源文件时使用的选项。
+func (c *ExportConfig) ParseOptions() domain.ParseOptions {
+	return domain.ParseOptions{
+		RecoverTruncated: c.RecoverTruncated,
+		MeasureColumn:    c.MeasureColumn,
+		AttrSection:      c.AttrSection,
+		GeomSection:      c.GeomSection,
+		Delimiter:        delimiterRune(c.Delimiter),
+	}
+}
+
+// delimiterRune 返回分隔符字符串的首个字符，空串返回 0（即默认逗号）。
+func delimiterRune(s string) rune {
+	if s == "" {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+// readInputList 读取输入清单文件，跳过空行与 # 开头的注释行，相对路径以清单所在目录为基准解析。
+func readInputList(listPath string) ([]string, error) {
+	data, err := os.ReadFile(listPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取输入清单失败: %w", err)
+	}
+	base := filepath.Dir(listPath)
+	if abs, err := filepath.Abs(base); err == nil {
+		base = abs
+	}
+	var inputs []string
+	lines := strings.Split(strings.TrimPrefix(string(data), "\uFEFF"), "\n")
+	for _, raw := range lines {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(base, line)
+		}
+		inputs = append(inputs, line)
+	}
+	logger.Log().Debug("  [输入] 读取输入清单", "清单", listPath, "条目", len(inputs))
+	return inputs, nil
+}
+
+// ProcessFilePath 返回处理历史记录文件的完整路径；NoHistory 时返回空串（不记录历史）
 func (c *ExportConfig) ProcessFilePath() string {
-	return filepath.Join(c.ProcessFileDir(), ProcessedFileName)
+	if c.HistoryFileName == "" {
+		return ""
+	}
+	return filepath.Join(c.ProcessFileDir(), c.HistoryFileName)
+}
+
+// ManifestFilePath 返回输出清单文件的完整路径：自定义历史文件名时清单名随之加上该前缀，
+// 使不同流水线的清单互不干扰；不记录处理历史时同样不写清单。
+func (c *ExportConfig) ManifestFilePath() string {
+	switch c.HistoryFileName {
+	case "":
+		return ""
+	case ProcessedFileName:
+		return filepath.Join(c.ProcessFileDir(), ManifestFileName)
+	default:
+		return filepath.Join(c.ProcessFileDir(), c.HistoryFileName+ManifestFileName)
+	}
+}
+
+// timeFilterLayouts 是 --modified-after/--modified-before 支持的绝对时间格式。
+var timeFilterLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// parseTimeFilter 解析修改时间过滤条件：相对天数（如 7d）或 timeFilterLayouts 中的绝对时间。
+func parseTimeFilter(s string, loc *time.Location) (time.Time, error) {
+	if days, ok := strings.CutSuffix(strings.ToLower(s), "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	for _, layout := range timeFilterLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无效的时间 '%s'，支持 2006-01-02、2006-01-02 15:04:05、RFC3339 或相对天数（如 7d）", s)
+}
+
+// parseFields 将 Fields 中的映射规则合并到 FieldMap，并校验目标键不重复。
+func (c *ExportConfig) parseFields() error {
+	if len(c.Fields) > 0 && c.FieldMap == nil {
+		c.FieldMap = make(map[string]string, len(c.Fields))
+	}
+	for _, spec := range c.Fields {
+		src, dst, hasDst := strings.Cut(spec, "=")
+		src, dst = strings.TrimSpace(src), strings.TrimSpace(dst)
+		if src == "" {
+			return fmt.Errorf("无效的字段映射 '%s'，格式为 源键=目标键", spec)
+		}
+		if !hasDst {
+			dst = src
+		}
+		c.FieldMap[src] = dst
+	}
+	targets := make(map[string]string, len(c.FieldMap))
+	for src, dst := range c.FieldMap {
+		if dst == "" {
+			continue
+		}
+		if other, ok := targets[dst]; ok {
+			return fmt.Errorf("字段 '%s' 与 '%s' 映射到同一目标 '%s'", other, src, dst)
+		}
+		targets[dst] = src
+	}
+	return nil
+}
+
+// parseCreationOptions 将 CreationArgs 合并到 CreationOptions，并校验键与值均非空。
+// 键按 GDAL 惯例转为大写；原生格式不经过 GDAL，配置了创建选项时报错。
+func (c *ExportConfig) parseCreationOptions() error {
+	if len(c.CreationArgs) > 0 && c.CreationOptions == nil {
+		c.CreationOptions = make(map[string]string, len(c.CreationArgs))
+	}
+	for _, spec := range c.CreationArgs {
+		key, value, _ := strings.Cut(spec, "=")
+		key, value = strings.ToUpper(strings.TrimSpace(key)), strings.TrimSpace(value)
+		if key == "" || value == "" {
+			return fmt.Errorf("无效的创建选项 '%s'，格式为 KEY=VALUE", spec)
+		}
+		c.CreationOptions[key] = value
+	}
+	for key, value := range c.CreationOptions {
+		if strings.TrimSpace(key) == "" || strings.TrimSpace(value) == "" {
+			return fmt.Errorf("无效的创建选项 '%s=%s'，键与值均不能为空", key, value)
+		}
+	}
+	if len(c.CreationOptions) > 0 && c.FormatDetails.Native {
+		return fmt.Errorf("%s 格式由程序直接写出，不支持 GDAL 创建选项", c.FormatDetails.Code)
+	}
+	return nil
+}
+
+// mapFields 按 FieldMap 重命名或删除属性，返回新的属性表；未配置 FieldMap 时原样返回。
+func (c *ExportConfig) mapFields(props map[string]any) map[string]any {
+	if len(c.FieldMap) == 0 || props == nil {
+		return props
+	}
+	out := make(map[string]any, len(props))
+	for k, v := range props {
+		dst, ok := c.FieldMap[k]
+		switch {
+		case !ok && c.KeepUnmapped:
+			if _, taken := out[k]; !taken {
+				out[k] = v
+			}
+		case ok && dst != "":
+			out[dst] = v // 映射的目标优先于同名的未映射字段
+		}
+	}
+	return out
 }
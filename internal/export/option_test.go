@@ -0,0 +1,301 @@
+package export
+
+import (
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"txt2geo/internal/domain"
+)
+
+// newTestConfig 返回一个可通过 Verify 的最小配置：单个输入文件、临时输出目录、FGB 格式。
+func newTestConfig(t *testing.T) ExportConfig {
+	t.Helper()
+	return ExportConfig{
+		InputPaths: []string{writeSample(t, t.TempDir(), "sample.txt")},
+		OutputDir:  t.TempDir(),
+		FormatKey:  "FGB",
+	}
+}
+
+func TestVerifyProjection(t *testing.T) {
+	scale, badScale, badLat := 0.9996, 0.0, 91.0
+	tests := []struct {
+		name       string
+		projection domain.ProjectionOptions
+		wantErr    string
+	}{
+		{"标准参数", domain.ProjectionOptions{}, ""},
+		{"比例因子 0.9996", domain.ProjectionOptions{ScaleFactor: &scale}, ""},
+		{"比例因子为 0", domain.ProjectionOptions{ScaleFactor: &badScale}, "比例因子"},
+		{"原点纬度越界", domain.ProjectionOptions{LatitudeOfOrigin: &badLat}, "原点纬度"},
+		{"北偏移 10000000", domain.ProjectionOptions{FalseNorthing: 10000000}, ""},
+		{"北偏移为 NaN", domain.ProjectionOptions{FalseNorthing: math.NaN()}, "北偏移"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(t)
+			cfg.Projection = tt.projection
+			err := cfg.Verify()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyHistoryFileName(t *testing.T) {
+	tests := []struct {
+		name         string
+		historyFile  string
+		noHistory    bool
+		resume       bool
+		wantFile     string
+		wantManifest string
+		wantErr      string
+	}{
+		{"默认文件名", "", false, false, ProcessedFileName, ManifestFileName, ""},
+		{"仅空白按默认处理", "  ", false, false, ProcessedFileName, ManifestFileName, ""},
+		{"自定义文件名", ".daily", false, false, ".daily", ".daily" + ManifestFileName, ""},
+		{"不记录历史", ".daily", true, false, "", "", ""},
+		{"包含目录", "sub/.daily", false, false, "", "", "只能是文件名"},
+		{"resume 与 no-history 冲突", "", true, true, "", "", "no-history"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(t)
+			cfg.HistoryFileName = tt.historyFile
+			cfg.NoHistory = tt.noHistory
+			cfg.Resume = tt.resume
+			err := cfg.Verify()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if got, want := cfg.ProcessFilePath(), joinIfSet(cfg.ProcessFileDir(), tt.wantFile); got != want {
+				t.Errorf("ProcessFilePath = %q, want %q", got, want)
+			}
+			if got, want := cfg.ManifestFilePath(), joinIfSet(cfg.ProcessFileDir(), tt.wantManifest); got != want {
+				t.Errorf("ManifestFilePath = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+// joinIfSet 拼接目录与文件名，文件名为空时返回空串（对应不记录历史）。
+func joinIfSet(dir, name string) string {
+	if name == "" {
+		return ""
+	}
+	return filepath.Join(dir, name)
+}
+
+func TestVerifyOptionConflicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *ExportConfig)
+		wantErr string
+	}{
+		{"append 与 overwrite", func(c *ExportConfig) { c.Append, c.Overwrite = true, true }, "append 与 overwrite 不能同时使用"},
+		{"仅 append", func(c *ExportConfig) { c.Append = true }, ""},
+		{"原生格式 append", func(c *ExportConfig) { c.FormatKey, c.Append = "GEOJSON", true }, "不支持 append"},
+		{"原生格式 reproject", func(c *ExportConfig) { c.FormatKey, c.Reproject = "CSV", true }, "不支持坐标转换"},
+		{"不支持的输出编码", func(c *ExportConfig) { c.OutputEncoding = "big5" }, "不支持的输出编码"},
+		{"原生格式 GBK", func(c *ExportConfig) { c.FormatKey, c.OutputEncoding = "GEOJSON", "GBK" }, "始终以 UTF-8 写出"},
+		{"原生格式 UTF-8", func(c *ExportConfig) { c.FormatKey, c.OutputEncoding = "CSV", "utf8" }, ""},
+		{"创建选项缺少值", func(c *ExportConfig) { c.CreationArgs = []string{"SPATIAL_INDEX="} }, "KEY=VALUE"},
+		{"创建选项缺少键", func(c *ExportConfig) { c.CreationArgs = []string{"=YES"} }, "KEY=VALUE"},
+		{"原生格式创建选项", func(c *ExportConfig) { c.FormatKey, c.CreationArgs = "GEOJSON", []string{"A=B"} }, "不支持 GDAL 创建选项"},
+		{"字段映射到同一目标", func(c *ExportConfig) { c.Fields = []string{"pid=ID", "pname=ID"} }, "映射到同一目标 'ID'"},
+		{"字段映射缺少源键", func(c *ExportConfig) { c.Fields = []string{"=ID"} }, "格式为 源键=目标键"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(t)
+			tt.modify(&cfg)
+			err := cfg.Verify()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNormalizeOutputEncoding(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"", "UTF-8", false},
+		{"utf-8", "UTF-8", false},
+		{" UTF8 ", "UTF-8", false},
+		{"gbk", "GBK", false},
+		{"CP936", "GBK", false},
+		{"gb18030", "GB18030", false},
+		{"latin1", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := normalizeOutputEncoding(tt.in)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("normalizeOutputEncoding(%q) = %q, %v, want %q, wantErr %v", tt.in, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseCreationOptions(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.CreationOptions = map[string]string{"METHOD": "SKIP"}
+	cfg.CreationArgs = []string{" spatial_index = no ", "2gb_limit=YES", "METHOD=AUTO", "A=B=C"}
+	if err := cfg.Verify(); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	want := map[string]string{"SPATIAL_INDEX": "no", "2GB_LIMIT": "YES", "METHOD": "AUTO", "A": "B=C"}
+	if !maps.Equal(cfg.CreationOptions, want) {
+		t.Errorf("CreationOptions = %v, want %v", cfg.CreationOptions, want)
+	}
+}
+
+func TestGetFormatDetails(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"shp", "SHP"},
+		{"Shapefile", "SHP"},
+		{".SHP", "SHP"},
+		{"flatgeobuf", "FGB"},
+		{"GeoPackage", "GPKG"},
+		{".gpkg", "GPKG"},
+		{"OpenFileGDB", "GDB"},
+		{"sqlite", "SPATIALITE"},
+		{"MapInfo File", "TAB"},
+		{"google earth", "KML"},
+		{"json", "GEOJSON"},
+		{".csv", "CSV"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := GetFormatDetails(tt.key)
+			if err != nil {
+				t.Fatalf("GetFormatDetails: %v", err)
+			}
+			if got.Code != tt.want {
+				t.Errorf("Code = %s, want %s", got.Code, tt.want)
+			}
+			if !reflect.DeepEqual(got, supportedFormats[tt.want]) {
+				t.Errorf("GetFormatDetails(%q) = %+v, want %+v", tt.key, got, supportedFormats[tt.want])
+			}
+		})
+	}
+	if _, err := GetFormatDetails("DXF"); err == nil || !strings.Contains(err.Error(), "不支持的输出格式") {
+		t.Errorf("不支持的格式 err = %v", err)
+	}
+}
+
+func TestReadInputList(t *testing.T) {
+	dir := t.TempDir()
+	abs := filepath.Join(t.TempDir(), "abs.txt")
+	content := "\uFEFF# 输入清单\n" +
+		"a.txt\n" +
+		"\n" +
+		"  sub/b.txt  \r\n" +
+		"   # 缩进的注释\n" +
+		abs + "\n" +
+		"*.txt\n"
+	list := filepath.Join(dir, "inputs.lst")
+	if err := os.WriteFile(list, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readInputList(list)
+	if err != nil {
+		t.Fatalf("readInputList: %v", err)
+	}
+	want := []string{
+		filepath.Join(dir, "a.txt"),
+		filepath.Join(dir, "sub", "b.txt"),
+		abs,
+		filepath.Join(dir, "*.txt"),
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("readInputList = %q, want %q", got, want)
+	}
+
+	// Verify 将清单条目追加到 InputPaths 之后
+	cfg := newTestConfig(t)
+	first := cfg.InputPaths[0]
+	cfg.InputList = list
+	if err := cfg.Verify(); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if want := append([]string{first}, want...); !slices.Equal(cfg.InputPaths, want) {
+		t.Errorf("InputPaths = %q, want %q", cfg.InputPaths, want)
+	}
+
+	if _, err := readInputList(filepath.Join(dir, "missing.lst")); err == nil {
+		t.Error("清单不存在时应返回错误")
+	}
+}
+
+func TestMapFields(t *testing.T) {
+	props := map[string]any{"pid": "1", "pname": "地块1", "area": 123.45, "code": "0101"}
+	tests := []struct {
+		name         string
+		fields       []string
+		keepUnmapped bool
+		want         map[string]any
+	}{
+		{"未配置映射", nil, false, props},
+		{"重命名并删除未列出字段", []string{"pid=DKBH", "pname=DKMC"}, false, map[string]any{"DKBH": "1", "DKMC": "地块1"}},
+		{"原名保留", []string{"pid", "area=MJ"}, false, map[string]any{"pid": "1", "MJ": 123.45}},
+		{"显式删除", []string{"code=", "pid=DKBH"}, true, map[string]any{"DKBH": "1", "pname": "地块1", "area": 123.45}},
+		{"保留未列出字段", []string{"pid=DKBH"}, true, map[string]any{"DKBH": "1", "pname": "地块1", "area": 123.45, "code": "0101"}},
+		{"映射目标优先于同名字段", []string{"pid=code"}, true, map[string]any{"code": "1", "pname": "地块1", "area": 123.45}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(t)
+			cfg.Fields, cfg.KeepUnmapped = tt.fields, tt.keepUnmapped
+			if err := cfg.Verify(); err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if got := cfg.mapFields(maps.Clone(props)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mapFields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessSingleFileFieldMap(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Fields = []string{"pid=DKBH", "area=", "bp_cnt"}
+	e := newProcessedExporter(t, cfg, map[string]string{"a.txt": sampleContent})
+	props := e.ProcessedData["h-a.txt"].Features[0]["properties"].(map[string]any)
+	if want := map[string]any{"DKBH": "1", "bp_cnt": "4"}; !reflect.DeepEqual(props, want) {
+		t.Errorf("properties = %v, want %v", props, want)
+	}
+}
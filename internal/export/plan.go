@@ -4,18 +4,30 @@ Copyright © 2025 TheMachine <592858548@qq.com>
 package export
 
 import (
+	"bytes"
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
+	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"txt2geo/internal/process"
 	"txt2geo/internal/util"
 	"txt2geo/pkg/logger"
 	"txt2geo/pkg/namex"
 	"txt2geo/pkg/pathx"
 )
 
-const defaultMergeName = "merged_output"
+const (
+	defaultMergeName = "merged_output"
+	wgs84CRS         = "EPSG:4326"
+	customCRS        = "custom" // 无标准 EPSG 代码时 {epsg} 的取值
+	mixedCRS         = "mixed"  // 合并模式下源文件坐标系不一致时 {epsg}/{crs} 的取值
+)
 
 // ExportPlan 定义了单个导出任务的源和目标。
 type ExportPlan struct {
@@ -34,6 +46,37 @@ func (p ExportPlan) displayTarget(isContainer bool) string {
 	return filepath.Join(p.OutputTarget, p.OutputName)
 }
 
+// crsLabels 返回名称模板 {epsg} 与 {crs} 的取值：标准投影为 EPSG 代码与 "EPSG<代码>"，
+// 自定义投影为 "custom" 与投影名称；多个源文件的坐标系不一致时均为 "mixed"。
+func (e *Exporter) crsLabels(hashes []string) (epsg, crs string) {
+	for _, h := range hashes {
+		pf, ok := e.ProcessedData[h]
+		if !ok {
+			continue
+		}
+		// 自定义中央经线的投影名称可能含小数点（如 CM_114.5E），替换掉以免被当作扩展名截断
+		fe, fc := customCRS, cmp.Or(strings.ReplaceAll(pf.CRSName, ".", "_"), customCRS)
+		if pf.EPSG > 0 {
+			fe = strconv.Itoa(pf.EPSG)
+			fc = "EPSG" + fe
+		}
+		if epsg != "" && (fe != epsg || fc != crs) {
+			return mixedCRS, mixedCRS
+		}
+		epsg, crs = fe, fc
+	}
+	return epsg, crs
+}
+
+// sourcePaths 返回哈希对应的源文件路径。
+func sourcePaths(fileCache map[string]FileCache, hashes []string) []string {
+	paths := make([]string, 0, len(hashes))
+	for _, h := range hashes {
+		paths = append(paths, fileCache[h].Path)
+	}
+	return paths
+}
+
 // generatePlans 根据源文件和配置创建导出计划列表。
 func (e *Exporter) generatePlans(fileCache map[string]FileCache) ([]ExportPlan, error) {
 	tmpl := strings.TrimSpace(e.Config.NameTemplate)
@@ -56,10 +99,18 @@ func (e *Exporter) generatePlans(fileCache map[string]FileCache) ([]ExportPlan,
 		for hash := range fileCache {
 			hashes = append(hashes, hash)
 		}
+		// map 遍历顺序不固定，按源路径排序使合并结果在多次运行间保持一致
+		slices.SortFunc(hashes, func(a, b string) int {
+			return cmp.Or(pathx.ComparePaths(fileCache[a].Path, fileCache[b].Path), strings.Compare(a, b))
+		})
 		items = append(items, item{sourceHashes: hashes, baseName: defaultMergeName, index: 1})
 	} else {
-		// 分散模式：每个文件一个计划
-		for hash, cache := range fileCache {
+		// 分散模式：每个文件一个计划；按源路径排序，使 {index}/{seq} 编号在多次运行间保持一致
+		hashes := slices.SortedFunc(maps.Keys(fileCache), func(a, b string) int {
+			return cmp.Or(pathx.ComparePaths(fileCache[a].Path, fileCache[b].Path), strings.Compare(a, b))
+		})
+		for _, hash := range hashes {
+			cache := fileCache[hash]
 			i := len(items) // 实际上可以使用一个单独的计数器，为了保持代码清晰
 			stem, serr := pathx.Stem(cache.Path)
 			if serr != nil || strings.TrimSpace(stem) == "" {
@@ -72,7 +123,18 @@ func (e *Exporter) generatePlans(fileCache map[string]FileCache) ([]ExportPlan,
 	plans := make([]ExportPlan, 0, total)
 
 	for _, it := range items {
-		outputName := renderNameTemplate(tmpl, it.baseName, it.index, total)
+		epsgLabel, crsLabel := e.crsLabels(it.sourceHashes)
+		outputName := renderNameTemplate(tmpl, nameTemplateData{
+			BaseName: it.baseName,
+			Index:    it.index,
+			SeqStart: e.Config.SeqStart,
+			Count:    total,
+			Parent:   parentName(sourcePaths(fileCache, it.sourceHashes)),
+			EPSG:     epsgLabel,
+			CRS:      crsLabel,
+			Hash:     combinedHash(it.sourceHashes),
+			Location: e.Config.Location,
+		})
 		outputName = namex.Sanitize(outputName, e.UsedNames)
 
 		if !formatDetails.IsContainer {
@@ -83,7 +145,41 @@ func (e *Exporter) generatePlans(fileCache map[string]FileCache) ([]ExportPlan,
 	return plans, nil
 }
 
-// previewPlans 打印导出计划的预览信息。
+// 预览时目标的状态标记。
+const (
+	targetNew       = "[new]"       // 目标不存在
+	targetOverwrite = "[overwrite]" // 目标已存在，将被覆盖
+	targetAppend    = "[append]"    // 目标已存在，将追加要素
+	targetConflict  = "[conflict]"  // 目标已存在且未开启 --overwrite / --append
+	targetExists    = "[exists]"    // 容器已存在，图层级冲突无法低成本检查
+)
+
+// targetStatus 检查计划的输出目标在磁盘上的状态，供预览模式提示覆盖或冲突。
+func (e *Exporter) targetStatus(plan ExportPlan, isContainer bool) string {
+	path := plan.displayTarget(false)
+	if isContainer {
+		path = plan.OutputTarget
+	}
+	exists, err := pathx.Exists(path)
+	if err != nil {
+		logger.Log().Debug("  [预览] 检查目标失败", "输出", path, "原因", err)
+		return targetNew
+	}
+	switch {
+	case !exists:
+		return targetNew
+	case isContainer:
+		return targetExists
+	case e.Config.Append:
+		return targetAppend
+	case e.Config.Overwrite:
+		return targetOverwrite
+	default:
+		return targetConflict
+	}
+}
+
+// previewPlans 打印导出计划的预览信息，并标注每个目标是新建、覆盖还是冲突。
 func (e *Exporter) previewPlans(plans []ExportPlan) {
 	total := len(plans)
 	mode := "分散模式"
@@ -93,6 +189,7 @@ func (e *Exporter) previewPlans(plans []ExportPlan) {
 	logger.Log().Info("[预览] 预览导出计划", "模式", mode, "计划数", total, "格式", e.Config.FormatKey)
 	isContainer := e.Config.FormatDetails.IsContainer
 	width := util.IntDigits(total)
+	conflicts := 0
 	for i, plan := range plans {
 		var src slog.Attr
 		if len(plan.SourceHashes) > 1 {
@@ -103,18 +200,65 @@ func (e *Exporter) previewPlans(plans []ExportPlan) {
 				src = slog.String("源路径", cache.Path)
 			}
 		}
+		status := e.targetStatus(plan, isContainer)
 		progress := fmt.Sprintf("[%0*d/%d]", width, i+1, total)
-		message := fmt.Sprintf("  %s", progress)
+		message := fmt.Sprintf("  %s %s", progress, status)
+		if status == targetConflict {
+			conflicts++
+			logger.Log().Warn(message, src, "输出", plan.displayTarget(isContainer))
+			continue
+		}
 		logger.Log().Info(message, src, "输出", plan.displayTarget(isContainer))
 	}
+	if conflicts > 0 {
+		logger.Log().Warn("[预览] 部分目标已存在，实际导出时需要 --overwrite 或 --append", "冲突", conflicts)
+	}
 }
 
 // ExecutionResult 保存计划执行的结果。
 type ExecutionResult struct {
 	Payload      []byte
-	SuccessCount int // 成功组装的数据集数量
-	LayerCount   int // 图层数量
-	FeatureCount int // 要素总数
+	Shards       []PayloadShard // 分片后的负载；未分片时为 nil，直接使用 Payload
+	SuccessCount int            // 成功组装的数据集数量
+	LayerCount   int            // 图层数量
+	FeatureCount int            // 要素总数
+	PerFile      map[string]int // 源文件路径 -> 要素数（含过滤后为 0 的文件）
+}
+
+// PayloadShard 是交给单个 Python 进程处理的一部分负载。
+type PayloadShard struct {
+	Payload      []byte
+	LayerCount   int
+	FeatureCount int
+}
+
+// shardCount 返回 Python 导出阶段实际可用的并发数。
+// 容器格式多个进程同时写同一个文件不安全，合并模式所有数据集写入同一目标，二者均不分片。
+func (e *Exporter) shardCount(datasets int) int {
+	n := e.Config.ExportConcurrency
+	if n <= 1 || e.Config.Merge || e.Config.FormatDetails.IsContainer {
+		return 1
+	}
+	return min(n, datasets)
+}
+
+// crsSource 记录一个源坐标系及首个使用它的源文件，用于报告合并模式下的坐标系冲突。
+type crsSource struct {
+	crs  string
+	path string
+}
+
+// describeCRSSources 将坐标系列表格式化为 "EPSG:4526 (a.txt), EPSG:4527 (b.txt)"，自定义投影显示为"自定义投影"。
+func describeCRSSources(sources []crsSource) string {
+	parts := make([]string, 0, len(sources))
+	for _, s := range sources {
+		label := s.crs
+		if !strings.HasPrefix(label, "EPSG:") {
+			label = "自定义投影"
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s)", label, s.path))
+	}
+	return strings.Join(parts, ", ")
 }
 
 // executePlans 实际执行所有导出任务。
@@ -131,28 +275,51 @@ func (e *Exporter) executePlans(plans []ExportPlan) (*ExecutionResult, error) {
 	var (
 		targetCRS    string // 所有文件的目标坐标系
 		featureTotal int    // 总要素图形（地块）数量
+		sourceCRS    []crsSource
 	)
 	datasets := make([]map[string]any, 0, total)
+	e.targets = make(map[string]process.OutputTarget, len(e.ProcessedData))
+	perFile := make(map[string]int, len(e.ProcessedData))
 
+	layers := 0
+	e.startStage(StageAssemble, total)
 	for i, plan := range plans {
+		e.advanceStage(StageAssemble)
 		layerName := plan.OutputName
+		planDatasets := 0
 		for _, hash := range plan.SourceHashes {
 			if processedFile, ok := e.ProcessedData[hash]; ok {
+				features := e.filterFeatures(processedFile.Features)
+				perFile[processedFile.FileCache.Path] = len(features)
+				if len(features) == 0 {
+					logger.Log().Debug("  [过滤] 过滤后无要素", "源路径", processedFile.FileCache.Path, "过滤", e.Config.Filter)
+					continue
+				}
+				e.targets[hash] = plan.outputTarget(isContainer)
 				if targetCRS == "" && processedFile.EPSG > 0 {
 					targetCRS = fmt.Sprintf("EPSG:%d", processedFile.EPSG)
 				}
+				if !slices.ContainsFunc(sourceCRS, func(s crsSource) bool { return s.crs == processedFile.CRS }) {
+					sourceCRS = append(sourceCRS, crsSource{crs: processedFile.CRS, path: processedFile.FileCache.Path})
+				}
 
-				featureTotal += len(processedFile.Features) // 统计要素数量
+				featureTotal += len(features) // 统计要素数量
+				planDatasets++
 				datasets = append(datasets, map[string]any{
 					"layer_name":     layerName,
 					"source_path":    processedFile.FileCache.Path,
 					"source_crs":     processedFile.CRS,
-					"features":       processedFile.Features,
-					"total_features": len(processedFile.Features),
+					"features":       features,
+					"total_features": len(features),
 					"hash":           processedFile.FileCache.Hash,
 				})
 			}
 		}
+		if planDatasets == 0 {
+			e.logPerFile(slog.LevelInfo, "  [跳过] 过滤后无要素", "输出", plan.displayTarget(isContainer))
+			continue
+		}
+		layers++
 
 		var src slog.Attr
 		if len(plan.SourceHashes) > 1 {
@@ -165,31 +332,101 @@ func (e *Exporter) executePlans(plans []ExportPlan) (*ExecutionResult, error) {
 		}
 		progress := fmt.Sprintf("[%0*d/%d]", width, i+1, total)
 		message := fmt.Sprintf("  %s", progress)
-		logger.Log().Info(message, src, "输出", plan.displayTarget(isContainer))
+		e.logPerFile(slog.LevelInfo, message, src, "输出", plan.displayTarget(isContainer))
 	}
 
 	if len(datasets) == 0 {
 		return &ExecutionResult{
 			SuccessCount: 0,
+			PerFile:      perFile,
 		}, nil
 	}
+	if e.Config.Merge && len(sourceCRS) > 1 && e.Config.FormatDetails.Code != "KML" {
+		// 合并到同一图层时坐标系不一致会导致空间位置错误：默认报错，--reproject 时统一重投影
+		if !e.Config.Reproject {
+			return nil, fmt.Errorf("合并模式下源文件坐标系不一致: %s；请按坐标系分批导出，或使用 --reproject 统一重投影", describeCRSSources(sourceCRS))
+		}
+		targetCRS = cmp.Or(targetCRS, sourceCRS[0].crs)
+		logger.Log().Warn("[警告] 源文件坐标系不一致，将统一重投影", "目标坐标系", targetCRS, "坐标系数", len(sourceCRS))
+	}
+	if e.Config.FormatDetails.Code == "KML" && targetCRS != wgs84CRS {
+		logger.Log().Warn("[警告] KML 要求 WGS84 经纬度坐标，源数据将被转换", "源坐标系", cmp.Or(targetCRS, "自定义"), "目标坐标系", wgs84CRS)
+		targetCRS = wgs84CRS
+	}
 
 	root := map[string]any{
-		"output_dir": e.Config.OutputDir,
-		"driver":     e.Config.FormatDetails.Driver,
-		"target_crs": targetCRS,
-		"merge":      e.Config.Merge,
-		"overwrite":  e.Config.Overwrite,
-		"datasets":   datasets,
+		"output_dir":      e.Config.OutputDir,
+		"driver":          e.Config.FormatDetails.Driver,
+		"is_container":    e.Config.FormatDetails.IsContainer,
+		"dataset_options": e.Config.FormatDetails.DatasetOptions,
+		"target_crs":      targetCRS,
+		"merge":           e.Config.Merge,
+		"overwrite":       e.Config.Overwrite,
+		"append":          e.Config.Append,
+		"encoding":        e.Config.OutputEncoding,
+		"datasets":        datasets,
+	}
+	if len(e.Config.CreationOptions) > 0 {
+		root["creation_options"] = e.Config.CreationOptions
 	}
 	data, err := json.Marshal(root)
 	if err != nil {
 		return nil, err
 	}
-	return &ExecutionResult{
+	if err := e.dumpPayload(root); err != nil {
+		return nil, err
+	}
+	result := &ExecutionResult{
 		Payload:      data,
 		SuccessCount: len(datasets),
-		LayerCount:   total,
+		LayerCount:   layers,
 		FeatureCount: featureTotal,
-	}, nil
+		PerFile:      perFile,
+	}
+
+	// 按数据集轮询分片，每个分片复用相同的根配置
+	if n := e.shardCount(len(datasets)); n > 1 {
+		groups := make([][]map[string]any, n)
+		for i, ds := range datasets {
+			groups[i%n] = append(groups[i%n], ds)
+		}
+		for _, group := range groups {
+			shardRoot := maps.Clone(root)
+			shardRoot["datasets"] = group
+			shardData, err := json.Marshal(shardRoot)
+			if err != nil {
+				return nil, err
+			}
+			features := 0
+			for _, ds := range group {
+				features += ds["total_features"].(int)
+			}
+			result.Shards = append(result.Shards, PayloadShard{
+				Payload:      shardData,
+				LayerCount:   len(group),
+				FeatureCount: features,
+			})
+		}
+	}
+	return result, nil
+}
+
+// dumpPayload 在配置了 DumpPayload 时，将发送给 Python 的负载以缩进格式写入该文件，便于排查问题。
+func (e *Exporter) dumpPayload(root map[string]any) error {
+	path := e.Config.DumpPayload
+	if path == "" {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return fmt.Errorf("序列化调试负载失败: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("写入调试负载 %s 失败: %w", path, err)
+	}
+	logger.Log().Info("[调试] 已写出 Python 负载", "路径", path, "大小", util.HumanBytes(int64(buf.Len())))
+	return nil
 }
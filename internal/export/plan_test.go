@@ -0,0 +1,258 @@
+package export
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// zone39Content 是位于第 39 带（EPSG:4527）的示例源文件，与 sampleContent（EPSG:4526）坐标系不同。
+var zone39Content = strings.NewReplacer("带号=38", "带号=39", "38500", "39500").Replace(sampleContent)
+
+// newProcessedExporter 按 cfg 预处理 contents 中的源文件（路径 -> 内容），
+// 返回已填充 FileCache 与 ProcessedData、可直接生成并执行计划的 Exporter。
+func newProcessedExporter(t *testing.T, cfg ExportConfig, contents map[string]string) *Exporter {
+	t.Helper()
+	if err := cfg.Verify(); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	e := &Exporter{
+		Config:        cfg,
+		FileCache:     make(map[string]FileCache),
+		ProcessedData: make(map[string]*ProcessedFile),
+		UsedNames:     make(map[string]struct{}),
+	}
+	for path, content := range contents {
+		fc := FileCache{Path: path, Content: []byte(content), Hash: "h-" + path}
+		res, err := e.processSingleFile(fc)
+		if err != nil {
+			t.Fatalf("processSingleFile(%s): %v", path, err)
+		}
+		e.FileCache[fc.Hash] = fc
+		e.ProcessedData[fc.Hash] = &ProcessedFile{FileCache: fc, Features: res.Features, CRS: res.CRS, EPSG: res.EPSG, CRSName: res.CRSName}
+	}
+	return e
+}
+
+// runPlans 生成并执行导出计划。
+func runPlans(t *testing.T, e *Exporter) (*ExecutionResult, error) {
+	t.Helper()
+	plans, err := e.generatePlans(e.FileCache)
+	if err != nil {
+		t.Fatalf("generatePlans: %v", err)
+	}
+	return e.executePlans(plans)
+}
+
+// decodePayload 将负载解码为通用 JSON 值。
+func decodePayload(t *testing.T, data []byte) map[string]any {
+	t.Helper()
+	var root map[string]any
+	if err := json.Unmarshal(data, &root); err != nil {
+		t.Fatalf("解析负载失败: %v", err)
+	}
+	return root
+}
+
+func TestExecutePlansFilterAndPerFile(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Filter = "area>1000"
+	e := newProcessedExporter(t, cfg, map[string]string{
+		"a.txt": sampleContent, // area=123.45，被全部过滤
+		"b.txt": strings.Replace(sampleContent, "123.45", "5000", 1),
+	})
+	result, err := runPlans(t, e)
+	if err != nil {
+		t.Fatalf("executePlans: %v", err)
+	}
+	if result.SuccessCount != 1 || result.LayerCount != 1 || result.FeatureCount != 1 {
+		t.Errorf("SuccessCount/LayerCount/FeatureCount = %d/%d/%d, want 1/1/1", result.SuccessCount, result.LayerCount, result.FeatureCount)
+	}
+
+	// PerFile 覆盖所有源文件（含过滤后为 0 的文件），且与负载中的数据集一致
+	if want := map[string]int{"a.txt": 0, "b.txt": 1}; !reflect.DeepEqual(result.PerFile, want) {
+		t.Errorf("PerFile = %v, want %v", result.PerFile, want)
+	}
+	datasets := decodePayload(t, result.Payload)["datasets"].([]any)
+	if len(datasets) != 1 {
+		t.Fatalf("数据集数 = %d, want 1（全部过滤的计划应被跳过）", len(datasets))
+	}
+	for _, raw := range datasets {
+		ds := raw.(map[string]any)
+		path := ds["source_path"].(string)
+		if got := int(ds["total_features"].(float64)); got != result.PerFile[path] {
+			t.Errorf("%s 的 total_features = %d, PerFile = %d", path, got, result.PerFile[path])
+		}
+	}
+
+	// 所有计划均被过滤时返回空结果而不是错误
+	cfg = newTestConfig(t)
+	cfg.Filter = "area>100000"
+	e = newProcessedExporter(t, cfg, map[string]string{"a.txt": sampleContent})
+	result, err = runPlans(t, e)
+	if err != nil {
+		t.Fatalf("executePlans: %v", err)
+	}
+	if result.SuccessCount != 0 || result.Payload != nil || result.PerFile["a.txt"] != 0 {
+		t.Errorf("全部过滤时 result = %+v, want 空负载", result)
+	}
+}
+
+func TestExecutePlansMergePayloadStable(t *testing.T) {
+	contents := map[string]string{}
+	for _, name := range []string{"c.txt", "a.txt", "e.txt", "b.txt", "d.txt"} {
+		contents[name] = strings.Replace(sampleContent, "地块1", "地块"+name, 1)
+	}
+	cfg := newTestConfig(t)
+	cfg.Merge = true
+	var first []byte
+	for i := range 5 {
+		result, err := runPlans(t, newProcessedExporter(t, cfg, contents))
+		if err != nil {
+			t.Fatalf("executePlans: %v", err)
+		}
+		if i == 0 {
+			first = result.Payload
+			continue
+		}
+		if string(result.Payload) != string(first) {
+			t.Fatalf("第 %d 次运行的合并负载与第一次不同", i+1)
+		}
+	}
+	var paths []string
+	for _, raw := range decodePayload(t, first)["datasets"].([]any) {
+		paths = append(paths, raw.(map[string]any)["source_path"].(string))
+	}
+	if want := []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("数据集顺序 = %v, want %v", paths, want)
+	}
+}
+
+func TestDumpPayload(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.DumpPayload = filepath.Join(t.TempDir(), "payload.json")
+	result, err := runPlans(t, newProcessedExporter(t, cfg, map[string]string{"地块.txt": sampleContent}))
+	if err != nil {
+		t.Fatalf("executePlans: %v", err)
+	}
+	data, err := os.ReadFile(cfg.DumpPayload)
+	if err != nil {
+		t.Fatalf("读取调试负载: %v", err)
+	}
+	if !strings.Contains(string(data), "\n  \"datasets\"") {
+		t.Errorf("调试负载应为缩进格式:\n%s", data)
+	}
+	if !strings.Contains(string(data), "地块.txt") {
+		t.Errorf("调试负载中的中文不应被转义:\n%s", data)
+	}
+	if got, want := decodePayload(t, data), decodePayload(t, result.Payload); !reflect.DeepEqual(got, want) {
+		t.Errorf("调试负载与实际负载不一致:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestExecutePlansMixedCRS(t *testing.T) {
+	contents := map[string]string{"a.txt": sampleContent, "b.txt": zone39Content}
+	tests := []struct {
+		name       string
+		merge      bool
+		reproject  bool
+		wantErr    string
+		wantTarget string
+	}{
+		{"合并模式报错", true, false, "EPSG:4526 (a.txt), EPSG:4527 (b.txt)", ""},
+		{"合并模式重投影", true, true, "", "EPSG:4526"},
+		{"分散模式不检查", false, false, "", "EPSG:4526"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(t)
+			cfg.Merge, cfg.Reproject = tt.merge, tt.reproject
+			result, err := runPlans(t, newProcessedExporter(t, cfg, contents))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("executePlans: %v", err)
+			}
+			if got := decodePayload(t, result.Payload)["target_crs"]; got != tt.wantTarget {
+				t.Errorf("target_crs = %v, want %s", got, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestExecutePlansPayloadOptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		encoding     string
+		creation     []string
+		wantEncoding string
+		wantCreation map[string]any
+	}{
+		{"默认", "", nil, "UTF-8", nil},
+		{"GBK 与创建选项", "cp936", []string{" spatial_index = NO ", "METHOD=SKIP"}, "GBK", map[string]any{"SPATIAL_INDEX": "NO", "METHOD": "SKIP"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(t)
+			cfg.OutputEncoding, cfg.CreationArgs = tt.encoding, tt.creation
+			result, err := runPlans(t, newProcessedExporter(t, cfg, map[string]string{"a.txt": sampleContent}))
+			if err != nil {
+				t.Fatalf("executePlans: %v", err)
+			}
+			root := decodePayload(t, result.Payload)
+			if root["encoding"] != tt.wantEncoding {
+				t.Errorf("encoding = %v, want %s", root["encoding"], tt.wantEncoding)
+			}
+			got, ok := root["creation_options"]
+			if tt.wantCreation == nil {
+				if ok {
+					t.Errorf("未配置创建选项时负载不应包含 creation_options: %v", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.wantCreation) {
+				t.Errorf("creation_options = %v, want %v", got, tt.wantCreation)
+			}
+		})
+	}
+}
+
+func TestTargetStatus(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "old.fgb"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	container := filepath.Join(dir, "out.gpkg")
+	if err := os.WriteFile(container, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		config      ExportConfig
+		plan        ExportPlan
+		isContainer bool
+		want        string
+	}{
+		{"新文件", ExportConfig{}, ExportPlan{OutputTarget: dir, OutputName: "new.fgb"}, false, targetNew},
+		{"已存在且 overwrite", ExportConfig{Overwrite: true}, ExportPlan{OutputTarget: dir, OutputName: "old.fgb"}, false, targetOverwrite},
+		{"已存在且 append", ExportConfig{Append: true}, ExportPlan{OutputTarget: dir, OutputName: "old.fgb"}, false, targetAppend},
+		{"已存在未指定", ExportConfig{}, ExportPlan{OutputTarget: dir, OutputName: "old.fgb"}, false, targetConflict},
+		{"容器已存在", ExportConfig{}, ExportPlan{OutputTarget: container, OutputName: "layer"}, true, targetExists},
+		{"容器不存在", ExportConfig{}, ExportPlan{OutputTarget: filepath.Join(dir, "new.gpkg"), OutputName: "layer"}, true, targetNew},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Exporter{Config: tt.config}
+			if got := e.targetStatus(tt.plan, tt.isContainer); got != tt.want {
+				t.Errorf("targetStatus = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package export
+
+// 导出流程的进度阶段，作为 ProgressFunc 的 stage 参数。
+const (
+	StageCollect    = "collect"    // 读取源文件并计算哈希
+	StagePreprocess = "preprocess" // 解码、解析与几何预处理
+	StageAssemble   = "assemble"   // 按计划组装导出数据
+	StageWrite      = "write"      // 写出目标文件（Python 回传结果或原生写出）
+)
+
+// ProgressFunc 接收导出进度：stage 为上述阶段之一，done 为该阶段已完成数，total 为该阶段总数。
+// 同一阶段内 done 单调递增；调用已串行化，实现无需自行加锁，但应尽快返回以免阻塞导出。
+type ProgressFunc func(stage string, done, total int)
+
+// stageProgress 记录单个阶段的进度。
+type stageProgress struct {
+	done, total int
+}
+
+// startStage 开始一个进度阶段，重置计数并以 done=0 通知一次。
+func (e *Exporter) startStage(stage string, total int) {
+	if e.Progress == nil {
+		return
+	}
+	e.progressMu.Lock()
+	defer e.progressMu.Unlock()
+	if e.progress == nil {
+		e.progress = make(map[string]*stageProgress)
+	}
+	e.progress[stage] = &stageProgress{total: total}
+	e.Progress(stage, 0, total)
+}
+
+// advanceStage 将阶段计数加一并通知；可在多个 goroutine 中调用，未开始的阶段忽略。
+func (e *Exporter) advanceStage(stage string) {
+	if e.Progress == nil {
+		return
+	}
+	e.progressMu.Lock()
+	defer e.progressMu.Unlock()
+	sp, ok := e.progress[stage]
+	if !ok {
+		return
+	}
+	sp.done = min(sp.done+1, sp.total)
+	e.Progress(stage, sp.done, sp.total)
+}
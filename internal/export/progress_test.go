@@ -0,0 +1,80 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecuteProgressMonotonic(t *testing.T) {
+	in := t.TempDir()
+	// 内容各不相同，避免按哈希去重
+	for i := range 12 {
+		name := fmt.Sprintf("f%02d.txt", i)
+		content := strings.Replace(sampleContent, "地块1", "地块"+name, 1)
+		if err := os.WriteFile(filepath.Join(in, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e, err := NewExporter(ExportConfig{
+		InputPaths:  []string{in},
+		OutputDir:   t.TempDir(),
+		FormatKey:   "GEOJSON",
+		Concurrency: 4,
+		NoHistory:   true,
+	})
+	if err != nil {
+		t.Fatalf("NewExporter: %v", err)
+	}
+
+	type call struct{ done, total int }
+	calls := map[string][]call{}
+	var order []string
+	e.Progress = func(stage string, done, total int) {
+		if len(calls[stage]) == 0 {
+			order = append(order, stage)
+		}
+		calls[stage] = append(calls[stage], call{done, total})
+	}
+	if err := e.Execute(); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	if want := []string{StageCollect, StagePreprocess, StageWrite}; strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("阶段顺序 = %v, want %v", order, want)
+	}
+	for stage, cs := range calls {
+		if cs[0].done != 0 {
+			t.Errorf("%s 首次回调 done = %d, want 0", stage, cs[0].done)
+		}
+		for i, c := range cs {
+			if c.total != cs[0].total {
+				t.Errorf("%s 第 %d 次回调 total = %d, want %d", stage, i+1, c.total, cs[0].total)
+			}
+			if i > 0 && c.done < cs[i-1].done {
+				t.Errorf("%s 进度回退: %d -> %d", stage, cs[i-1].done, c.done)
+			}
+		}
+		if last := cs[len(cs)-1]; last.done != last.total {
+			t.Errorf("%s 最终进度 = %d/%d", stage, last.done, last.total)
+		}
+	}
+	if got := calls[StagePreprocess][0].total; got != 12 {
+		t.Errorf("预处理阶段 total = %d, want 12", got)
+	}
+}
+
+func TestAdvanceStageUnstarted(t *testing.T) {
+	var got []int
+	e := &Exporter{Progress: func(_ string, done, _ int) { got = append(got, done) }}
+	e.advanceStage(StageWrite) // 未开始的阶段被忽略
+	e.startStage(StageWrite, 2)
+	for range 3 {
+		e.advanceStage(StageWrite) // 超出 total 时不再增加
+	}
+	if want := []int{0, 1, 2, 2}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("进度 = %v, want %v", got, want)
+	}
+}
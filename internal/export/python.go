@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os/exec"
@@ -16,13 +17,14 @@ import (
 	"sync/atomic"
 	"time"
 	"txt2geo/internal/pyscript"
+	"txt2geo/internal/util"
 	"txt2geo/pkg/environ"
 	"txt2geo/pkg/logger"
 )
 
 const (
-	// 定义子进程执行的超时时间
-	executionTimeout = 60 * time.Second
+	// DefaultExecTimeout 是 --timeout 的默认值，即 Python 子进程的默认执行超时
+	DefaultExecTimeout = 60 * time.Second
 )
 
 // mapPythonLogLevel 将从 Python 日志中解析出的级别字符串映射到 slog.Level。
@@ -41,22 +43,76 @@ func mapPythonLogLevel(levelStr string) slog.Level {
 	}
 }
 
+// commandRunner 创建外部命令。Exporter 通过它启动 Python 子进程，测试中可替换为伪造命令。
+type commandRunner interface {
+	CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd
+}
+
+// execRunner 是默认的 commandRunner，直接使用 exec.CommandContext。
+type execRunner struct{}
+
+func (execRunner) CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, name, args...)
+}
+
+// runPythonExport 执行 Python 导出阶段；存在分片时为每个分片并发启动一个 Python 进程，
+// 每个进程拥有独立的超时上下文，所有分片的错误汇总后返回。
+func (e *Exporter) runPythonExport(result *ExecutionResult) error {
+	e.startStage(StageWrite, result.SuccessCount)
+	if len(result.Shards) <= 1 {
+		return e.InvokePythonExporter(result.Payload, result.LayerCount, result.FeatureCount)
+	}
+	logger.Log().Info("[导出] 并发启动 Python 导出器", "进程数", len(result.Shards))
+	errs := make([]error, len(result.Shards))
+	var wg sync.WaitGroup
+	for i, shard := range result.Shards {
+		wg.Go(func() {
+			if err := e.InvokePythonExporter(shard.Payload, shard.LayerCount, shard.FeatureCount); err != nil {
+				errs[i] = fmt.Errorf("分片 %d/%d: %w", i+1, len(result.Shards), err)
+			}
+		})
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
 func (e *Exporter) InvokePythonExporter(payload []byte, totalFiles, totalFeatures int) error {
-	logger.Log().Debug("  [准备] 准备调用 Python", "数据大小", fmt.Sprintf("%d bytes", len(payload)))
+	logger.Log().Debug("  [准备] 准备调用 Python", "数据大小", util.HumanBytes(int64(len(payload))))
 
 	// 1. 配置运行环境
-	prefixPath, pythonPath, err := environ.InitializeQGISEnvironment()
+	prefixPath, pythonPath, err := environ.InitializeQGISEnvironmentWithPath(e.Config.QGISPath)
 	if err != nil {
 		return fmt.Errorf("初始化 QGIS 环境失败: %w", err)
 	}
 
-	// 2. 设置带超时的上下文
-	ctx, cancel := context.WithTimeout(context.Background(), executionTimeout)
-	defer cancel()
+	// 在发送数据前确认解释器可以导入 qgis.core，把晦涩的运行时崩溃变为明确的前置诊断
+	e.qgisCheck.Do(func() {
+		e.qgisCheckErr = environ.VerifyQGISPython(pythonPath, prefixPath)
+	})
+	if e.qgisCheckErr != nil {
+		return fmt.Errorf("QGIS Python 环境不可用: %w", e.qgisCheckErr)
+	}
+	return e.runPythonScript(pythonPath, prefixPath, payload, totalFiles, totalFeatures)
+}
+
+// runPythonScript 在 Config.ExecTimeout 限制内运行导出脚本，将负载写入其标准输入，
+// 并实时转发脚本日志、按标准输出中的逐数据集结果更新处理历史。
+func (e *Exporter) runPythonScript(pythonPath, prefixPath string, payload []byte, totalFiles, totalFeatures int) error {
+	// 2. 设置带超时的上下文；ExecTimeout 为 0 时不设截止时间
+	ctx := context.Background()
+	if e.Config.ExecTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Config.ExecTimeout)
+		defer cancel()
+	}
 
 	// 3. 创建执行命令，使用 -c 标志
 	// 第一个参数是 "-c"，第二个参数是脚本的完整内容
-	cmd := exec.CommandContext(ctx, pythonPath, "-c", pyscript.GeoExport, prefixPath)
+	runner := e.runner
+	if runner == nil {
+		runner = execRunner{}
+	}
+	cmd := runner.CommandContext(ctx, pythonPath, "-c", pyscript.GeoExport, prefixPath)
 
 	// 4. 获取标准输出和标准错误的管道
 	stdoutPipe, err := cmd.StdoutPipe()
@@ -85,7 +141,12 @@ func (e *Exporter) InvokePythonExporter(payload []byte, totalFiles, totalFeature
 			if len(parts) == 2 {
 				levelStr, message := parts[0], parts[1]
 				slogLevel := mapPythonLogLevel(levelStr)
-				logger.Log().Log(context.Background(), slogLevel, fmt.Sprintf("  >> [Python] %s", message))
+				if slogLevel == slog.LevelInfo {
+					// Python 的 Info 日志基本都是逐文件进度，交由 SummaryOnly 控制
+					e.logPerFile(slogLevel, fmt.Sprintf("  >> [Python] %s", message))
+				} else {
+					logger.Log().Log(context.Background(), slogLevel, fmt.Sprintf("  >> [Python] %s", message))
+				}
 
 			} else {
 				logger.Log().Warn(fmt.Sprintf("  >> [Python] %s", line))
@@ -107,11 +168,12 @@ func (e *Exporter) InvokePythonExporter(payload []byte, totalFiles, totalFeature
 
 			// 实时处理 hash
 			if hash, ok := res["hash"].(string); ok && hash != "" {
-				if e.History != nil {
-					e.History.CheckAndRecord(hash)
-				}
+				status, _ := res["status"].(string)
+				target, known := e.targets[hash]
+				e.recordExported(hash, target, known && status == ResultProcessed)
 			}
 			resultsCount.Add(1)
+			e.advanceStage(StageWrite)
 		}
 	})
 
@@ -122,7 +184,7 @@ func (e *Exporter) InvokePythonExporter(payload []byte, totalFiles, totalFeature
 	err = cmd.Wait()
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
-			return fmt.Errorf("python 脚本执行超时 (%v)", executionTimeout)
+			return fmt.Errorf("python 脚本执行超时 (%v)", e.Config.ExecTimeout)
 		}
 		return fmt.Errorf("执行 Python 脚本失败: %w", err)
 	}
@@ -0,0 +1,64 @@
+package export
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRunner 忽略请求的 Python 命令，改为用 sh 执行 script，并记录收到的上下文是否带截止时间。
+type fakeRunner struct {
+	script      string
+	name        string
+	hasDeadline bool
+}
+
+func (f *fakeRunner) CommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	f.name = name
+	_, f.hasDeadline = ctx.Deadline()
+	return exec.CommandContext(ctx, "sh", "-c", f.script)
+}
+
+func TestRunPythonScriptTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("伪造命令依赖 sh")
+	}
+	tests := []struct {
+		name         string
+		script       string
+		timeout      time.Duration
+		wantDeadline bool
+		wantErr      string
+	}{
+		{"快速失败", "exit 3", DefaultExecTimeout, true, "执行 Python 脚本失败"},
+		{"超时", "exec sleep 5", 100 * time.Millisecond, true, "执行超时"},
+		{"0 表示不限制", "exit 0", 0, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{script: tt.script}
+			e := &Exporter{Config: ExportConfig{ExecTimeout: tt.timeout}, runner: runner}
+			start := time.Now()
+			err := e.runPythonScript("python-fake", "", []byte("{}"), 1, 1)
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("runPythonScript 耗时 %v，超时未生效", elapsed)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("runPythonScript: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+			}
+			if runner.name != "python-fake" {
+				t.Errorf("命令 = %q, want python-fake", runner.name)
+			}
+			if runner.hasDeadline != tt.wantDeadline {
+				t.Errorf("上下文带截止时间 = %v, want %v", runner.hasDeadline, tt.wantDeadline)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"txt2geo/internal/util"
+	"txt2geo/pkg/logger"
+	"txt2geo/pkg/pathx"
+)
+
+// featureReport 是 --report 写出的逐文件要素统计。
+type featureReport struct {
+	Format   string         `json:"format"`
+	Files    int            `json:"files"`
+	Features int            `json:"features"`
+	PerFile  map[string]int `json:"per_file"` // 源文件路径 -> 导出要素数
+}
+
+// reportPerFile 以表格形式输出每个源文件的要素数，并在配置了 ReportPath 时写出 JSON 报告。
+// 要素数为 0 的文件（如被 --filter 全部过滤）同样列出，便于排查；逐文件行受 SummaryOnly 控制，汇总行始终输出。
+func (e *Exporter) reportPerFile(perFile map[string]int) error {
+	if len(perFile) == 0 {
+		return nil
+	}
+	paths := slices.SortedFunc(maps.Keys(perFile), pathx.ComparePaths)
+	total := 0
+	for _, n := range perFile {
+		total += n
+	}
+
+	logger.Log().Info("[统计] 逐文件要素数", "文件数", len(paths), "地块", total)
+	width := util.IntDigits(len(paths))
+	for i, path := range paths {
+		if perFile[path] == 0 {
+			e.logPerFile(slog.LevelWarn, fmt.Sprintf("  [%0*d]", width, i+1), "要素", 0, "文件", path)
+			continue
+		}
+		e.logPerFile(slog.LevelInfo, fmt.Sprintf("  [%0*d]", width, i+1), "要素", perFile[path], "文件", path)
+	}
+
+	if e.Config.ReportPath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(featureReport{
+		Format:   e.Config.FormatDetails.Code,
+		Files:    len(paths),
+		Features: total,
+		PerFile:  perFile,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化统计报告失败: %w", err)
+	}
+	if err := os.WriteFile(e.Config.ReportPath, data, 0o644); err != nil {
+		return fmt.Errorf("写入统计报告 %s 失败: %w", e.Config.ReportPath, err)
+	}
+	logger.Log().Info("[统计] 已写出统计报告", "路径", e.Config.ReportPath)
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// 单个输入文件的处理状态
+const (
+	ResultProcessed = "processed" // 预处理成功
+	ResultSkipped   = "skipped"   // 已处理过或内容重复而跳过
+	ResultFailed    = "failed"    // 解码/解析/几何处理失败
+	ResultEmpty     = "empty"     // 处理成功但没有有效地块
+)
+
+// FileResult 描述单个输入文件的处理结果，以 NDJSON 形式逐行写出，便于下游统计分析。
+type FileResult struct {
+	Path       string  `json:"path"`
+	Hash       string  `json:"hash,omitempty"`
+	Encoding   string  `json:"encoding,omitempty"`
+	Parcels    int     `json:"parcels"`
+	Features   int     `json:"features"`
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	ReadMs     float64 `json:"read_ms"`     // 读取与哈希耗时
+	DurationMs float64 `json:"duration_ms"` // 解码、解析与几何预处理耗时
+}
+
+// RejectRecord 描述一个被剔除的要素，写入 RejectsNDJSONPath 供人工复核。
+type RejectRecord struct {
+	SourcePath string         `json:"source_path"`
+	Reason     string         `json:"reason"`
+	WKT        string         `json:"wkt,omitempty"`
+	Properties map[string]any `json:"properties"`
+}
+
+// durationMs 将耗时转换为保留微秒精度的毫秒数。
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// ndjsonWriter 将记录逐条追加写入 NDJSON 文件。nil 接收者上的调用均为空操作。
+type ndjsonWriter struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// newNDJSONWriter 创建（截断）输出文件；path 为空时返回 nil，表示不输出。
+func newNDJSONWriter(path string) (*ndjsonWriter, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法创建文件 %s: %w", path, err)
+	}
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	return &ndjsonWriter{file: f, enc: enc}, nil
+}
+
+// Write 写入一条记录（Encoder 会自动追加换行）。
+func (w *ndjsonWriter) Write(r any) error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Encode(r)
+}
+
+// Close 关闭输出文件。
+func (w *ndjsonWriter) Close() error {
+	if w == nil {
+		return nil
+	}
+	return w.file.Close()
+}
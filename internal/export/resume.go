@@ -0,0 +1,47 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package export
+
+import (
+	"fmt"
+	"os"
+	"txt2geo/internal/process"
+	"txt2geo/pkg/logger"
+)
+
+// outputTarget 返回计划对应的输出位置，用于写入 Manifest。
+func (p ExportPlan) outputTarget(isContainer bool) process.OutputTarget {
+	if isContainer {
+		return process.OutputTarget{Path: p.OutputTarget, Layer: p.OutputName}
+	}
+	return process.OutputTarget{Path: p.displayTarget(false)}
+}
+
+// reconcileHistory 核对处理历史与磁盘上的实际输出：Manifest 中没有记录或输出已不存在的哈希
+// 会从历史与 Manifest 中移除，使对应文件在本次运行中被重新处理。
+// 容器格式只能检查容器本身是否存在，无法确认其中的图层。
+func (e *Exporter) reconcileHistory() error {
+	var missing []string
+	hashes := e.History.Hashes()
+	for _, hash := range hashes {
+		target, ok := e.Manifest.Lookup(hash)
+		if !ok {
+			logger.Log().Debug("[恢复] 历史记录无对应输出清单", "哈希", hash)
+			missing = append(missing, hash)
+			continue
+		}
+		if _, err := os.Stat(target.Path); err != nil {
+			logger.Log().Debug("[恢复] 输出不存在", "哈希", hash, "输出", target.Path, "原因", err)
+			missing = append(missing, hash)
+		}
+	}
+	if err := e.History.Remove(missing); err != nil {
+		return fmt.Errorf("更新处理历史失败: %w", err)
+	}
+	if err := e.Manifest.Remove(missing); err != nil {
+		return fmt.Errorf("更新输出清单失败: %w", err)
+	}
+	logger.Log().Info("[恢复] 处理历史核对完成", "历史记录", len(hashes), "输出缺失", len(missing), "保留", len(hashes)-len(missing))
+	return nil
+}
@@ -0,0 +1,67 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package export
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+	"txt2geo/internal/util"
+	"txt2geo/pkg/logger"
+)
+
+// fileTiming 记录单个输入文件各阶段的耗时。
+type fileTiming struct {
+	Path    string
+	Read    time.Duration // 读取与哈希
+	Process time.Duration // 解码、解析与几何预处理
+}
+
+// Total 返回各阶段耗时之和。
+func (t *fileTiming) Total() time.Duration {
+	return t.Read + t.Process
+}
+
+// timingFor 返回指定路径的耗时记录，不存在时创建。
+func (e *Exporter) timingFor(path string) *fileTiming {
+	if e.timings == nil {
+		e.timings = make(map[string]*fileTiming)
+	}
+	t, ok := e.timings[path]
+	if !ok {
+		t = &fileTiming{Path: path}
+		e.timings[path] = t
+	}
+	return t
+}
+
+// reportSlowest 按总耗时降序输出最慢的 SlowestFiles 个文件；未启用或无记录时不输出。
+func (e *Exporter) reportSlowest() {
+	n := e.Config.SlowestFiles
+	if n <= 0 || len(e.timings) == 0 {
+		return
+	}
+	list := make([]*fileTiming, 0, len(e.timings))
+	for _, t := range e.timings {
+		list = append(list, t)
+	}
+	slices.SortFunc(list, func(a, b *fileTiming) int {
+		if c := cmp.Compare(b.Total(), a.Total()); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Path, b.Path)
+	})
+	list = list[:min(n, len(list))]
+
+	logger.Log().Info("[耗时] 最慢的文件", "数量", len(list), "统计文件数", len(e.timings))
+	width := util.IntDigits(len(list))
+	for i, t := range list {
+		logger.Log().Info(fmt.Sprintf("  [%0*d]", width, i+1),
+			"文件", t.Path,
+			"总计", t.Total().Round(time.Microsecond),
+			"读取", t.Read.Round(time.Microsecond),
+			"处理", t.Process.Round(time.Microsecond))
+	}
+}
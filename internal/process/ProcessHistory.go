@@ -5,24 +5,104 @@ package process
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 	"txt2geo/pkg/logger"
 )
 
 // ProcessHistory 管理文件收集、内容读取（带哈希）以及已处理文件的记录（避免重复处理）。
+//
+// 记录文件每行一条：默认格式为 "hash<TAB>unixtime"，旧格式仅有 hash（记录时间未知）；
+// 启用 HistoryOptions.JSON 时写入 JSON 行，额外记录源文件路径与输出格式。读取时三种格式均可识别。
 type ProcessHistory struct {
 	processedFile string
-	processed     map[string]struct{}
+	processed     map[string]historyEntry
+	json          bool
 	mu            sync.RWMutex
 }
 
+// HistoryOptions 控制处理历史的记录格式。
+type HistoryOptions struct {
+	// JSON 为 true 时每行写入 {"hash":...,"path":...,"format":...,"time":...}，便于审计
+	JSON bool
+}
+
+// historyEntry 是单个哈希的记录信息。
+type historyEntry struct {
+	Time   time.Time // 记录时间；旧格式行为零值
+	Path   string    // 源文件路径（仅 JSON 格式记录）
+	Format string    // 输出格式（仅 JSON 格式记录）
+}
+
+// historyRecord 是 JSON 格式的单行记录。
+type historyRecord struct {
+	Hash   string `json:"hash"`
+	Path   string `json:"path,omitempty"`
+	Format string `json:"format,omitempty"`
+	Time   int64  `json:"time,omitempty"` // Unix 秒
+}
+
+// line 返回该记录在历史文件中的文本行；asJSON 为 true 时输出 JSON 行。
+func (h historyEntry) line(hash string, asJSON bool) string {
+	if asJSON {
+		rec := historyRecord{Hash: hash, Path: h.Path, Format: h.Format}
+		if !h.Time.IsZero() {
+			rec.Time = h.Time.Unix()
+		}
+		if data, err := json.Marshal(rec); err == nil {
+			return string(data)
+		}
+	}
+	if h.Time.IsZero() {
+		return hash
+	}
+	return hash + "\t" + strconv.FormatInt(h.Time.Unix(), 10)
+}
+
+// parseHistoryLine 解析历史文件中的一行，兼容 JSON 行与仅有哈希的旧格式；时间戳无法解析时视为未知。
+func parseHistoryLine(line string) (string, historyEntry) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		var rec historyRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			return "", historyEntry{}
+		}
+		entry := historyEntry{Path: rec.Path, Format: rec.Format}
+		if rec.Time > 0 {
+			entry.Time = time.Unix(rec.Time, 0)
+		}
+		return strings.TrimSpace(rec.Hash), entry
+	}
+	hash, ts, ok := strings.Cut(line, "\t")
+	hash = strings.TrimSpace(hash)
+	if !ok {
+		return hash, historyEntry{}
+	}
+	sec, err := strconv.ParseInt(strings.TrimSpace(ts), 10, 64)
+	if err != nil {
+		return hash, historyEntry{}
+	}
+	return hash, historyEntry{Time: time.Unix(sec, 0)}
+}
+
 // NewProcessHistory 创建一个 ProcessHistory 并尝试加载已处理文件记录。
 func NewProcessHistory(processedFile string) (*ProcessHistory, error) {
+	return NewProcessHistoryWithOptions(processedFile, HistoryOptions{})
+}
+
+// NewProcessHistoryWithOptions 与 NewProcessHistory 相同，但记录格式由 opts 指定。
+func NewProcessHistoryWithOptions(processedFile string, opts HistoryOptions) (*ProcessHistory, error) {
 	fm := &ProcessHistory{
 		processedFile: processedFile,
-		processed:     make(map[string]struct{}),
+		processed:     make(map[string]historyEntry),
+		json:          opts.JSON,
 	}
 
 	if processedFile == "" {
@@ -44,11 +124,16 @@ func NewProcessHistory(processedFile string) (*ProcessHistory, error) {
 // 返回值 isNew 为 true 表示这是一个新的哈希，文件应该被处理。
 // 返回值 isNew 为 false 表示哈希已存在（来自历史记录或本次运行），文件应被跳过。
 func (fm *ProcessHistory) CheckAndRecord(hash string) (isNew bool, err error) {
+	return fm.CheckAndRecordMeta(hash, "", "")
+}
+
+// CheckAndRecordMeta 与 CheckAndRecord 相同，同时记录源文件路径与输出格式（仅 JSON 格式写入文件）。
+func (fm *ProcessHistory) CheckAndRecordMeta(hash, path, format string) (isNew bool, err error) {
 	if hash == "" {
 		return false, nil
 	}
 
-	// 第一步：使用读锁快速检查。这是为了在大多数情况下（文件已处理）提高并发性能。
+	// 使用读锁快速检查。这是为了在大多数情况下（文件已处理）提高并发性能。
 	fm.mu.RLock()
 	_, exists := fm.processed[hash]
 	fm.mu.RUnlock()
@@ -56,35 +141,182 @@ func (fm *ProcessHistory) CheckAndRecord(hash string) (isNew bool, err error) {
 		return false, nil // 哈希已存在，直接返回。
 	}
 
-	// 第二步：如果不存在，则获取写锁准备写入。
+	added, err := fm.recordBatch([]pendingRecord{{hash: hash, entry: historyEntry{Path: path, Format: format}}})
+	if err != nil {
+		return false, err
+	}
+	return len(added) == 1, nil
+}
+
+// CheckAndRecordBatch 批量检查并记录哈希：只获取一次写锁，在内存中筛选出新哈希后一次性追加写入记录文件。
+// 返回按输入顺序排列的新哈希；批内重复的哈希只在首次出现时计为新记录。持久化结果与逐个调用 CheckAndRecord 相同。
+func (fm *ProcessHistory) CheckAndRecordBatch(hashes []string) (newHashes []string, err error) {
+	records := make([]pendingRecord, 0, len(hashes))
+	for _, h := range hashes {
+		if h != "" {
+			records = append(records, pendingRecord{hash: h})
+		}
+	}
+	return fm.recordBatch(records)
+}
+
+// pendingRecord 是等待写入的一条记录。
+type pendingRecord struct {
+	hash  string
+	entry historyEntry
+}
+
+// recordBatch 在写锁下筛选出尚未记录的哈希，以一次缓冲写入追加到记录文件，并标记到内存中。
+func (fm *ProcessHistory) recordBatch(records []pendingRecord) ([]string, error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+
 	fm.mu.Lock()
 	defer fm.mu.Unlock()
 
-	// 双重检查：在获取写锁后，必须再次检查哈希是否存在。
-	// 这是因为在读锁释放和写锁获取的间隙，可能有另一个goroutine已经写入了相同的哈希。
-	if _, exists := fm.processed[hash]; exists {
-		return false, nil
+	// 在写锁下重新检查：读锁释放后可能已有其他 goroutine 写入了相同的哈希。
+	now := time.Now()
+	fresh := make([]pendingRecord, 0, len(records))
+	seen := make(map[string]struct{}, len(records))
+	for _, r := range records {
+		if _, exists := fm.processed[r.hash]; exists {
+			continue
+		}
+		if _, dup := seen[r.hash]; dup {
+			continue
+		}
+		seen[r.hash] = struct{}{}
+		r.entry.Time = now
+		fresh = append(fresh, r)
+	}
+	if len(fresh) == 0 {
+		return nil, nil
 	}
 
 	// 记录到文件中（如果配置了文件路径）
 	if fm.processedFile != "" {
-		f, err := os.OpenFile(fm.processedFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
-		if err != nil {
-			return false, fmt.Errorf("无法打开 %s 进行写入: %w", fm.processedFile, err)
+		if err := fm.appendRecords(fresh); err != nil {
+			return nil, err
 		}
-		defer f.Close()
+	}
 
-		if _, err := f.WriteString(hash + "\n"); err != nil {
-			return false, fmt.Errorf("无法写入 %s: %w", fm.processedFile, err)
+	// 在内存中标记为已处理
+	newHashes := make([]string, 0, len(fresh))
+	for _, r := range fresh {
+		fm.processed[r.hash] = r.entry
+		newHashes = append(newHashes, r.hash)
+		logger.Log().Debug("记录新哈希", "hash", r.hash)
+	}
+	return newHashes, nil
+}
+
+// appendRecords 打开记录文件一次，将所有记录经缓冲后追加写入；调用方需持有写锁。
+// 文件末尾是崩溃时截断、没有换行结尾的行时先补上换行，避免新记录与之拼接成无效行。
+func (fm *ProcessHistory) appendRecords(records []pendingRecord) error {
+	f, err := os.OpenFile(fm.processedFile, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("无法打开 %s 进行写入: %w", fm.processedFile, err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			w.WriteByte('\n')
 		}
 	}
+	for _, r := range records {
+		w.WriteString(r.entry.line(r.hash, fm.json))
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("无法写入 %s: %w", fm.processedFile, err)
+	}
+	return nil
+}
 
-	// 在内存中标记为已处理
-	fm.processed[hash] = struct{}{}
-	logger.Log().Debug("记录新哈希", "hash", hash)
+// List 返回当前记录的所有哈希，按字典序排序。
+func (fm *ProcessHistory) List() []string {
+	hashes := fm.Hashes()
+	slices.Sort(hashes)
+	return hashes
+}
+
+// Clear 清空所有记录并截断记录文件；持有写锁，与并发的 CheckAndRecord 互斥。
+func (fm *ProcessHistory) Clear() error {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	clear(fm.processed)
+	if fm.processedFile == "" {
+		return nil
+	}
+	if err := os.Truncate(fm.processedFile, 0); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("无法清空 %s: %w", fm.processedFile, err)
+	}
+	return nil
+}
+
+// Hashes 返回当前记录的所有哈希（顺序不定）。
+func (fm *ProcessHistory) Hashes() []string {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	hashes := make([]string, 0, len(fm.processed))
+	for h := range fm.processed {
+		hashes = append(hashes, h)
+	}
+	return hashes
+}
+
+// Remove 从历史中删除给定哈希并重写记录文件，使对应文件在后续运行中被重新处理。
+func (fm *ProcessHistory) Remove(hashes []string) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	for _, h := range hashes {
+		delete(fm.processed, h)
+	}
+	return fm.rewrite()
+}
+
+// PruneOlderThan 删除记录时间早于 d 之前的哈希并重写记录文件，使这些文件在后续运行中被重新处理。
+// 旧格式（无时间戳）的记录无法判断新旧，予以保留。返回删除的数量。
+func (fm *ProcessHistory) PruneOlderThan(d time.Duration) (int, error) {
+	if d <= 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().Add(-d)
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
 
-	// 确认是新记录
-	return true, nil
+	pruned := 0
+	for h, entry := range fm.processed {
+		if !entry.Time.IsZero() && entry.Time.Before(cutoff) {
+			delete(fm.processed, h)
+			pruned++
+		}
+	}
+	if pruned == 0 {
+		return 0, nil
+	}
+	logger.Log().Debug("清理过期哈希", "file", fm.processedFile, "count", pruned)
+	return pruned, fm.rewrite()
+}
+
+// rewrite 用内存中的记录重写历史文件；调用方需持有写锁。
+func (fm *ProcessHistory) rewrite() error {
+	if fm.processedFile == "" {
+		return nil
+	}
+	lines := make([]string, 0, len(fm.processed))
+	for h, entry := range fm.processed {
+		lines = append(lines, entry.line(h, fm.json))
+	}
+	return rewriteLines(fm.processedFile, lines)
 }
 
 // loadProcessed 从文件中加载已处理的哈希。
@@ -105,11 +337,16 @@ func (fm *ProcessHistory) loadProcessed() error {
 	scanner := bufio.NewScanner(file)
 	var count int
 	for scanner.Scan() {
-		hash := scanner.Text()
+		hash, entry := parseHistoryLine(scanner.Text())
 		if hash == "" {
 			continue
 		}
-		fm.processed[hash] = struct{}{}
+		if !isSHA256Hex(hash) {
+			// 可能是崩溃时写了一半的行，不能匹配任何真实文件，跳过以免污染记录
+			logger.Log().Debug("跳过无效的历史记录行", "file", fm.processedFile, "line", scanner.Text())
+			continue
+		}
+		fm.processed[hash] = entry
 		count++
 	}
 	if err := scanner.Err(); err != nil {
@@ -118,3 +355,17 @@ func (fm *ProcessHistory) loadProcessed() error {
 	logger.Log().Debug("加载已处理哈希", "file", fm.processedFile, "count", count)
 	return nil
 }
+
+// isSHA256Hex 判断 s 是否为 64 位小写十六进制的 SHA-256 摘要。
+func isSHA256Hex(s string) bool {
+	if len(s) != 2*sha256.Size {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,335 @@
+package process
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testHashes 返回 n 个确定的 SHA-256 十六进制哈希。
+func testHashes(n int) []string {
+	hashes := make([]string, n)
+	for i := range hashes {
+		sum := sha256.Sum256(fmt.Appendf(nil, "file-%d", i))
+		hashes[i] = hex.EncodeToString(sum[:])
+	}
+	return hashes
+}
+
+// fileHashes 按行序返回记录文件中的哈希（忽略时间戳）。
+func fileHashes(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hashes []string
+	for line := range strings.Lines(string(data)) {
+		hash, _ := parseHistoryLine(strings.TrimSpace(line))
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+func TestCheckAndRecordBatchMatchesSingle(t *testing.T) {
+	hashes := testHashes(20)
+	existing := hashes[:5]
+	// 批内包含重复哈希、空哈希以及已记录的哈希
+	input := slices.Concat(hashes[3:12], []string{hashes[4], "", hashes[8]}, hashes[12:])
+
+	newHistory := func(name string) (*ProcessHistory, string) {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), name)
+		h, err := NewProcessHistory(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := h.CheckAndRecordBatch(existing); err != nil {
+			t.Fatal(err)
+		}
+		return h, path
+	}
+
+	single, singlePath := newHistory("single.processed")
+	var wantNew []string
+	for _, hash := range input {
+		isNew, err := single.CheckAndRecord(hash)
+		if err != nil {
+			t.Fatalf("CheckAndRecord: %v", err)
+		}
+		if isNew {
+			wantNew = append(wantNew, hash)
+		}
+	}
+
+	batch, batchPath := newHistory("batch.processed")
+	gotNew, err := batch.CheckAndRecordBatch(input)
+	if err != nil {
+		t.Fatalf("CheckAndRecordBatch: %v", err)
+	}
+	if !slices.Equal(gotNew, wantNew) {
+		t.Errorf("新哈希不一致:\n got %v\nwant %v", gotNew, wantNew)
+	}
+	if want := hashes[5:]; !slices.Equal(gotNew, want) {
+		t.Errorf("新哈希 = %d 个, want %d 个", len(gotNew), len(want))
+	}
+	if got, want := fileHashes(t, batchPath), fileHashes(t, singlePath); !slices.Equal(got, want) {
+		t.Errorf("记录文件内容不一致:\n got %v\nwant %v", got, want)
+	}
+
+	// 重新加载后两者的记录相同
+	reloaded, err := NewProcessHistory(batchPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := reloaded.List(), single.List(); !slices.Equal(got, want) {
+		t.Errorf("重新加载后的记录 = %v, want %v", got, want)
+	}
+}
+
+func BenchmarkCheckAndRecord(b *testing.B) {
+	hashes := testHashes(1000)
+	b.Run("逐个", func(b *testing.B) {
+		for b.Loop() {
+			h, err := NewProcessHistory(filepath.Join(b.TempDir(), "h.processed"))
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, hash := range hashes {
+				if _, err := h.CheckAndRecord(hash); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("批量", func(b *testing.B) {
+		for b.Loop() {
+			h, err := NewProcessHistory(filepath.Join(b.TempDir(), "h.processed"))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := h.CheckAndRecordBatch(hashes); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// writeHistory 将 lines 按行写入临时目录中的记录文件并返回其路径；trailingNewline 为 false 时最后一行不以换行结尾。
+func writeHistory(t *testing.T, lines []string, trailingNewline bool) string {
+	t.Helper()
+	content := strings.Join(lines, "\n")
+	if trailingNewline {
+		content += "\n"
+	}
+	path := filepath.Join(t.TempDir(), ".processed")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPruneOlderThanMixedFormats(t *testing.T) {
+	hashes := testHashes(6)
+	now := time.Now()
+	old, recent := now.Add(-30*24*time.Hour).Unix(), now.Add(-time.Hour).Unix()
+	path := writeHistory(t, []string{
+		hashes[0], // 旧格式：无时间戳，无法判断新旧，保留
+		fmt.Sprintf("%s\t%d", hashes[1], old),
+		fmt.Sprintf("%s\t%d", hashes[2], recent),
+		fmt.Sprintf(`{"hash":%q,"path":"a.txt","format":"GPKG","time":%d}`, hashes[3], old),
+		fmt.Sprintf(`{"hash":%q,"time":%d}`, hashes[4], recent),
+		fmt.Sprintf("%s\tnot-a-time", hashes[5]), // 时间戳无法解析视为未知，保留
+	}, true)
+
+	h, err := NewProcessHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(h.List()); got != 6 {
+		t.Fatalf("加载 %d 条记录, want 6", got)
+	}
+	pruned, err := h.PruneOlderThan(7 * 24 * time.Hour)
+	if err != nil {
+		t.Fatalf("PruneOlderThan: %v", err)
+	}
+	if pruned != 2 {
+		t.Errorf("pruned = %d, want 2", pruned)
+	}
+	want := slices.Sorted(slices.Values([]string{hashes[0], hashes[2], hashes[4], hashes[5]}))
+	if got := h.List(); !slices.Equal(got, want) {
+		t.Errorf("List = %v, want %v", got, want)
+	}
+
+	// 记录文件已重写，重新加载得到相同结果；旧格式行仍为旧格式
+	reloaded, err := NewProcessHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.List(); !slices.Equal(got, want) {
+		t.Errorf("重新加载后 List = %v, want %v", got, want)
+	}
+	if !slices.Contains(fileLines(t, path), hashes[0]) {
+		t.Errorf("旧格式行应原样保留: %v", fileLines(t, path))
+	}
+
+	if n, err := reloaded.PruneOlderThan(0); n != 0 || err != nil {
+		t.Errorf("PruneOlderThan(0) = %d, %v, want 0, nil", n, err)
+	}
+}
+
+// fileLines 返回记录文件中去除首尾空白后的非空行。
+func fileLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for line := range strings.Lines(string(data)) {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestCheckAndRecordMetaJSON(t *testing.T) {
+	hashes := testHashes(2)
+	path := filepath.Join(t.TempDir(), ".processed")
+	h, err := NewProcessHistoryWithOptions(path, HistoryOptions{JSON: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now().Add(-time.Second)
+	if isNew, err := h.CheckAndRecordMeta(hashes[0], `D:\data\a.txt`, "GPKG"); err != nil || !isNew {
+		t.Fatalf("CheckAndRecordMeta = %v, %v, want true, nil", isNew, err)
+	}
+	if isNew, err := h.CheckAndRecordMeta(hashes[0], "other.txt", "SHP"); err != nil || isNew {
+		t.Fatalf("重复记录 CheckAndRecordMeta = %v, %v, want false, nil", isNew, err)
+	}
+	if _, err := h.CheckAndRecord(hashes[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := fileLines(t, path)
+	if len(lines) != 2 {
+		t.Fatalf("记录文件 = %v, want 2 行", lines)
+	}
+	var rec historyRecord
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatalf("首行不是 JSON: %q", lines[0])
+	}
+	if rec.Hash != hashes[0] || rec.Path != `D:\data\a.txt` || rec.Format != "GPKG" || rec.Time < before.Unix() {
+		t.Errorf("JSON 记录 = %+v", rec)
+	}
+
+	// 追加一条旧格式行，重新加载混合文件
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := testHashes(3)[2]
+	fmt.Fprintln(f, legacy)
+	f.Close()
+
+	reloaded, err := NewProcessHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := reloaded.processed[hashes[0]]
+	if entry.Path != `D:\data\a.txt` || entry.Format != "GPKG" || entry.Time.Before(before) {
+		t.Errorf("重新加载的元数据 = %+v", entry)
+	}
+	if e, ok := reloaded.processed[legacy]; !ok || !e.Time.IsZero() {
+		t.Errorf("旧格式记录 = %+v, %v, want 零时间", e, ok)
+	}
+	if got := len(reloaded.List()); got != 3 {
+		t.Errorf("List 长度 = %d, want 3", got)
+	}
+}
+
+func TestListAndClear(t *testing.T) {
+	hashes := testHashes(5)
+	path := filepath.Join(t.TempDir(), ".processed")
+	h, err := NewProcessHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.CheckAndRecordBatch(hashes); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := h.List(), slices.Sorted(slices.Values(hashes)); !slices.Equal(got, want) {
+		t.Errorf("List = %v, want %v", got, want)
+	}
+
+	if err := h.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if got := h.List(); len(got) != 0 {
+		t.Errorf("Clear 后 List = %v, want empty", got)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Errorf("Clear 后记录文件大小 = %v (err %v), want 0", info.Size(), err)
+	}
+	reloaded, err := NewProcessHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.List(); len(got) != 0 {
+		t.Errorf("Clear 后重新加载 List = %v, want empty", got)
+	}
+	// 清空后同一哈希重新视为新记录
+	if isNew, err := h.CheckAndRecord(hashes[0]); err != nil || !isNew {
+		t.Errorf("Clear 后 CheckAndRecord = %v, %v, want true, nil", isNew, err)
+	}
+
+	// 记录文件不存在时 Clear 不报错
+	missing, err := NewProcessHistory(filepath.Join(t.TempDir(), "none.processed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := missing.Clear(); err != nil {
+		t.Errorf("Clear 不存在的记录文件: %v", err)
+	}
+}
+
+func TestLoadSkipsCorruptLines(t *testing.T) {
+	hashes := testHashes(3)
+	path := writeHistory(t, []string{
+		hashes[0],
+		"garbage line",
+		strings.ToUpper(hashes[1]), // 大写十六进制不是本工具写出的格式
+		fmt.Sprintf("%s\t%d", hashes[1], time.Now().Unix()),
+		`{"hash":"` + hashes[2][:10], // 写了一半的 JSON 行
+		"",
+		hashes[2][:40], // 崩溃时截断的最后一行，无换行结尾
+	}, false)
+
+	h, err := NewProcessHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := h.List(), slices.Sorted(slices.Values(hashes[:2])); !slices.Equal(got, want) {
+		t.Errorf("List = %v, want %v", got, want)
+	}
+	// 被截断的哈希对应的文件仍会被处理
+	if isNew, err := h.CheckAndRecord(hashes[2]); err != nil || !isNew {
+		t.Errorf("CheckAndRecord(截断的哈希) = %v, %v, want true, nil", isNew, err)
+	}
+	// 追加的记录不能与截断的最后一行拼接在一起
+	reloaded, err := NewProcessHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := reloaded.List(), slices.Sorted(slices.Values(hashes)); !slices.Equal(got, want) {
+		t.Errorf("重新加载后 List = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package process
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"txt2geo/pkg/logger"
+)
+
+// OutputTarget 描述一个源文件哈希实际写入的输出位置。
+type OutputTarget struct {
+	Path  string // 输出文件路径（容器格式为容器文件/目录路径）
+	Layer string // 容器格式下的图层名，非容器格式为空
+}
+
+// Manifest 记录每个已成功导出的源文件哈希与其输出位置的对应关系（追加写入，每行 hash\tpath\tlayer），
+// 用于在恢复运行时核对处理历史是否对应真实存在的输出。
+type Manifest struct {
+	manifestFile string
+	outputs      map[string]OutputTarget
+	mu           sync.RWMutex
+}
+
+// NewManifest 创建一个 Manifest 并尝试加载已有记录；文件不存在时视为空清单。
+func NewManifest(manifestFile string) (*Manifest, error) {
+	m := &Manifest{
+		manifestFile: manifestFile,
+		outputs:      make(map[string]OutputTarget),
+	}
+	if manifestFile == "" {
+		return m, nil
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	logger.Log().Debug("Manifest 初始化完成", "file", manifestFile, "count", len(m.outputs))
+	return m, nil
+}
+
+// Lookup 返回哈希对应的输出位置。
+func (m *Manifest) Lookup(hash string) (OutputTarget, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t, ok := m.outputs[hash]
+	return t, ok
+}
+
+// Record 记录哈希对应的输出位置；同一哈希的后写记录覆盖先前记录。
+func (m *Manifest) Record(hash string, target OutputTarget) error {
+	if hash == "" {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.manifestFile != "" {
+		f, err := os.OpenFile(m.manifestFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("无法打开 %s 进行写入: %w", m.manifestFile, err)
+		}
+		defer f.Close()
+
+		if _, err := f.WriteString(hash + "\t" + target.Path + "\t" + target.Layer + "\n"); err != nil {
+			return fmt.Errorf("无法写入 %s: %w", m.manifestFile, err)
+		}
+	}
+	m.outputs[hash] = target
+	return nil
+}
+
+// Remove 删除给定哈希的记录并重写清单文件。
+func (m *Manifest) Remove(hashes []string) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, h := range hashes {
+		delete(m.outputs, h)
+	}
+	if m.manifestFile == "" {
+		return nil
+	}
+	lines := make([]string, 0, len(m.outputs))
+	for h, t := range m.outputs {
+		lines = append(lines, h+"\t"+t.Path+"\t"+t.Layer)
+	}
+	return rewriteLines(m.manifestFile, lines)
+}
+
+// load 从文件中加载清单记录，格式不正确的行将被忽略。
+func (m *Manifest) load() error {
+	file, err := os.Open(m.manifestFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("无法打开 %s: %w", m.manifestFile, err)
+	}
+	defer file.Close()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		parts := strings.Split(scanner.Text(), "\t")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+			continue
+		}
+		m.outputs[parts[0]] = OutputTarget{Path: parts[1], Layer: parts[2]}
+	}
+	return scanner.Err()
+}
+
+// rewriteLines 先写入临时文件再替换目标文件，避免中断时留下不完整的记录。
+func rewriteLines(path string, lines []string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("无法创建 %s: %w", tmp, err)
+	}
+	w := bufio.NewWriter(f)
+	for _, line := range lines {
+		w.WriteString(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("无法写入 %s: %w", tmp, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("无法写入 %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("无法替换 %s: %w", path, err)
+	}
+	return nil
+}
@@ -24,6 +24,7 @@ from qgis.core import (
     QgsGeometry,
     QgsProject,
     QgsVectorFileWriter,
+    QgsVectorLayer,
     QgsWkbTypes,
 )
 from qgis.PyQt.QtCore import QMetaType
@@ -89,6 +90,22 @@ FIELD_MAPPING: dict[str, list[str]] = {
     "DLBM": ["code"],
     "WJLJ": ["source_path"],
 }
+# 由几何计算的可选度量字段，仅当要素属性中出现对应键时才创建 (与 Go 端 domain.KeyComputed* 保持一致)
+COMPUTED_FIELD_DEFINITIONS: list[FieldDef] = [
+    FieldDef(
+        "JSMJ", QMetaType.Type.Double, "计算面积", "由几何计算的面积(平方米)", length=32, precision=6
+    ),
+    FieldDef(
+        "JSZC", QMetaType.Type.Double, "计算周长", "由几何计算的周长(米)", length=32, precision=6
+    ),
+]
+COMPUTED_FIELD_MAPPING: dict[str, str] = {
+    "JSMJ": "computed_area",
+    "JSZC": "computed_perimeter",
+}
+# 起始行中超出已知字段的额外字段键名前缀 (与 Go 端 domain.ExtraAttrPrefix 保持一致)
+EXTRA_PREFIX = "extra_"
+
 # region --- 数据模型 ---
 # 使用 dataclasses 将输入的 JSON 结构化为 Python 对象
 # 不可轻易修改的数据结构
@@ -98,6 +115,7 @@ class Feature:
 
     properties: dict
     wkt: str
+    bbox: list[float] | None = None  # 外包矩形 [minX, minY, maxX, maxY]，与 WKT 坐标顺序一致
 
 
 @dataclass
@@ -122,6 +140,11 @@ class ExportPayload:
     output_dir: str
     overwrite: bool
     target_crs: str
+    is_container: bool = False
+    dataset_options: list[str] | None = None
+    append: bool = False
+    encoding: str = "UTF-8"  # 属性文本编码，Shapefile 以此写入 DBF 代码页
+    creation_options: dict[str, str] | None = None  # 用户指定的 GDAL 图层创建选项 (--co)，覆盖同名默认选项
 
     @classmethod
     def from_dict(cls, data: dict) -> ExportPayload:
@@ -149,7 +172,10 @@ class GeoProcessor:
         初始化处理器，接收一个 ExportPayload 对象作为任务配置。
         """
         self.payload = payload
-        self.fields: QgsFields = self._build_fields()
+        self.extra_keys: list[str] = self._collect_extra_keys(payload)
+        self.computed_fields: list[FieldDef] = self._collect_computed_fields(payload)
+        self.custom_keys: list[str] = self._collect_custom_keys(payload)
+        self.fields: QgsFields = self._build_fields(self.computed_fields, self.extra_keys, self.custom_keys)
         self.crs_cache: dict[str, QgsCoordinateReferenceSystem] = {}
         self.transform_cache: dict[tuple, QgsCoordinateTransform] = {}
         self.default_crs: QgsCoordinateReferenceSystem = self._build_crs("EPSG:4526")
@@ -157,11 +183,56 @@ class GeoProcessor:
         logging.info("GeoProcessor 初始化完成，任务负载已加载。")
 
     @staticmethod
-    def _build_fields() -> QgsFields:
-        """根据 FIELD_DEFINITIONS 构建 QgsFields 对象"""
+    def _collect_extra_keys(payload: ExportPayload) -> list[str]:
+        """收集所有要素中出现的额外字段键 (extra_1, extra_2 ...)，按序号排序"""
+        count = 0
+        for dataset in payload.datasets:
+            for feature in dataset.features:
+                for key in feature.properties or {}:
+                    suffix = key[len(EXTRA_PREFIX):] if key.startswith(EXTRA_PREFIX) else ""
+                    if suffix.isdigit():
+                        count = max(count, int(suffix))
+        return [f"{EXTRA_PREFIX}{i}" for i in range(1, count + 1)]
+
+    @staticmethod
+    def _collect_computed_fields(payload: ExportPayload) -> list[FieldDef]:
+        """收集要素属性中出现的计算度量字段，保持 COMPUTED_FIELD_DEFINITIONS 的顺序"""
+        present: set[str] = set()
+        for dataset in payload.datasets:
+            for feature in dataset.features:
+                present.update(feature.properties or {})
+        return [f for f in COMPUTED_FIELD_DEFINITIONS if COMPUTED_FIELD_MAPPING[f.name] in present]
+
+    @staticmethod
+    def _collect_custom_keys(payload: ExportPayload) -> list[str]:
+        """收集经字段映射 (--field) 重命名后的自定义属性键，即不属于任何已知字段来源的键，按名称排序"""
+        known = {key for keys in FIELD_MAPPING.values() for key in keys}
+        known.update(COMPUTED_FIELD_MAPPING.values())
+        custom: set[str] = set()
+        for dataset in payload.datasets:
+            for feature in dataset.features:
+                for key in feature.properties or {}:
+                    suffix = key[len(EXTRA_PREFIX):] if key.startswith(EXTRA_PREFIX) else ""
+                    if key not in known and not suffix.isdigit():
+                        custom.add(key)
+        return sorted(custom)
+
+    @staticmethod
+    def _build_fields(computed_fields: list[FieldDef], extra_keys: list[str], custom_keys: list[str]) -> QgsFields:
+        """根据 FIELD_DEFINITIONS、计算度量字段、额外字段及自定义字段构建 QgsFields 对象"""
         fields = QgsFields()
         for f_def in FIELD_DEFINITIONS:
             fields.append(f_def.to_qgs_field())
+        for f_def in computed_fields:
+            fields.append(f_def.to_qgs_field())
+        for key in extra_keys:
+            fields.append(
+                FieldDef(key.upper(), QMetaType.Type.QString, "", "额外字段", length=254).to_qgs_field()
+            )
+        for key in custom_keys:
+            fields.append(
+                FieldDef(key, QMetaType.Type.QString, "", "自定义字段", length=254).to_qgs_field()
+            )
         return fields
 
     def _extract_attributes(self, props: dict) -> list[any]:
@@ -191,6 +262,16 @@ class GeoProcessor:
                         break
             attributes.append(found_value)
 
+        for field_def in self.computed_fields:
+            attributes.append(props.get(COMPUTED_FIELD_MAPPING[field_def.name]))
+
+        for key in self.extra_keys:
+            attributes.append(props.get(key))
+
+        for key in self.custom_keys:
+            value = props.get(key)
+            attributes.append(None if value is None else str(value))
+
         return attributes
 
     def _build_crs(self, def_crs: str) -> QgsCoordinateReferenceSystem:
@@ -272,7 +353,7 @@ class GeoProcessor:
         overwrite = self.payload.overwrite
 
         # 确定是容器格式 (如 GPKG) 还是单文件格式 (如 Shapefile)
-        is_container = driver_name.upper() in {"GPKG", "OPENFILEGDB"}
+        is_container = self.payload.is_container or driver_name.upper() in {"GPKG", "OPENFILEGDB", "SQLITE"}
 
         if is_container:
             target_path = output_dir
@@ -283,20 +364,44 @@ class GeoProcessor:
 
         # 确定文件存在时的操作
         action = QgsVectorFileWriter.CreateOrOverwriteFile
-        if target_path.exists() and overwrite:
+        if target_path.exists() and self.payload.append:
+            # 追加模式：目标图层已存在时追加要素 (新增字段随之添加)，否则在容器中新建图层
+            if self._layer_exists(target_path, layer_name, is_container):
+                action = QgsVectorFileWriter.AppendToLayerAddFields
+            elif is_container:
+                action = QgsVectorFileWriter.CreateOrOverwriteLayer
+        elif target_path.exists() and overwrite:
             action = QgsVectorFileWriter.CreateOrOverwriteLayer if is_container else QgsVectorFileWriter.CreateOrOverwriteFile
         
         # 创建并配置保存选项
         save_opts = QgsVectorFileWriter.SaveVectorOptions()
         save_opts.driverName = driver_name
         save_opts.layerName = layer_name
-        save_opts.fileEncoding = "UTF-8"
+        save_opts.fileEncoding = self.payload.encoding or "UTF-8"
         save_opts.actionOnExistingFile = action
+        if self.payload.dataset_options:
+            save_opts.datasetOptions = list(self.payload.dataset_options)
+        layer_options: dict[str, str] = {}
         if driver_name.upper() != "OPENFILEGDB":
-            save_opts.layerOptions = ["SPATIAL_INDEX=YES"]
+            layer_options["SPATIAL_INDEX"] = "YES"
+        if driver_name.upper() == "ESRI SHAPEFILE":
+            layer_options["ENCODING"] = save_opts.fileEncoding
+        for key, value in (self.payload.creation_options or {}).items():
+            layer_options[key.upper()] = value
+        if layer_options:
+            save_opts.layerOptions = [f"{k}={v}" for k, v in layer_options.items()]
 
         return save_opts, target_path.as_posix(), display_path
 
+    @staticmethod
+    def _layer_exists(target_path: Path, layer_name: str, is_container: bool) -> bool:
+        """检查目标数据源中是否已存在指定图层"""
+        uri = target_path.as_posix()
+        if is_container:
+            uri = f"{uri}|layername={layer_name}"
+        layer = QgsVectorLayer(uri, layer_name, "ogr")
+        return layer.isValid()
+
     def _write_features(self, features: list[QgsFeature], dest_crs: QgsCoordinateReferenceSystem)-> bool:
         """
         将要素列表写入矢量文件。
@@ -304,10 +409,31 @@ class GeoProcessor:
         save_opts, target_path_str, display_path = self._prepare_save_options()
         transform_context = QgsProject.instance().transformContext()
 
+        # 几何类型取自首个有几何的要素，以便保留 M 等附加维度
+        wkb_type = QgsWkbTypes.Polygon
+        for feature in features:
+            if feature.hasGeometry():
+                wkb_type = feature.geometry().wkbType()
+                break
+
+        # 点/线/面混合时无法使用单一几何类型，交由驱动处理 (Shapefile 等不支持混合几何的格式会报错)
+        geom_types = {f.geometry().type() for f in features if f.hasGeometry()}
+        if len(geom_types) > 1:
+            logging.warning("图层 '%s' 包含点/线/面混合几何，将以未知几何类型写出", self.current_dataset.layer_name)
+            wkb_type = QgsWkbTypes.Unknown
+        # 含多个部件的地块 (如 MULTIPOLYGON) 使整个图层提升为多部件类型，单部件几何随之转换
+        elif any(f.hasGeometry() and f.geometry().isMultipart() for f in features):
+            wkb_type = QgsWkbTypes.multiType(wkb_type)
+            for feature in features:
+                if feature.hasGeometry() and not feature.geometry().isMultipart():
+                    geom = feature.geometry()
+                    geom.convertToMultiType()
+                    feature.setGeometry(geom)
+
         writer = QgsVectorFileWriter.create(
             target_path_str,
             self.fields,
-            QgsWkbTypes.Polygon,
+            wkb_type,
             dest_crs,
             transform_context,
             save_opts,
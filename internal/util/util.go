@@ -26,14 +26,44 @@ func IntDigits(n int) int {
 	return count
 }
 
+// HumanBytes 以二进制单位 (1024) 格式化字节数并保留一位小数，如 1048576 -> "1.0 MiB"；不足 1 KiB 时输出 "512 B"。
+// 保留一位小数后会进位到 1024 的值使用更大的单位，如 1048575 -> "1.0 MiB" 而不是 "1024.0 KiB"。
+func HumanBytes(n int64) string {
+	const unit = 1024
+	if n > -unit && n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	const carry = unit - 0.05 // 不小于该值时 "%.1f" 会输出 1024.0
+	v := float64(n)
+	units := "KMGTPE"
+	i := -1
+	for (v <= -carry || v >= carry) && i < len(units)-1 {
+		v /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %ciB", v, units[i])
+}
+
+// RandomString 生成 n 个字母数字组成的随机字符串，各字符等概率出现。
+// 随机字节不小于 62 的最大倍数 (248) 时丢弃重采，避免直接取模造成的偏差。
 func RandomString(n int) string {
-	letters := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, n)
-	_, _ = rand.Read(b)
-	for i := range n {
-		b[i] = letters[int(b[i])%len(letters)]
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	const limit = 256 - 256%len(letters)
+	out := make([]byte, 0, n)
+	buf := make([]byte, n+n/8+1) // 约 3% 的字节会被丢弃，略多读取以减少重采次数
+	for len(out) < n {
+		_, _ = rand.Read(buf)
+		for _, c := range buf {
+			if int(c) >= limit {
+				continue
+			}
+			out = append(out, letters[int(c)%len(letters)])
+			if len(out) == n {
+				break
+			}
+		}
 	}
-	return string(b)
+	return string(out)
 }
 
 func GetUUIDv4() (string, error) {
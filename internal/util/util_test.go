@@ -0,0 +1,72 @@
+package util
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestRandomStringUniform(t *testing.T) {
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	const perLetter = 1000
+	s := RandomString(len(letters) * perLetter)
+	if len(s) != len(letters)*perLetter {
+		t.Fatalf("len = %d, want %d", len(s), len(letters)*perLetter)
+	}
+	counts := make(map[rune]int, len(letters))
+	for _, r := range s {
+		if !strings.ContainsRune(letters, r) {
+			t.Fatalf("非法字符 %q", r)
+		}
+		counts[r]++
+	}
+
+	// 卡方检验：自由度 61，p=0.0001 的临界值约为 110；取模偏差（前 8 个字符概率为 5/256）会使统计量达到 400 左右
+	var chi2 float64
+	for _, r := range letters {
+		d := float64(counts[r] - perLetter)
+		chi2 += d * d / perLetter
+	}
+	if chi2 > 120 {
+		t.Errorf("卡方统计量 = %.1f，分布明显不均匀: %v", chi2, counts)
+	}
+}
+
+func TestRandomStringLength(t *testing.T) {
+	for _, n := range []int{0, 1, 8, 100} {
+		if got := RandomString(n); len(got) != n {
+			t.Errorf("len(RandomString(%d)) = %d", n, len(got))
+		}
+	}
+}
+
+func TestHumanBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1, "1 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{512 << 10, "512.0 KiB"},
+		{1<<20 - 1, "1.0 MiB"},
+		{1 << 20, "1.0 MiB"},
+		{1<<30 - 1, "1.0 GiB"},
+		{5 << 30, "5.0 GiB"},
+		{1 << 40, "1.0 TiB"},
+		{1 << 50, "1.0 PiB"},
+		{1 << 60, "1.0 EiB"},
+		{math.MaxInt64, "8.0 EiB"},
+		{-1023, "-1023 B"},
+		{-1024, "-1.0 KiB"},
+		{-(1 << 20), "-1.0 MiB"},
+		{math.MinInt64, "-8.0 EiB"},
+	}
+	for _, tt := range tests {
+		if got := HumanBytes(tt.n); got != tt.want {
+			t.Errorf("HumanBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
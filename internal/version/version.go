@@ -53,6 +53,7 @@ func GetAbout() string {
       · FlatGeobuf (FGB)
       · GeoPackage (GPKG)
       · OpenFileGDB (GDB)
+      · SpatiaLite (SPATIALITE)
    - 自动纠错: 智能识别并修正常见数据格式问题
    - 用户友好: 简洁命令行界面, 易于上手
    - 高性能: 优化的转换算法, 处理大规模数据毫不费力
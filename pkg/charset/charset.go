@@ -8,6 +8,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"strings"
 	"unicode/utf8"
 
 	"golang.org/x/text/encoding/simplifiedchinese"
@@ -22,7 +24,12 @@ import (
 //
 // 公开函数：
 //   Detect(data) -> 粗略检测编码标识；
-//   Decode(data)  -> 返回 UTF-8 文本及原编码标识，并在必要时返回警告错误。
+//   DetectDetailed(data) -> 检测编码并给出置信度与命中的启发式规则；
+//   Decode(data)  -> 返回 UTF-8 文本及原编码标识，并在必要时返回警告错误；
+//   DecodeWith(data, enc) -> 跳过探测，按指定编码解码；
+//   DecodeClean(data) -> 同 Decode，并移除零宽字符及文本中间的 BOM；
+//   StripInvisible(s) -> 移除零宽字符及 BOM，返回移除数量；
+//   LineEndingStats(data) -> 统计 CRLF/LF/CR 三种换行符的数量。
 //
 // 注意：探测是启发式的，极端短样本或混合编码内容可能仍得到 Unknown。
 // 调用方如需更强能力，可在 Unknown 分支再接入外部库。
@@ -37,62 +44,146 @@ const (
 	EncodingUnknown = "unknown"
 )
 
+// Detector 持有编码探测的启发式阈值。所有字段均为 [0,1] 区间内的比例，
+// 超出区间的值在探测时回退为默认值。请使用 NewDetector 获取默认配置后再按需调整。
+type Detector struct {
+	// 无 BOM UTF-16 零字节分布：一侧零字节比例高于 ZeroHighRatio 且另一侧低于 ZeroLowRatio 视为候选
+	ZeroHighRatio float64
+	ZeroLowRatio  float64
+	// 纯中文 UTF-16：一侧高字节落在 CJK 常用区的比例 ≥ CJKHighByteRatio 且另一侧 < CJKOppositeMaxRatio 视为候选
+	CJKHighByteRatio    float64
+	CJKOppositeMaxRatio float64
+	// UTF-16 解码质量下限
+	MinPrintableRatio float64 // 可打印字符占比下限
+	MaxControlRatio   float64 // 控制字符占比上限
+	MaxWeirdRatio     float64 // 非字符/孤立代理占比上限
+	MinTextRatio      float64 // 强制双向解码时 ASCII 或 CJK 任一占比下限
+	// UTF-16 与 GB18030 竞争时的判定
+	MinUTF16Score    float64 // UTF-16 综合分低于此值时让位于可严格解码的 GB18030
+	MaxZeroByteRatio float64 // 零字节总占比不超过此值才视为“几乎无零字节”
+	MinGBPairRatio   float64 // GB18030 合法双字节对比例下限
+	MaxASCIIRunRatio float64 // 最长 ASCII 连续段占比上限
+}
+
+// NewDetector 返回使用默认阈值的探测器，其结果与包级 Detect 完全一致。
+func NewDetector() *Detector {
+	return &Detector{
+		ZeroHighRatio:       0.30,
+		ZeroLowRatio:        0.05,
+		CJKHighByteRatio:    0.75,
+		CJKOppositeMaxRatio: 0.60,
+		MinPrintableRatio:   0.80,
+		MaxControlRatio:     0.05,
+		MaxWeirdRatio:       0.02,
+		MinTextRatio:        0.05,
+		MinUTF16Score:       0.90,
+		MaxZeroByteRatio:    0.01,
+		MinGBPairRatio:      0.28,
+		MaxASCIIRunRatio:    0.40,
+	}
+}
+
+// defaultDetector 供包级 Detect 使用。
+var defaultDetector = NewDetector()
+
+// normalized 返回一份阈值副本，超出 [0,1] 的字段回退为默认值。
+func (d *Detector) normalized() Detector {
+	def := NewDetector()
+	if d == nil {
+		return *def
+	}
+	out := *d
+	fix := func(v *float64, fallback float64) {
+		if *v < 0 || *v > 1 || math.IsNaN(*v) {
+			*v = fallback
+		}
+	}
+	fix(&out.ZeroHighRatio, def.ZeroHighRatio)
+	fix(&out.ZeroLowRatio, def.ZeroLowRatio)
+	fix(&out.CJKHighByteRatio, def.CJKHighByteRatio)
+	fix(&out.CJKOppositeMaxRatio, def.CJKOppositeMaxRatio)
+	fix(&out.MinPrintableRatio, def.MinPrintableRatio)
+	fix(&out.MaxControlRatio, def.MaxControlRatio)
+	fix(&out.MaxWeirdRatio, def.MaxWeirdRatio)
+	fix(&out.MinTextRatio, def.MinTextRatio)
+	fix(&out.MinUTF16Score, def.MinUTF16Score)
+	fix(&out.MaxZeroByteRatio, def.MaxZeroByteRatio)
+	fix(&out.MinGBPairRatio, def.MinGBPairRatio)
+	fix(&out.MaxASCIIRunRatio, def.MaxASCIIRunRatio)
+	return out
+}
+
 // Detect 通过 BOM、UTF-8/UTF-16/GB18030 的字节模式与启发式规则检测给定字节切片的编码。
 // 支持的编码包括：utf-8-sig, utf-8, utf-16-le, utf-16-be, gb18030。
 // 若无法确定，返回 EncodingUnknown。空数据视作 UTF-8。
+// 等价于使用 NewDetector() 的默认阈值进行探测。
 func Detect(data []byte) string {
+	return defaultDetector.Detect(data)
+}
+
+// DetectDetailed 与 Detect 相同，但额外返回 0~1 的置信度及触发的启发式规则
+// （如 "bom"、"utf8-strict"、"utf16-le-cjk-pattern"、"gb18030-strict"）。
+// BOM 命中的置信度为 1.0，无法判定时为 0。
+func DetectDetailed(data []byte) (encoding string, confidence float64, reasons []string) {
+	return defaultDetector.DetectDetailed(data)
+}
+
+// Detect 使用探测器的阈值检测编码，规则同包级 Detect。
+func (d *Detector) Detect(data []byte) string {
+	enc, _, _ := d.DetectDetailed(data)
+	return enc
+}
+
+// DetectDetailed 使用探测器的阈值检测编码，规则同包级 DetectDetailed。
+func (d *Detector) DetectDetailed(data []byte) (string, float64, []string) {
+	cfg := d.normalized()
 	if len(data) == 0 {
-		return EncodingUTF8 // treat empty as utf-8
+		return EncodingUTF8, 1.0, []string{"empty"} // treat empty as utf-8
 	}
 
 	// 1. BOM detection
 	if bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}) {
-		return EncodingUTF8BOM
+		return EncodingUTF8BOM, 1.0, []string{"bom"}
 	}
 	if len(data) >= 2 {
 		if data[0] == 0xFF && data[1] == 0xFE { // LE BOM
-			return EncodingUTF16LE
+			return EncodingUTF16LE, 1.0, []string{"bom"}
 		}
 		if data[0] == 0xFE && data[1] == 0xFF { // BE BOM
-			return EncodingUTF16BE
+			return EncodingUTF16BE, 1.0, []string{"bom"}
 		}
 	}
 
-	// Early binary noise exclusion: if many zero bytes but not plausible UTF-16 layout
-	zeroBytes := 0
-	for _, b := range data {
-		if b == 0 {
-			zeroBytes++
-		}
-	}
-	if zeroBytes > len(data)/2 {
-		// fallthrough but we will rely on distribution checks
-	}
-
 	// 2. Manual UTF-8 validation (tolerate truncated final sequence)
 	if validUTF8StrictOrTrunc(data) {
-		return EncodingUTF8
+		if utf8.Valid(data) {
+			return EncodingUTF8, 1.0, []string{"utf8-strict"}
+		}
+		// 仅末尾多字节序列被截断
+		return EncodingUTF8, 0.9, []string{"utf8-truncated-tail"}
 	}
 
 	// 3. Try UTF-16 without BOM
-	utf16Guess := guessUTF16(data)
-	if utf16Guess != "" {
-		return utf16Guess
+	if enc, score, reasons := cfg.guessUTF16(data); enc != "" {
+		return enc, score, reasons
 	}
 
 	// 4. Fallback: attempt GB18030 strict decode
 	if isGB18030(data) {
-		return EncodingGB18030
+		pairRatio, _ := gb18030PatternConfidence(data)
+		return EncodingGB18030, gb18030Confidence(pairRatio), []string{"gb18030-strict"}
 	}
 
-	return EncodingUnknown
+	return EncodingUnknown, 0, nil
 }
 
 // guessUTF16 尝试通过零字节分布、高字节模式及解码评估分数来探测无 BOM 的 UTF-16 编码。
 // 这是一个内部辅助函数，具有较高的防误判门槛。
-func guessUTF16(data []byte) string {
+// 返回：(编码, 置信度, 触发的启发式规则)；编码为空表示放弃判断。
+// 若判定过程中确认为 GB18030，也会直接返回 EncodingGB18030。
+func (d Detector) guessUTF16(data []byte) (string, float64, []string) {
 	if len(data) < 4 { // 太短不判断无 BOM UTF-16
-		return ""
+		return "", 0, nil
 	}
 
 	// 旧的零字节分布启发：仍保留，用于快速高置信度路径
@@ -108,21 +199,24 @@ func guessUTF16(data []byte) string {
 	}
 	half := len(data) / 2
 	if half == 0 {
-		return ""
+		return "", 0, nil
 	}
 	evenRatio := float64(evenZeros) / float64(half)
 	oddRatio := float64(oddZeros) / float64(half)
 
-	const high = 0.30
-	const low = 0.05
+	high := d.ZeroHighRatio
+	low := d.ZeroLowRatio
 
 	forceDecode := false
 	leCandidate, beCandidate := false, false
+	var reasons []string
 	if oddRatio > high && evenRatio < low {
 		leCandidate = true
+		reasons = append(reasons, "utf16-le-zero-pattern")
 	}
 	if evenRatio > high && oddRatio < low {
 		beCandidate = true
+		reasons = append(reasons, "utf16-be-zero-pattern")
 	}
 
 	// 短中文文本（纯 CJK）零字节往往很少：通过“高字节落在 CJK 常用区”模式识别
@@ -142,12 +236,14 @@ func guessUTF16(data []byte) string {
 		leHighRatio := float64(leHigh) / float64(halfBytes)
 		beHighRatio := float64(beHigh) / float64(halfBytes)
 		// 典型 UTF-16LE 中文：奇数位(高字节)集中在 0x4E~0x9F；BE 则偶数位集中。
-		// 设阈值：一侧 ≥0.75 且另一侧 <0.60 作为显著指示。
-		if leHighRatio >= 0.75 && beHighRatio < 0.60 {
+		// 阈值（默认）：一侧 ≥0.75 且另一侧 <0.60 作为显著指示，见 Detector.CJKHighByteRatio。
+		if leHighRatio >= d.CJKHighByteRatio && beHighRatio < d.CJKOppositeMaxRatio {
 			leCandidate = true
+			reasons = append(reasons, "utf16-le-cjk-pattern")
 		}
-		if beHighRatio >= 0.75 && leHighRatio < 0.60 {
+		if beHighRatio >= d.CJKHighByteRatio && leHighRatio < d.CJKOppositeMaxRatio {
 			beCandidate = true
+			reasons = append(reasons, "utf16-be-cjk-pattern")
 		}
 	}
 
@@ -155,21 +251,20 @@ func guessUTF16(data []byte) string {
 	if !leCandidate && !beCandidate && len(data) >= 8 && len(data)%2 == 0 {
 		forceDecode = true
 		leCandidate, beCandidate = true, true
+		reasons = append(reasons, "utf16-forced-decode")
 	}
 
 	if !leCandidate && !beCandidate {
-		return ""
+		return "", 0, nil
 	}
 
 	leEval := evaluateUTF16(data, true)
 	beEval := evaluateUTF16(data, false)
 
 	// 最低可接受条件（防止把随机二进制当作 UTF-16）
-	const (
-		minPrintableRatio = 0.80 // 可打印+中文占比
-		maxControlRatio   = 0.05
-		maxWeirdRatio     = 0.02 // 非字符/孤立代理
-	)
+	minPrintableRatio := d.MinPrintableRatio // 可打印+中文占比
+	maxControlRatio := d.MaxControlRatio
+	maxWeirdRatio := d.MaxWeirdRatio // 非字符/孤立代理
 
 	pick := func(ev utf16Eval, encoding string) string {
 		if !ev.validStructure {
@@ -187,7 +282,7 @@ func guessUTF16(data []byte) string {
 		// 在强制双分支模式下，为避免与 GB18030 竞争，再加一个区分度：
 		// UTF-16 典型模式：ASCII+中文覆盖率较高且单字节分布不呈现典型 GB18030 的高频双字节范围。
 		// 粗略用 ascii 或 中文任一占比 > 5% 来增强可信度。
-		if forceDecode && ev.asciiRatio < 0.05 && ev.cjkRatio < 0.05 {
+		if forceDecode && ev.asciiRatio < d.MinTextRatio && ev.cjkRatio < d.MinTextRatio {
 			return ""
 		}
 		// 额外短样本防误判：针对短 (<24 字节) 且无零字节分布、纯 UTF-8 中文被拆成伪 UTF-16 的情况
@@ -213,52 +308,57 @@ func guessUTF16(data []byte) string {
 	gbPairRatio, asciiRunRatio := gb18030PatternConfidence(data)
 
 	// 新增第一道拦截：若 UTF-16 两端候选均为低分、且 GB18030 有较高双字节合法对比例，则优先 GB18030
-	// 条件：
+	// 条件（数值为默认阈值，见 Detector）：
 	//   1. (leOk 或 beOk 存在) 且其 compositeScore < 0.90
 	//   2. 零字节总数极低（≤1%）
 	//   3. gbPairRatio ≥ 0.28 （经验阈值）
 	//   4. asciiRunRatio < 0.40 （避免把大量 ASCII + 少量高字节当 GB）
-	lowZero := float64(evenZeros+oddZeros)/float64(len(data)) <= 0.01
-	utf16LowScore := (leOk != "" && leEval.compositeScore < 0.90) || (beOk != "" && beEval.compositeScore < 0.90)
-	if utf16LowScore && lowZero && gbPairRatio >= 0.28 && asciiRunRatio < 0.40 {
+	lowZero := float64(evenZeros+oddZeros)/float64(len(data)) <= d.MaxZeroByteRatio
+	utf16LowScore := (leOk != "" && leEval.compositeScore < d.MinUTF16Score) || (beOk != "" && beEval.compositeScore < d.MinUTF16Score)
+	if utf16LowScore && lowZero && gbPairRatio >= d.MinGBPairRatio && asciiRunRatio < d.MaxASCIIRunRatio {
 		if isGB18030(data) {
-			return EncodingGB18030
+			return EncodingGB18030, gb18030Confidence(gbPairRatio), append(reasons, "utf16-low-score", "gb18030-pair-pattern", "gb18030-strict")
 		}
 	}
 
 	// 第二道拦截（原始逻辑增强版）：UTF-16 低分 + GB18030 可严格解码
-	minScore := 0.90
+	minScore := d.MinUTF16Score
 	if (leOk != "" && leEval.compositeScore < minScore) || (beOk != "" && beEval.compositeScore < minScore) {
 		if isGB18030(data) {
-			return EncodingGB18030
+			return EncodingGB18030, gb18030Confidence(gbPairRatio), append(reasons, "utf16-low-score", "gb18030-strict")
 		}
 	}
 
 	if leOk == "" && beOk == "" {
-		return ""
+		return "", 0, nil
 	}
 	if leOk != "" && beOk == "" {
-		return leOk
+		return leOk, leEval.compositeScore, reasons
 	}
 	if beOk != "" && leOk == "" {
-		return beOk
+		return beOk, beEval.compositeScore, reasons
 	}
 
 	// 都有效，按综合分决定；分数接近时优先零字节模式匹配的那个
 	if leEval.compositeScore > beEval.compositeScore {
-		return EncodingUTF16LE
+		return EncodingUTF16LE, leEval.compositeScore, reasons
 	}
 	if beEval.compositeScore > leEval.compositeScore {
-		return EncodingUTF16BE
+		return EncodingUTF16BE, beEval.compositeScore, reasons
 	}
 	// 分数相同：如果某个触发了明确零字节模式则优先它
 	if oddRatio > high && evenRatio < low {
-		return EncodingUTF16LE
+		return EncodingUTF16LE, leEval.compositeScore, reasons
 	}
 	if evenRatio > high && oddRatio < low {
-		return EncodingUTF16BE
+		return EncodingUTF16BE, beEval.compositeScore, reasons
 	}
-	return "" // 模棱两可，放弃，交给后续 UTF-8/GB18030 逻辑
+	return "", 0, nil // 模棱两可，放弃，交给后续 UTF-8/GB18030 逻辑
+}
+
+// gb18030Confidence 将 GB18030 合法双字节对比例映射为置信度：能严格解码即有 0.5 基础分，其余由双字节模式比例决定。
+func gb18030Confidence(pairRatio float64) float64 {
+	return 0.5 + 0.5*math.Min(math.Max(pairRatio, 0), 1)
 }
 
 // gb18030PatternConfidence 粗略评估原始字节序列中“看起来像 GB/GB18030 双字节模式”的比例与 ASCII 连续度：
@@ -490,56 +590,144 @@ func isGB18030(data []byte) bool {
 // 对于未知编码，会尝试按 UTF-8 进行容错修复并返回相应提示。
 func Decode(data []byte) (string, string, error) {
 	enc := Detect(data)
+	text, err := decodeAs(data, enc)
+	return text, enc, err
+}
+
+// DecodeClean 与 Decode 相同，但会在解码后调用 StripInvisible 移除零宽字符及混入文本中间的 BOM。
+// 返回的 int 为被移除的字符数。
+func DecodeClean(data []byte) (string, string, int, error) {
+	text, enc, err := Decode(data)
+	text, removed := StripInvisible(text)
+	return text, enc, removed, err
+}
+
+// StripInvisible 移除字符串中的不可见字符：U+FEFF（BOM/零宽不换行空格）、U+200B–U+200D（零宽空格/连接符）
+// 以及 U+2060（词连接符）。这些字符会导致 strconv.ParseFloat 等解析失败，或污染属性键名。
+// 返回清理后的字符串及移除的字符数；不含此类字符时原样返回，不产生额外分配。
+func StripInvisible(s string) (string, int) {
+	if !strings.ContainsFunc(s, isInvisible) {
+		return s, 0
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	removed := 0
+	for _, r := range s {
+		if isInvisible(r) {
+			removed++
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), removed
+}
+
+// isInvisible 判断 r 是否为 StripInvisible 需要移除的字符。
+func isInvisible(r rune) bool {
+	switch r {
+	case '\uFEFF', '\u200B', '\u200C', '\u200D', '\u2060':
+		return true
+	}
+	return false
+}
+
+// DecodeWith 跳过探测，直接按指定编码（Encoding* 常量，大小写不敏感）将输入转换为 UTF-8。
+// 替换非法序列/代理对的修复逻辑及警告性错误与 Decode 一致；
+// 不支持的编码（包括 EncodingUnknown）直接返回错误，不会回退到自动探测。
+func DecodeWith(data []byte, enc string) (string, error) {
+	name, ok := normalizeEncoding(enc)
+	if !ok {
+		return "", fmt.Errorf("不支持的编码: %q", enc)
+	}
+	return decodeAs(data, name)
+}
+
+// IsSupported 判断 enc 是否为 DecodeWith 可接受的编码名称。
+func IsSupported(enc string) bool {
+	_, ok := normalizeEncoding(enc)
+	return ok
+}
+
+// LineEndingStats 统计数据中各类换行符的数量，应在解码后（UTF-8 字节）调用。
+// CRLF 只计入 crlf，不会重复计入 cr 或 lf；lf、cr 分别为单独出现的 '\n' 与 '\r'。
+// 多于一种计数非零即表示换行符混用。
+func LineEndingStats(data []byte) (crlf, lf, cr int) {
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '\r':
+			if i+1 < len(data) && data[i+1] == '\n' {
+				crlf++
+				i++
+			} else {
+				cr++
+			}
+		case '\n':
+			lf++
+		}
+	}
+	return crlf, lf, cr
+}
+
+// normalizeEncoding 将编码名称规范为 Encoding* 常量，EncodingUnknown 视为不支持。
+func normalizeEncoding(enc string) (string, bool) {
+	name := strings.ToLower(strings.TrimSpace(enc))
+	switch name {
+	case EncodingUTF8, EncodingUTF8BOM, EncodingUTF16LE, EncodingUTF16BE, EncodingGB18030:
+		return name, true
+	}
+	return "", false
+}
+
+// decodeAs 按给定编码解码，enc 为 EncodingUnknown 或其它值时按未知编码容错处理。
+func decodeAs(data []byte, enc string) (string, error) {
 	switch enc {
 	case EncodingUTF8BOM:
-		if len(data) >= 3 {
-			data = data[3:] // 去掉 BOM
-		}
-		return string(data), EncodingUTF8BOM, nil
+		data = bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF}) // 去掉 BOM
+		return string(data), nil
 	case EncodingUTF8:
 		if utf8.Valid(data) {
-			return string(data), EncodingUTF8, nil
+			return string(data), nil
 		}
 		// 虽检测为 UTF-8 但存在非法序列（极少见，可能截断），执行修复
 		fixed, replaced := sanitizeInvalidUTF8(data)
 		if replaced > 0 {
-			return string(fixed), EncodingUTF8, fmt.Errorf("utf-8 含有 %d 处非法序列已替换", replaced)
+			return string(fixed), fmt.Errorf("utf-8 含有 %d 处非法序列已替换", replaced)
 		}
-		return string(fixed), EncodingUTF8, nil
+		return string(fixed), nil
 	case EncodingUTF16LE:
 		utf8Bytes, rep, err := decodeUTF16(data, true)
 		if err != nil {
-			return string(utf8Bytes), EncodingUTF16LE, err
+			return string(utf8Bytes), err
 		}
 		if rep > 0 {
-			return string(utf8Bytes), EncodingUTF16LE, fmt.Errorf("utf-16-le 含有 %d 处非法代理对已替换", rep)
+			return string(utf8Bytes), fmt.Errorf("utf-16-le 含有 %d 处非法代理对已替换", rep)
 		}
-		return string(utf8Bytes), EncodingUTF16LE, nil
+		return string(utf8Bytes), nil
 	case EncodingUTF16BE:
 		utf8Bytes, rep, err := decodeUTF16(data, false)
 		if err != nil {
-			return string(utf8Bytes), EncodingUTF16BE, err
+			return string(utf8Bytes), err
 		}
 		if rep > 0 {
-			return string(utf8Bytes), EncodingUTF16BE, fmt.Errorf("utf-16-be 含有 %d 处非法代理对已替换", rep)
+			return string(utf8Bytes), fmt.Errorf("utf-16-be 含有 %d 处非法代理对已替换", rep)
 		}
-		return string(utf8Bytes), EncodingUTF16BE, nil
+		return string(utf8Bytes), nil
 	case EncodingGB18030:
 		dec := simplifiedchinese.GB18030.NewDecoder()
 		utf8Bytes, err := dec.Bytes(data)
 		if err != nil {
-			return string(utf8Bytes), EncodingGB18030, fmt.Errorf("gb18030 解码失败: %w", err)
+			return string(utf8Bytes), fmt.Errorf("gb18030 解码失败: %w", err)
 		}
-		return string(utf8Bytes), EncodingGB18030, nil
+		return string(utf8Bytes), nil
 	default: // Unknown
 		if utf8.Valid(data) {
-			return string(data), EncodingUnknown, fmt.Errorf("编码未知，按 utf-8 返回")
+			return string(data), fmt.Errorf("编码未知，按 utf-8 返回")
 		}
 		fixed, replaced := sanitizeInvalidUTF8(data)
 		if replaced > 0 {
-			return string(fixed), EncodingUnknown, fmt.Errorf("编码未知且包含 %d 处非法字节，已替换为 U+FFFD", replaced)
+			return string(fixed), fmt.Errorf("编码未知且包含 %d 处非法字节，已替换为 U+FFFD", replaced)
 		}
-		return string(fixed), EncodingUnknown, fmt.Errorf("编码未知")
+		return string(fixed), fmt.Errorf("编码未知")
 	}
 }
 
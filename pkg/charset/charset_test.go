@@ -0,0 +1,221 @@
+package charset
+
+import (
+	"encoding/binary"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// encodeUTF16 将 s 编码为不带 BOM 的 UTF-16 字节序列。
+func encodeUTF16(s string, littleEndian bool) []byte {
+	var out []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		if littleEndian {
+			out = binary.LittleEndian.AppendUint16(out, u)
+		} else {
+			out = binary.BigEndian.AppendUint16(out, u)
+		}
+	}
+	return out
+}
+
+// encodeGB18030 将 s 编码为 GB18030 字节序列。
+func encodeGB18030(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := simplifiedchinese.GB18030.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+const cadastralText = "[属性描述]\n坐标系=2000国家大地坐标系\n几度分带=3\n"
+
+func TestDetectDetailed(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       []byte
+		want       string
+		minConf    float64
+		maxConf    float64
+		wantReason string
+	}{
+		{"空数据", nil, EncodingUTF8, 1, 1, "empty"},
+		{"UTF-8 BOM", append([]byte{0xEF, 0xBB, 0xBF}, "地块"...), EncodingUTF8BOM, 1, 1, "bom"},
+		{"UTF-16LE BOM", append([]byte{0xFF, 0xFE}, encodeUTF16("地块", true)...), EncodingUTF16LE, 1, 1, "bom"},
+		{"UTF-16BE BOM", append([]byte{0xFE, 0xFF}, encodeUTF16("地块", false)...), EncodingUTF16BE, 1, 1, "bom"},
+		{"ASCII", []byte("J1,1,3400000.00,38500000.00\n"), EncodingUTF8, 1, 1, "utf8-strict"},
+		{"UTF-8 中文", []byte(cadastralText), EncodingUTF8, 1, 1, "utf8-strict"},
+		{"UTF-8 末尾截断", []byte("地块")[:5], EncodingUTF8, 0.9, 0.9, "utf8-truncated-tail"},
+		{"无 BOM UTF-16LE 中文", encodeUTF16("宗地界址点权属集体所有", true), EncodingUTF16LE, 0.9, 1, "utf16-le-cjk-pattern"},
+		{"无 BOM UTF-16BE 中文", encodeUTF16("宗地界址点权属集体所有", false), EncodingUTF16BE, 0.9, 1, "utf16-be-cjk-pattern"},
+		{"GB18030 中文", encodeGB18030(t, "宗地界址点权属集体所有"), EncodingGB18030, 0.5, 1, "gb18030-strict"},
+		{"GB18030 混合 ASCII", encodeGB18030(t, cadastralText), EncodingGB18030, 0.5, 1, "gb18030-strict"},
+		{"UTF-16 低分让位于 GB18030", []byte{0xFF, 0x00, 0x80, 0x81}, EncodingGB18030, 0.5, 0.5, "utf16-low-score"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, conf, reasons := DetectDetailed(tt.data)
+			if enc != tt.want {
+				t.Fatalf("DetectDetailed = %s (%v, %v), want %s", enc, conf, reasons, tt.want)
+			}
+			if conf < tt.minConf || conf > tt.maxConf {
+				t.Errorf("置信度 = %v, want [%v, %v]", conf, tt.minConf, tt.maxConf)
+			}
+			if !slices.Contains(reasons, tt.wantReason) {
+				t.Errorf("reasons = %v, want 包含 %q", reasons, tt.wantReason)
+			}
+			if got := Detect(tt.data); got != enc {
+				t.Errorf("Detect = %s, 与 DetectDetailed 的 %s 不一致", got, enc)
+			}
+		})
+	}
+}
+
+func TestDetectorThresholds(t *testing.T) {
+	data := []byte{0xFF, 0x00, 0x80, 0x81} // 默认阈值下 UTF-16 综合分过低，让位于 GB18030
+
+	tuned := NewDetector()
+	tuned.MinUTF16Score = 0
+	if got := tuned.Detect(data); got != EncodingUTF16LE {
+		t.Errorf("MinUTF16Score=0 时 Detect = %s, want %s", got, EncodingUTF16LE)
+	}
+
+	// 超出 [0,1] 的阈值回退为默认值，结果与包级 Detect 一致
+	for _, bad := range []float64{-0.1, 1.5, math.NaN()} {
+		d := NewDetector()
+		d.MinUTF16Score, d.MinGBPairRatio, d.CJKHighByteRatio = bad, bad, bad
+		for _, sample := range [][]byte{data, encodeUTF16("宗地界址点权属集体所有", true), encodeGB18030(t, cadastralText)} {
+			if got, want := d.Detect(sample), Detect(sample); got != want {
+				t.Errorf("阈值 %v: Detect(% x) = %s, want %s", bad, sample, got, want)
+			}
+		}
+	}
+
+	var nilDetector *Detector
+	if got := nilDetector.Detect(data); got != Detect(data) {
+		t.Errorf("nil Detector 的 Detect = %s, want %s", got, Detect(data))
+	}
+}
+
+func TestDecodeWith(t *testing.T) {
+	loneSurrogate := append(encodeUTF16("地", true), 0x00, 0xD8) // 孤立高代理
+	tests := []struct {
+		name     string
+		data     []byte
+		enc      string
+		want     string
+		wantWarn string // 为空表示无错误
+	}{
+		{"GB18030", encodeGB18030(t, cadastralText), " GB18030 ", cadastralText, ""},
+		{"UTF-8 BOM", append([]byte{0xEF, 0xBB, 0xBF}, "地块"...), "utf-8-sig", "地块", ""},
+		{"UTF-16BE", encodeUTF16("地块", false), "UTF-16-BE", "地块", ""},
+		{"UTF-16LE 孤立代理", loneSurrogate, "utf-16-le", "地�", "1 处非法代理对"},
+		{"UTF-8 非法序列", []byte{'a', 0xFF, 'b'}, "utf-8", "a�b", "1 处非法序列"},
+		{"不支持的编码", []byte("a"), "latin1", "", "不支持的编码"},
+		{"unknown 不是可用编码", []byte("a"), EncodingUnknown, "", "不支持的编码"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeWith(tt.data, tt.enc)
+			if tt.wantWarn == "" {
+				if err != nil {
+					t.Fatalf("DecodeWith: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantWarn) {
+				t.Fatalf("err = %v, want containing %q", err, tt.wantWarn)
+			}
+			if got != tt.want {
+				t.Errorf("DecodeWith = %q, want %q", got, tt.want)
+			}
+			if supported := IsSupported(tt.enc); supported != !strings.Contains(tt.wantWarn, "不支持") {
+				t.Errorf("IsSupported(%q) = %v", tt.enc, supported)
+			}
+		})
+	}
+}
+
+func TestLineEndingStats(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		crlf, lf, cr int
+	}{
+		{"空", "", 0, 0, 0},
+		{"无换行", "abc", 0, 0, 0},
+		{"仅 CRLF", "a\r\nb\r\n", 2, 0, 0},
+		{"仅 LF", "a\nb\nc", 0, 2, 0},
+		{"仅 CR", "a\rb\r", 0, 0, 2},
+		{"三种混用", "a\r\nb\nc\rd\r\ne\n", 2, 2, 1},
+		{"CR 后紧跟 CRLF", "a\r\r\nb", 1, 0, 1},
+		{"LF 后紧跟 CR", "a\n\rb", 0, 1, 1},
+		{"末尾单独 CR", "a\r\nb\r", 1, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crlf, lf, cr := LineEndingStats([]byte(tt.data))
+			if crlf != tt.crlf || lf != tt.lf || cr != tt.cr {
+				t.Errorf("LineEndingStats(%q) = %d/%d/%d, want %d/%d/%d", tt.data, crlf, lf, cr, tt.crlf, tt.lf, tt.cr)
+			}
+		})
+	}
+}
+
+func TestStripInvisible(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		removed int
+	}{
+		{"地块1", "地块1", 0},
+		{"\uFEFF地块", "地块", 1},
+		{"a\u200Bb\u200Cc\u200Dd\u2060e", "abcde", 4},
+		{"38500000\uFEFF.00", "38500000.00", 1},
+		{"\u200E保留", "\u200E保留", 0}, // 仅移除指定的字符
+	}
+	for _, tt := range tests {
+		got, removed := StripInvisible(tt.in)
+		if got != tt.want || removed != tt.removed {
+			t.Errorf("StripInvisible(%q) = %q, %d, want %q, %d", tt.in, got, removed, tt.want, tt.removed)
+		}
+	}
+}
+
+func TestDecodeCleanCoordinateLine(t *testing.T) {
+	line := "J1,1,3400000.00\u200B,\uFEFF38500000.00\r\n"
+	parse := func(s string) error {
+		for _, field := range strings.Split(strings.TrimSpace(s), ",")[2:] {
+			if _, err := strconv.ParseFloat(field, 64); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := parse(line); err == nil {
+		t.Fatal("含零宽字符的坐标行不应能直接解析")
+	}
+
+	for _, data := range [][]byte{
+		append([]byte{0xEF, 0xBB, 0xBF}, line...), // 开头 BOM 由解码去除，不计入 removed
+		append([]byte{0xFF, 0xFE}, encodeUTF16(line, true)...),
+		encodeGB18030(t, "地块"+line),
+	} {
+		text, enc, removed, err := DecodeClean(data)
+		if err != nil {
+			t.Fatalf("DecodeClean(%s): %v", enc, err)
+		}
+		if removed != 2 {
+			t.Errorf("%s: removed = %d, want 2", enc, removed)
+		}
+		text = strings.TrimPrefix(text, "地块")
+		if err := parse(text); err != nil {
+			t.Errorf("%s: 清理后的坐标行仍无法解析: %v", enc, err)
+		}
+	}
+}
@@ -4,19 +4,21 @@ Copyright © 2025 TheMachine <592858548@qq.com>
 package environ
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
-
-	"txt2geo/pkg/pathx"
-
-	"golang.org/x/sys/windows"
-	"golang.org/x/sys/windows/registry"
+	"sync"
+	"time"
 )
 
-// 本文件提供在 Windows 平台自动发现 QGIS 安装目录并设置运行所需环境变量的能力。
+// 本文件提供自动发现 QGIS 安装目录并设置运行所需环境变量的能力。
+// 与平台相关的查找、校验与环境配置见 environ_windows.go、environ_linux.go、environ_darwin.go，
+// 各平台需实现 findQGISPath、isValidQGISPath、rootFromPrefixPath、qgisPrefixPath、
+// resolvePythonExecutable 与 setupQGISEnvironment。
 // 仅做本地进程级别 (os.Setenv) 修改，不对系统永久环境产生影响。
 
 // 哨兵错误（Sentinel Errors），调用方可使用 errors.Is 进行判定：
@@ -25,24 +27,93 @@ var (
 	ErrQGISNotFound = errors.New("qgis not installed")
 	// ErrQGISEnvSetup 表示找到了安装目录但环境变量配置失败。
 	ErrQGISEnvSetup = errors.New("qgis environment setup failed")
+	// ErrQGISInvalidPath 表示显式指定的 QGIS 安装目录无效。
+	ErrQGISInvalidPath = errors.New("invalid qgis path")
+	// ErrQGISPython 表示解析到的 Python 解释器无法导入 qgis.core。
+	ErrQGISPython = errors.New("qgis python unusable")
+)
+
+const (
+	// verifyPythonTimeout 是 VerifyQGISPython 导入检查的超时时间
+	verifyPythonTimeout = 30 * time.Second
 )
 
+// 可显式指定 QGIS 安装目录的环境变量，按优先级排列。
+// QGIS_PREFIX_PATH 通常指向安装根目录下的 prefix 目录（Windows 为 apps/qgis，macOS 为 Contents/MacOS），
+// 解析时会自动回溯到安装根目录。
+var qgisPathEnvVars = []string{"TXT2GEO_QGIS", "QGIS_PREFIX_PATH"}
+
 // InitializeQGISEnvironment 自动查找 QGIS 安装路径并为当前进程设置必要的环境变量。
 //
-// 此函数会依次尝试从注册表和常见安装位置查找 QGIS。
+// 此函数会依次尝试环境变量 TXT2GEO_QGIS、QGIS_PREFIX_PATH，以及平台相关的位置查找 QGIS：
+// Windows 为注册表和常见安装目录，Linux/macOS 为 PATH 中的 qgis/qgis_process 与标准安装目录。
 // 成功找到并设置环境变量后，会更新 PATH 和 PYTHONPATH 等，以便后续操作能正确调用 QGIS 相关工具。
 //
 // 返回:
 //   - prefixPath: QGIS 的prefixPath路径。
-//   - pythonPath: 解析到的 Python 解释器可执行文件路径（通常位于 QGIS 安装目录下的 bin/python*.exe）。
+//   - pythonPath: 解析到的 Python 解释器可执行文件路径（Windows 通常为安装目录下的 bin/python*.exe）。
 //   - ErrQGISNotFound: 如果未找到 QGIS 安装。
+//   - ErrQGISInvalidPath: 如果环境变量指定的目录不是有效的 QGIS 安装。
 //   - ErrQGISEnvSetup: 如果找到了 QGIS 但在设置环境变量时出错。
 func InitializeQGISEnvironment() (string, string, error) {
-	qgisPath, err := findQGISPath()
+	return InitializeQGISEnvironmentWithPath("")
+}
+
+// InitializeQGISEnvironmentWithPath 与 InitializeQGISEnvironment 相同，但优先使用显式指定的安装目录。
+//
+// 查找顺序：参数 override → 环境变量 TXT2GEO_QGIS → QGIS_PREFIX_PATH → 平台相关的自动查找。
+// 显式指定的目录无效时返回 ErrQGISInvalidPath，不会回退到自动查找。
+//
+// 同一进程内的结果（含错误）按 override 缓存，重复调用不会再次查询注册表或扫描磁盘，并发调用也只执行一次初始化。
+func InitializeQGISEnvironmentWithPath(override string) (string, string, error) {
+	envCache.mu.Lock()
+	if envCache.entries == nil {
+		envCache.entries = make(map[string]*envResult)
+	}
+	r, ok := envCache.entries[override]
+	if !ok {
+		r = &envResult{}
+		envCache.entries[override] = r
+	}
+	envCache.mu.Unlock()
+
+	r.once.Do(func() {
+		r.prefixPath, r.pythonPath, r.err = initEnvironment(override)
+	})
+	return r.prefixPath, r.pythonPath, r.err
+}
+
+// envResult 是一次环境初始化的结果。
+type envResult struct {
+	once       sync.Once
+	prefixPath string
+	pythonPath string
+	err        error
+}
+
+// envCache 按显式路径缓存环境初始化结果。
+var envCache struct {
+	mu      sync.Mutex
+	entries map[string]*envResult
+}
+
+// ResetEnvironmentCache 清空环境初始化缓存，使下次调用重新查找 QGIS（主要用于测试）。
+func ResetEnvironmentCache() {
+	envCache.mu.Lock()
+	defer envCache.mu.Unlock()
+	envCache.entries = nil
+}
+
+// initEnvironment 是缓存未命中时执行的初始化函数，测试中可替换以统计调用次数。
+var initEnvironment = initializeQGISEnvironment
+
+// initializeQGISEnvironment 执行实际的查找与环境变量设置，不经过缓存。
+func initializeQGISEnvironment(override string) (string, string, error) {
+	qgisPath, err := resolveQGISPath(override)
 	if err != nil {
-		return "", "", ErrQGISNotFound
+		return "", "", err
 	}
-	prefixPath := filepath.Join(qgisPath, "apps", "qgis")
+	prefixPath := qgisPrefixPath(qgisPath)
 
 	pythonPath, err := resolvePythonExecutable(qgisPath)
 	if err != nil {
@@ -56,124 +127,96 @@ func InitializeQGISEnvironment() (string, string, error) {
 	return prefixPath, pythonPath, nil
 }
 
-// findQGISPath 负责按顺序从不同来源查找 QGIS 的安装根目录。
-// 它首先检查 Windows 注册表，如果找不到，则会搜索常见的安装目录。
-// 返回找到的路径或一个错误。
-func findQGISPath() (string, error) {
-	// 1. 注册表
-	registryKeys := []string{
-		"QGIS Project\\Shell\\open\\command",
-		"QGIS Project\\DefaultIcon",
+// resolveQGISPath 确定 QGIS 安装根目录：显式指定的路径优先，其次是环境变量，最后自动查找。
+func resolveQGISPath(override string) (string, error) {
+	source, path := "参数", strings.TrimSpace(override)
+	if path == "" {
+		for _, name := range qgisPathEnvVars {
+			if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+				source, path = "环境变量 "+name, v
+				break
+			}
+		}
 	}
-	if path, err := findFromRegistry(registryKeys); err == nil {
-		return path, nil
+	if path == "" {
+		qgisPath, err := findQGISPath()
+		if err != nil {
+			return "", ErrQGISNotFound
+		}
+		return qgisPath, nil
 	}
-	// 2. 常见安装位置（含通配符）
-	commonPaths := []string{"OSGeo4W", "Program Files\\QGIS*"}
-	if path, err := findFromCommonPaths(commonPaths); err == nil {
+
+	path = filepath.Clean(strings.Trim(path, "\""))
+	if isValidQGISPath(path) {
 		return path, nil
 	}
-	return "", fmt.Errorf("未找到QGIS安装路径，请确保QGIS已正确安装")
+	// 兼容指向 prefix 目录（如 <安装目录>/apps/qgis）的路径
+	if root := rootFromPrefixPath(path); root != "" && isValidQGISPath(root) {
+		return root, nil
+	}
+	return "", fmt.Errorf("%w: %s 指定的目录不是有效的 QGIS 安装目录: %s", ErrQGISInvalidPath, source, path)
 }
 
-// findFromRegistry 通过查询 Windows 注册表中的预定义键来定位 QGIS 安装路径。
-// 它会遍历 `keyPaths` 中的每个键，直到找到一个有效路径为止。
-func findFromRegistry(keyPaths []string) (string, error) {
-	for _, keyPath := range keyPaths {
-		key, err := registry.OpenKey(registry.CLASSES_ROOT, keyPath, windows.KEY_READ)
-		if err != nil {
-			continue
-		}
-		defer key.Close()
+// VerifyQGISPython 使用当前进程环境运行 `python -c "import qgis.core"`，确认解释器能够加载 QGIS。
+//
+// 用于在发送数据前提前发现损坏的 QGIS 安装或被系统 Python 遮蔽等问题；prefixPath 非空时作为 QGIS_PREFIX_PATH 传入。
+// 检查失败时返回包装了 ErrQGISPython 的错误，并附带解释器输出的最后一行。
+func VerifyQGISPython(pythonPath, prefixPath string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), verifyPythonTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, pythonPath, "-c", "import qgis.core")
+	if prefixPath != "" {
+		cmd.Env = append(os.Environ(), "QGIS_PREFIX_PATH="+prefixPath)
+	}
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: 导入 qgis.core 超时 (%v): %s", ErrQGISPython, verifyPythonTimeout, pythonPath)
+	}
+	detail := err.Error()
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+		detail = last
+	}
+	return fmt.Errorf("%w: %s 无法导入 qgis.core: %s", ErrQGISPython, pythonPath, detail)
+}
 
-		value, _, err := key.GetStringValue("")
+// findFromPATH 在 PATH 中查找 names 中的任一可执行文件（如 qgis、qgis_process），
+// 并从其所在位置向上追溯 QGIS 安装根目录。
+func findFromPATH(names ...string) (string, error) {
+	for _, name := range names {
+		exe, err := exec.LookPath(name)
 		if err != nil {
 			continue
 		}
-
-		// 清理路径并验证
-		trimmedPath := strings.Trim(value, "\"")
-		if trimmedPath == "" {
-			continue
-		}
-		// 提取QGIS根目录路径
-		qgisRootDir := extractQGISRootPath(trimmedPath)
-		if qgisRootDir == "" {
-			continue
-		}
-
-		// 转换为标准长路径格式
-		longPath, err := pathx.GetLongPathName(qgisRootDir)
-		if err != nil {
-			// 转换失败，直接用原路径，库代码不输出
-			longPath = qgisRootDir
-		}
-
-		// 验证是否为有效的QGIS根目录
-		if isValidQGISPath(longPath) {
-			return longPath, nil
+		// 解析符号链接（如 /usr/local/bin/qgis -> /Applications/QGIS.app/...）
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
 		}
-	}
-	return "", fmt.Errorf("未在注册表中找到QGIS安装路径")
-}
-
-// findFromCommonPaths 遍历系统的所有逻辑驱动器和一组常见的 QGIS 安装目录名来查找 QGIS。
-// 支持通配符路径，例如 "Program Files\\QGIS*"。
-func findFromCommonPaths(commonPaths []string) (string, error) {
-	drivers, err := pathx.GetLogicalDrives()
-	if err != nil {
-		return "", fmt.Errorf("获取逻辑驱动器失败: %w", err)
-	}
-
-	for _, drive := range drivers {
-		for _, commonPath := range commonPaths {
-			fullPath := filepath.Join(drive, commonPath)
-
-			// 处理通配符路径
-			if strings.Contains(commonPath, "*") {
-				matches, err := filepath.Glob(fullPath)
-				if err != nil {
-					continue
-				}
-				for _, match := range matches {
-					if isValidQGISPath(match) {
-						return match, nil
-					}
-				}
-			} else {
-				// 直接路径检查
-				if isValidQGISPath(fullPath) {
-					return fullPath, nil
-				}
-			}
+		if root := extractQGISRootPath(exe); root != "" {
+			return root, nil
 		}
 	}
-	return "", fmt.Errorf("未在常见路径中找到QGIS安装")
+	return "", fmt.Errorf("未在 PATH 中找到 QGIS 可执行文件")
 }
 
-// resolvePythonExecutable 在给定的 QGIS 安装目录下定位 Python 解释器可执行文件。
-func resolvePythonExecutable(qgisPath string) (string, error) {
-	candidates := []string{
-		filepath.Join(qgisPath, "bin", "python3.exe"),
-		filepath.Join(qgisPath, "bin", "python.exe"),
-	}
-	for _, candidate := range candidates {
-		if exists, _ := pathx.Exists(candidate); exists {
-			return candidate, nil
+// firstValidQGISPath 依次展开 patterns（支持通配符），返回第一个有效的 QGIS 安装根目录。
+func firstValidQGISPath(patterns []string) (string, error) {
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			continue
 		}
-	}
-
-	pattern := filepath.Join(qgisPath, "bin", "python*.exe")
-	matches, err := filepath.Glob(pattern)
-	if err == nil {
 		for _, match := range matches {
-			if exists, _ := pathx.Exists(match); exists {
+			if isValidQGISPath(match) {
 				return match, nil
 			}
 		}
 	}
-
-	return "", fmt.Errorf("未在 QGIS 安装目录中找到 python 可执行文件: %s", qgisPath)
+	return "", fmt.Errorf("未在常见路径中找到QGIS安装")
 }
 
 // extractQGISRootPath 从一个给定的路径（通常是注册表中的可执行文件路径）向上追溯，
@@ -190,28 +233,6 @@ func extractQGISRootPath(registryValue string) string {
 	}
 }
 
-// isValidQGISPath 检查给定路径是否是一个有效的 QGIS 安装目录。
-// 它通过检查目录下是否存在一些关键文件（如 "OSGeo4W.bat"）来做出判断。
-func isValidQGISPath(path string) bool {
-	if exists, _ := pathx.Exists(path); !exists {
-		return false
-	}
-
-	// 检查其他关键文件
-	keyFiles := []string{
-		"OSGeo4W.bat",
-		"bin/python3.exe",
-		"apps/qgis/python",
-		"bin/qgis.bat",
-	}
-	for _, keyFile := range keyFiles {
-		if exists, _ := pathx.Exists(filepath.Join(path, keyFile)); exists {
-			return true
-		}
-	}
-	return false
-}
-
 // ===== 环境变量相关函数 =====
 
 // parseEnvFile 解析 QGIS 的环境配置文件 (如 qgis-bin.env)，
@@ -239,54 +260,6 @@ func parseEnvFile(filePath string) (map[string]string, error) {
 	return envVars, nil
 }
 
-// setupQGISEnvironment 根据找到的 QGIS 安装路径，为当前进程配置所需的环境变量。
-// 它会读取 .env 文件，并合并 PATH 和 PYTHONPATH 等路径变量。
-func setupQGISEnvironment(qgisPath string) error {
-	// 读取QGIS环境变量文件
-	envFile := filepath.Join(qgisPath, "bin", "qgis-bin.env")
-	envVars, err := parseEnvFile(envFile)
-	if err != nil {
-		return fmt.Errorf("解析环境文件失败: %w", err)
-	}
-
-	// 添加QGIS Python路径到PYTHONPATH
-	pythonPath := filepath.Join(qgisPath, "apps", "qgis", "python")
-	if _, err := os.Stat(pythonPath); err == nil {
-		currentPythonPath := os.Getenv("PYTHONPATH")
-		if currentPythonPath == "" {
-			envVars["PYTHONPATH"] = pythonPath
-		} else {
-			envVars["PYTHONPATH"] = pythonPath + ";" + currentPythonPath
-		}
-	}
-
-	// 需要合并追加的路径型环境变量
-	pathVars := map[string]struct{}{
-		"PATH":       {},
-		"PYTHONPATH": {},
-	}
-
-	for key, value := range envVars {
-		upperKey := strings.ToUpper(key)
-		if _, ok := pathVars[upperKey]; ok {
-			// 路径型变量，合并去重
-			oldVal := os.Getenv(upperKey)
-			merged := mergePathEnv(value, oldVal)
-			if err := os.Setenv(upperKey, merged); err != nil {
-				// 设置失败，库代码不输出
-			}
-		} else {
-			// 普通变量，直接覆盖
-			if err := os.Setenv(upperKey, value); err != nil {
-				// 设置失败，库代码不输出
-			}
-		}
-	}
-
-	// QGIS环境设置完成，库代码不输出
-	return nil
-}
-
 // mergePathEnv 合并新旧两个路径字符串，去除重复项，并返回一个单一的、合并后的路径字符串。
 // 路径项会进行标准化和去重处理。
 func mergePathEnv(newVal, oldVal string) string {
@@ -0,0 +1,107 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package environ
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"txt2geo/pkg/pathx"
+)
+
+// macOS 平台：QGIS 以 .app 应用包分发（官方安装包与 Homebrew cask 均安装到 Applications），
+// 安装根目录为应用包本身，QGIS_PREFIX_PATH 为 <应用包>/Contents/MacOS。
+
+// darwinBundlePatterns 常见的 QGIS 应用包位置（支持通配符），用户目录下的 Applications 在查找时追加。
+var darwinBundlePatterns = []string{
+	"/Applications/QGIS*.app",
+	"/opt/homebrew/opt/qgis/QGIS*.app",
+	"/usr/local/opt/qgis/QGIS*.app",
+}
+
+// findQGISPath 查找 QGIS 应用包：先从 PATH 中的可执行文件回溯，再检查常见安装位置。
+func findQGISPath() (string, error) {
+	if path, err := findFromPATH("qgis", "qgis_process"); err == nil {
+		return path, nil
+	}
+	patterns := darwinBundlePatterns
+	if home, err := os.UserHomeDir(); err == nil {
+		patterns = append(patterns[:len(patterns):len(patterns)], filepath.Join(home, "Applications", "QGIS*.app"))
+	}
+	if path, err := firstValidQGISPath(patterns); err == nil {
+		return path, nil
+	}
+	return "", fmt.Errorf("未找到QGIS安装路径，请确保QGIS已正确安装")
+}
+
+// isValidQGISPath 检查给定路径是否是一个有效的 QGIS 应用包。
+// 判断依据为包内存在 QGIS 主程序或 Python 绑定目录。
+func isValidQGISPath(path string) bool {
+	if exists, _ := pathx.Exists(path); !exists {
+		return false
+	}
+	keyFiles := []string{
+		"Contents/MacOS/QGIS",
+		"Contents/Resources/python",
+	}
+	for _, keyFile := range keyFiles {
+		if exists, _ := pathx.Exists(filepath.Join(path, keyFile)); exists {
+			return true
+		}
+	}
+	return false
+}
+
+// qgisPrefixPath 返回应用包对应的 QGIS_PREFIX_PATH（<应用包>/Contents/MacOS）。
+func qgisPrefixPath(qgisPath string) string {
+	return filepath.Join(qgisPath, "Contents", "MacOS")
+}
+
+// rootFromPrefixPath 将指向 <应用包>/Contents/MacOS 的 prefix 路径还原为应用包，不是 prefix 路径时返回空。
+func rootFromPrefixPath(path string) string {
+	if strings.EqualFold(filepath.Base(path), "MacOS") && strings.EqualFold(filepath.Base(filepath.Dir(path)), "Contents") {
+		return filepath.Dir(filepath.Dir(path))
+	}
+	return ""
+}
+
+// resolvePythonExecutable 在应用包内定位随 QGIS 分发的 python3。
+func resolvePythonExecutable(qgisPath string) (string, error) {
+	candidates := []string{
+		filepath.Join(qgisPath, "Contents", "MacOS", "bin", "python3"),
+		filepath.Join(qgisPath, "Contents", "Frameworks", "Python.framework", "Versions", "Current", "bin", "python3"),
+	}
+	for _, candidate := range candidates {
+		if exists, _ := pathx.Exists(candidate); exists {
+			return candidate, nil
+		}
+	}
+	pattern := filepath.Join(qgisPath, "Contents", "Frameworks", "Python.framework", "Versions", "*", "bin", "python3")
+	if matches, err := filepath.Glob(pattern); err == nil && len(matches) > 0 {
+		return matches[len(matches)-1], nil
+	}
+	return "", fmt.Errorf("未在 QGIS 应用包中找到 python3: %s", qgisPath)
+}
+
+// setupQGISEnvironment 设置 QGIS_PREFIX_PATH，并将应用包内的 Python 绑定目录与 bin 目录加入 PYTHONPATH、PATH。
+func setupQGISEnvironment(qgisPath string) error {
+	if err := os.Setenv("QGIS_PREFIX_PATH", qgisPrefixPath(qgisPath)); err != nil {
+		return err
+	}
+	pathVars := map[string]string{
+		"PYTHONPATH": filepath.Join(qgisPath, "Contents", "Resources", "python"),
+		"PATH":       filepath.Join(qgisPath, "Contents", "MacOS", "bin"),
+	}
+	for key, dir := range pathVars {
+		if exists, _ := pathx.IsDir(dir); !exists {
+			continue
+		}
+		if err := os.Setenv(key, mergePathEnv(dir, os.Getenv(key))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package environ
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeDarwinBundle 在临时目录中构造一个最小的 QGIS 应用包。
+func fakeDarwinBundle(t *testing.T, bundle string) string {
+	t.Helper()
+	for _, d := range []string{"Contents/MacOS/bin", "Contents/Resources/python"} {
+		if err := os.MkdirAll(filepath.Join(bundle, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, exe := range []string{"Contents/MacOS/QGIS", "Contents/MacOS/bin/python3", "Contents/MacOS/bin/qgis_process"} {
+		if err := os.WriteFile(filepath.Join(bundle, exe), []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return bundle
+}
+
+func TestFindQGISPathDarwin(t *testing.T) {
+	base := t.TempDir()
+	bundle := fakeDarwinBundle(t, filepath.Join(base, "QGIS-LTR.app"))
+	t.Setenv("PATH", "")
+	t.Setenv("HOME", t.TempDir())
+
+	saved := darwinBundlePatterns
+	t.Cleanup(func() { darwinBundlePatterns = saved })
+	darwinBundlePatterns = []string{filepath.Join(base, "QGIS*.app")}
+
+	got, err := findQGISPath()
+	if err != nil || got != bundle {
+		t.Fatalf("findQGISPath() = %q, %v; want %q", got, err, bundle)
+	}
+
+	// PATH 中的 qgis_process 可回溯到应用包
+	darwinBundlePatterns = nil
+	t.Setenv("PATH", filepath.Join(bundle, "Contents", "MacOS", "bin"))
+	if got, err := findQGISPath(); err != nil || got != bundle {
+		t.Fatalf("findQGISPath() via PATH = %q, %v; want %q", got, err, bundle)
+	}
+}
+
+func TestResolveDarwinBundle(t *testing.T) {
+	bundle := fakeDarwinBundle(t, filepath.Join(t.TempDir(), "QGIS.app"))
+
+	if !isValidQGISPath(bundle) {
+		t.Fatalf("isValidQGISPath(%q) = false", bundle)
+	}
+	if got := rootFromPrefixPath(qgisPrefixPath(bundle)); got != bundle {
+		t.Errorf("rootFromPrefixPath = %q, want %q", got, bundle)
+	}
+	python, err := resolvePythonExecutable(bundle)
+	if want := filepath.Join(bundle, "Contents", "MacOS", "bin", "python3"); err != nil || python != want {
+		t.Errorf("resolvePythonExecutable = %q, %v; want %q", python, err, want)
+	}
+
+	for _, name := range qgisPathEnvVars {
+		t.Setenv(name, "")
+	}
+	got, err := resolveQGISPath(filepath.Join(bundle, "Contents", "MacOS"))
+	if err != nil || got != bundle {
+		t.Errorf("resolveQGISPath(prefix) = %q, %v; want %q", got, err, bundle)
+	}
+}
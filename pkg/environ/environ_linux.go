@@ -0,0 +1,94 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package environ
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"txt2geo/pkg/pathx"
+)
+
+// Linux 平台：依次查找 PATH 中的 qgis/qgis_process 与常见安装前缀；安装前缀即 QGIS_PREFIX_PATH。
+// QGIS 在 Linux 上使用系统 Python，安装前缀下没有 python3 时使用 PATH 中的 python3。
+
+// linuxInstallPrefixes 常见的 QGIS 安装前缀（支持通配符）：发行版软件包、源码编译的默认前缀与 /opt 下的独立安装。
+var linuxInstallPrefixes = []string{"/usr", "/usr/local", "/opt/qgis*"}
+
+// findQGISPath 查找 QGIS 安装前缀：先从 PATH 中的可执行文件回溯，再检查常见安装前缀。
+func findQGISPath() (string, error) {
+	if path, err := findFromPATH("qgis", "qgis_process"); err == nil {
+		return path, nil
+	}
+	if path, err := firstValidQGISPath(linuxInstallPrefixes); err == nil {
+		return path, nil
+	}
+	return "", fmt.Errorf("未找到QGIS安装路径，请确保QGIS已正确安装")
+}
+
+// isValidQGISPath 检查给定路径是否是一个有效的 QGIS 安装前缀。
+// 判断依据为前缀下存在 QGIS 的资源目录 share/qgis/resources 或 qgis/qgis_process 可执行文件。
+func isValidQGISPath(path string) bool {
+	if exists, _ := pathx.Exists(path); !exists {
+		return false
+	}
+	keyFiles := []string{
+		"share/qgis/resources",
+		"bin/qgis",
+		"bin/qgis_process",
+	}
+	for _, keyFile := range keyFiles {
+		if exists, _ := pathx.Exists(filepath.Join(path, keyFile)); exists {
+			return true
+		}
+	}
+	return false
+}
+
+// qgisPrefixPath 返回安装前缀对应的 QGIS_PREFIX_PATH，Linux 上即安装前缀本身。
+func qgisPrefixPath(qgisPath string) string {
+	return qgisPath
+}
+
+// rootFromPrefixPath Linux 上 prefix 路径即安装前缀，无需还原。
+func rootFromPrefixPath(string) string {
+	return ""
+}
+
+// resolvePythonExecutable 优先使用安装前缀下的 bin/python3，其次是 PATH 中的 python3。
+func resolvePythonExecutable(qgisPath string) (string, error) {
+	candidate := filepath.Join(qgisPath, "bin", "python3")
+	if exists, _ := pathx.Exists(candidate); exists {
+		return candidate, nil
+	}
+	if path, err := exec.LookPath("python3"); err == nil {
+		return path, nil
+	}
+	return "", fmt.Errorf("未在 QGIS 安装前缀或 PATH 中找到 python3: %s", qgisPath)
+}
+
+// setupQGISEnvironment 设置 QGIS_PREFIX_PATH，并将 QGIS 自带的 Python 目录与库目录加入 PYTHONPATH、LD_LIBRARY_PATH。
+// 系统软件包安装的 Python 绑定位于 dist-packages 中，无需额外配置。
+func setupQGISEnvironment(qgisPath string) error {
+	if err := os.Setenv("QGIS_PREFIX_PATH", qgisPath); err != nil {
+		return err
+	}
+	pathVars := [][2]string{{"PYTHONPATH", filepath.Join(qgisPath, "share", "qgis", "python")}}
+	// 系统前缀的库目录已在链接器默认搜索路径中
+	if qgisPath != "/usr" && qgisPath != "/usr/local" {
+		pathVars = append(pathVars, [2]string{"LD_LIBRARY_PATH", filepath.Join(qgisPath, "lib")})
+	}
+	for _, kv := range pathVars {
+		key, dir := kv[0], kv[1]
+		if exists, _ := pathx.IsDir(dir); !exists {
+			continue
+		}
+		if err := os.Setenv(key, mergePathEnv(dir, os.Getenv(key))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
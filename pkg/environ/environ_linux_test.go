@@ -0,0 +1,130 @@
+package environ
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeLinuxInstall 在临时目录中构造一个最小的 QGIS 安装前缀。
+func fakeLinuxInstall(t *testing.T, dir string) string {
+	t.Helper()
+	for _, d := range []string{"bin", "lib", "share/qgis/resources", "share/qgis/python"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, exe := range []string{"bin/qgis", "bin/python3"} {
+		if err := os.WriteFile(filepath.Join(dir, exe), []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// isolateEnv 清空与 QGIS 查找相关的环境变量，测试结束后自动恢复。
+func isolateEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range append([]string{"PATH", "PYTHONPATH", "LD_LIBRARY_PATH"}, qgisPathEnvVars...) {
+		t.Setenv(name, "")
+	}
+	ResetEnvironmentCache()
+	t.Cleanup(ResetEnvironmentCache)
+}
+
+func TestIsValidQGISPathLinux(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, dir string)
+		want  bool
+	}{
+		{"完整安装", func(t *testing.T, dir string) { fakeLinuxInstall(t, dir) }, true},
+		{"仅资源目录", func(t *testing.T, dir string) {
+			if err := os.MkdirAll(filepath.Join(dir, "share/qgis/resources"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+		}, true},
+		{"空目录", func(*testing.T, string) {}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tt.setup(t, dir)
+			if got := isValidQGISPath(dir); got != tt.want {
+				t.Errorf("isValidQGISPath(%q) = %v, want %v", dir, got, tt.want)
+			}
+		})
+	}
+	if isValidQGISPath(filepath.Join(t.TempDir(), "missing")) {
+		t.Error("不存在的目录不应视为有效安装")
+	}
+}
+
+func TestFindQGISPathFromPATH(t *testing.T) {
+	isolateEnv(t)
+	root := fakeLinuxInstall(t, t.TempDir())
+	t.Setenv("PATH", filepath.Join(root, "bin"))
+
+	got, err := findQGISPath()
+	if err != nil || got != root {
+		t.Fatalf("findQGISPath() = %q, %v; want %q", got, err, root)
+	}
+}
+
+func TestFindQGISPathFromInstallPrefixes(t *testing.T) {
+	isolateEnv(t)
+	base := t.TempDir()
+	root := fakeLinuxInstall(t, filepath.Join(base, "qgis-3.34"))
+
+	saved := linuxInstallPrefixes
+	t.Cleanup(func() { linuxInstallPrefixes = saved })
+	linuxInstallPrefixes = []string{filepath.Join(base, "missing"), filepath.Join(base, "qgis*")}
+
+	got, err := findQGISPath()
+	if err != nil || got != root {
+		t.Fatalf("findQGISPath() = %q, %v; want %q", got, err, root)
+	}
+
+	linuxInstallPrefixes = []string{filepath.Join(base, "missing")}
+	if _, err := findQGISPath(); err == nil {
+		t.Fatal("没有安装时应返回错误")
+	}
+}
+
+func TestInitializeQGISEnvironmentLinux(t *testing.T) {
+	isolateEnv(t)
+	root := fakeLinuxInstall(t, t.TempDir())
+
+	prefix, python, err := InitializeQGISEnvironmentWithPath(root)
+	if err != nil {
+		t.Fatalf("InitializeQGISEnvironmentWithPath: %v", err)
+	}
+	if prefix != root {
+		t.Errorf("prefixPath = %q, want %q", prefix, root)
+	}
+	if want := filepath.Join(root, "bin", "python3"); python != want {
+		t.Errorf("pythonPath = %q, want %q", python, want)
+	}
+	if got := os.Getenv("QGIS_PREFIX_PATH"); got != root {
+		t.Errorf("QGIS_PREFIX_PATH = %q, want %q", got, root)
+	}
+	if got, want := os.Getenv("PYTHONPATH"), filepath.Join(root, "share", "qgis", "python"); got != want {
+		t.Errorf("PYTHONPATH = %q, want %q", got, want)
+	}
+	if got, want := os.Getenv("LD_LIBRARY_PATH"), filepath.Join(root, "lib"); got != want {
+		t.Errorf("LD_LIBRARY_PATH = %q, want %q", got, want)
+	}
+}
+
+func TestInitializeQGISEnvironmentInvalidOverride(t *testing.T) {
+	isolateEnv(t)
+	// 即使能自动查找到安装，无效的显式路径也应直接报错
+	root := fakeLinuxInstall(t, t.TempDir())
+	t.Setenv("PATH", filepath.Join(root, "bin"))
+
+	_, _, err := InitializeQGISEnvironmentWithPath(t.TempDir())
+	if !errors.Is(err, ErrQGISInvalidPath) {
+		t.Fatalf("err = %v, want ErrQGISInvalidPath", err)
+	}
+}
@@ -0,0 +1,58 @@
+package environ
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// countInit 将 initEnvironment 替换为计数的伪实现：override 为空时返回 ErrQGISNotFound，否则返回固定路径。
+func countInit(t *testing.T) map[string]*atomic.Int32 {
+	t.Helper()
+	calls := map[string]*atomic.Int32{"": {}, "/opt/qgis": {}}
+	orig := initEnvironment
+	initEnvironment = func(override string) (string, string, error) {
+		calls[override].Add(1)
+		if override == "" {
+			return "", "", ErrQGISNotFound
+		}
+		return override + "/prefix", override + "/bin/python3", nil
+	}
+	ResetEnvironmentCache()
+	t.Cleanup(func() {
+		initEnvironment = orig
+		ResetEnvironmentCache()
+	})
+	return calls
+}
+
+func TestInitializeQGISEnvironmentOnce(t *testing.T) {
+	calls := countInit(t)
+
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Go(func() {
+			prefix, python, err := InitializeQGISEnvironmentWithPath("/opt/qgis")
+			if err != nil || prefix != "/opt/qgis/prefix" || python != "/opt/qgis/bin/python3" {
+				t.Errorf("InitializeQGISEnvironmentWithPath = %q, %q, %v", prefix, python, err)
+			}
+			if _, _, err := InitializeQGISEnvironment(); !errors.Is(err, ErrQGISNotFound) {
+				t.Errorf("InitializeQGISEnvironment err = %v, want ErrQGISNotFound", err)
+			}
+		})
+	}
+	wg.Wait()
+	for override, n := range calls {
+		if got := n.Load(); got != 1 {
+			t.Errorf("override=%q 的初始化次数 = %d, want 1（错误结果同样缓存）", override, got)
+		}
+	}
+
+	// 清空缓存后重新执行查找
+	ResetEnvironmentCache()
+	InitializeQGISEnvironmentWithPath("/opt/qgis")
+	if got := calls["/opt/qgis"].Load(); got != 2 {
+		t.Errorf("ResetEnvironmentCache 后初始化次数 = %d, want 2", got)
+	}
+}
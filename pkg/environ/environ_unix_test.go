@@ -0,0 +1,52 @@
+//go:build linux || darwin
+
+package environ
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakePython 在临时目录中写入一个以 script 为内容的可执行 sh 脚本，用于替代 Python 解释器。
+func fakePython(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "python3")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestVerifyQGISPython(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		prefix  string
+		wantErr string // 为空表示检查通过
+	}{
+		{"导入成功", "exit 0", "", ""},
+		{"导入失败", "echo 'Traceback (most recent call last):' >&2\necho \"ModuleNotFoundError: No module named 'qgis'\" >&2\nexit 1", "", "ModuleNotFoundError: No module named 'qgis'"},
+		{"无输出的非零退出", "exit 3", "", "exit status 3"},
+		{"传入 QGIS_PREFIX_PATH", `[ "$QGIS_PREFIX_PATH" = /opt/qgis ] || exit 1`, "/opt/qgis", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyQGISPython(fakePython(t, tt.script), tt.prefix)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("VerifyQGISPython: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrQGISPython) {
+				t.Fatalf("err = %v, want ErrQGISPython", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
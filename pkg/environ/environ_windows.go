@@ -0,0 +1,222 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package environ
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"txt2geo/pkg/pathx"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// Windows 平台：通过注册表与各驱动器上的常见安装目录（OSGeo4W、Program Files\QGIS*）查找 QGIS，
+// 环境变量取自安装目录下的 bin/qgis-bin.env。
+
+// qgisPrefixPath 返回安装根目录对应的 QGIS_PREFIX_PATH（<安装目录>/apps/qgis）。
+func qgisPrefixPath(qgisPath string) string {
+	return filepath.Join(qgisPath, "apps", "qgis")
+}
+
+// rootFromPrefixPath 将指向 <安装目录>/apps/qgis 的 prefix 路径还原为安装根目录，不是 prefix 路径时返回空。
+func rootFromPrefixPath(path string) string {
+	if strings.EqualFold(filepath.Base(path), "qgis") && strings.EqualFold(filepath.Base(filepath.Dir(path)), "apps") {
+		return filepath.Dir(filepath.Dir(path))
+	}
+	return ""
+}
+
+// findQGISPath 负责按顺序从不同来源查找 QGIS 的安装根目录。
+// 它首先检查 Windows 注册表，如果找不到，则会搜索常见的安装目录。
+// 返回找到的路径或一个错误。
+func findQGISPath() (string, error) {
+	// 1. 注册表
+	registryKeys := []string{
+		"QGIS Project\\Shell\\open\\command",
+		"QGIS Project\\DefaultIcon",
+	}
+	if path, err := findFromRegistry(registryKeys); err == nil {
+		return path, nil
+	}
+	// 2. 常见安装位置（含通配符）
+	commonPaths := []string{"OSGeo4W", "Program Files\\QGIS*"}
+	if path, err := findFromCommonPaths(commonPaths); err == nil {
+		return path, nil
+	}
+	return "", fmt.Errorf("未找到QGIS安装路径，请确保QGIS已正确安装")
+}
+
+// findFromRegistry 通过查询 Windows 注册表中的预定义键来定位 QGIS 安装路径。
+// 它会遍历 `keyPaths` 中的每个键，直到找到一个有效路径为止。
+func findFromRegistry(keyPaths []string) (string, error) {
+	for _, keyPath := range keyPaths {
+		key, err := registry.OpenKey(registry.CLASSES_ROOT, keyPath, windows.KEY_READ)
+		if err != nil {
+			continue
+		}
+		defer key.Close()
+
+		value, _, err := key.GetStringValue("")
+		if err != nil {
+			continue
+		}
+
+		// 清理路径并验证
+		trimmedPath := strings.Trim(value, "\"")
+		if trimmedPath == "" {
+			continue
+		}
+		// 提取QGIS根目录路径
+		qgisRootDir := extractQGISRootPath(trimmedPath)
+		if qgisRootDir == "" {
+			continue
+		}
+
+		// 转换为标准长路径格式
+		longPath, err := pathx.GetLongPathName(qgisRootDir)
+		if err != nil {
+			// 转换失败，直接用原路径，库代码不输出
+			longPath = qgisRootDir
+		}
+
+		// 验证是否为有效的QGIS根目录
+		if isValidQGISPath(longPath) {
+			return longPath, nil
+		}
+	}
+	return "", fmt.Errorf("未在注册表中找到QGIS安装路径")
+}
+
+// findFromCommonPaths 遍历系统的所有逻辑驱动器和一组常见的 QGIS 安装目录名来查找 QGIS。
+// 支持通配符路径，例如 "Program Files\\QGIS*"。
+func findFromCommonPaths(commonPaths []string) (string, error) {
+	drivers, err := pathx.GetLogicalDrives()
+	if err != nil {
+		return "", fmt.Errorf("获取逻辑驱动器失败: %w", err)
+	}
+
+	for _, drive := range drivers {
+		for _, commonPath := range commonPaths {
+			fullPath := filepath.Join(drive, commonPath)
+
+			// 处理通配符路径
+			if strings.Contains(commonPath, "*") {
+				matches, err := filepath.Glob(fullPath)
+				if err != nil {
+					continue
+				}
+				for _, match := range matches {
+					if isValidQGISPath(match) {
+						return match, nil
+					}
+				}
+			} else {
+				// 直接路径检查
+				if isValidQGISPath(fullPath) {
+					return fullPath, nil
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("未在常见路径中找到QGIS安装")
+}
+
+// resolvePythonExecutable 在给定的 QGIS 安装目录下定位 Python 解释器可执行文件。
+func resolvePythonExecutable(qgisPath string) (string, error) {
+	candidates := []string{
+		filepath.Join(qgisPath, "bin", "python3.exe"),
+		filepath.Join(qgisPath, "bin", "python.exe"),
+	}
+	for _, candidate := range candidates {
+		if exists, _ := pathx.Exists(candidate); exists {
+			return candidate, nil
+		}
+	}
+
+	pattern := filepath.Join(qgisPath, "bin", "python*.exe")
+	matches, err := filepath.Glob(pattern)
+	if err == nil {
+		for _, match := range matches {
+			if exists, _ := pathx.Exists(match); exists {
+				return match, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("未在 QGIS 安装目录中找到 python 可执行文件: %s", qgisPath)
+}
+
+// isValidQGISPath 检查给定路径是否是一个有效的 QGIS 安装目录。
+// 它通过检查目录下是否存在一些关键文件（如 "OSGeo4W.bat"）来做出判断。
+func isValidQGISPath(path string) bool {
+	if exists, _ := pathx.Exists(path); !exists {
+		return false
+	}
+
+	// 检查其他关键文件
+	keyFiles := []string{
+		"OSGeo4W.bat",
+		"bin/python3.exe",
+		"apps/qgis/python",
+		"bin/qgis.bat",
+	}
+	for _, keyFile := range keyFiles {
+		if exists, _ := pathx.Exists(filepath.Join(path, keyFile)); exists {
+			return true
+		}
+	}
+	return false
+}
+
+// setupQGISEnvironment 根据找到的 QGIS 安装路径，为当前进程配置所需的环境变量。
+// 它会读取 .env 文件，并合并 PATH 和 PYTHONPATH 等路径变量。
+func setupQGISEnvironment(qgisPath string) error {
+	// 读取QGIS环境变量文件
+	envFile := filepath.Join(qgisPath, "bin", "qgis-bin.env")
+	envVars, err := parseEnvFile(envFile)
+	if err != nil {
+		return fmt.Errorf("解析环境文件失败: %w", err)
+	}
+
+	// 添加QGIS Python路径到PYTHONPATH
+	pythonPath := filepath.Join(qgisPath, "apps", "qgis", "python")
+	if _, err := os.Stat(pythonPath); err == nil {
+		currentPythonPath := os.Getenv("PYTHONPATH")
+		if currentPythonPath == "" {
+			envVars["PYTHONPATH"] = pythonPath
+		} else {
+			envVars["PYTHONPATH"] = pythonPath + ";" + currentPythonPath
+		}
+	}
+
+	// 需要合并追加的路径型环境变量
+	pathVars := map[string]struct{}{
+		"PATH":       {},
+		"PYTHONPATH": {},
+	}
+
+	for key, value := range envVars {
+		upperKey := strings.ToUpper(key)
+		if _, ok := pathVars[upperKey]; ok {
+			// 路径型变量，合并去重
+			oldVal := os.Getenv(upperKey)
+			merged := mergePathEnv(value, oldVal)
+			if err := os.Setenv(upperKey, merged); err != nil {
+				// 设置失败，库代码不输出
+			}
+		} else {
+			// 普通变量，直接覆盖
+			if err := os.Setenv(upperKey, value); err != nil {
+				// 设置失败，库代码不输出
+			}
+		}
+	}
+
+	// QGIS环境设置完成，库代码不输出
+	return nil
+}
@@ -0,0 +1,46 @@
+package environ
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeWindowsInstall 在临时目录中构造一个最小的 OSGeo4W 风格 QGIS 安装目录。
+func fakeWindowsInstall(t *testing.T, dir string) string {
+	t.Helper()
+	for _, d := range []string{"bin", "apps/qgis/python"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []string{"OSGeo4W.bat", "bin/python3.exe", "bin/qgis-bin.env"} {
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestResolveWindowsInstall(t *testing.T) {
+	root := fakeWindowsInstall(t, t.TempDir())
+
+	if !isValidQGISPath(root) {
+		t.Fatalf("isValidQGISPath(%q) = false", root)
+	}
+	if isValidQGISPath(t.TempDir()) {
+		t.Error("空目录不应视为有效安装")
+	}
+	python, err := resolvePythonExecutable(root)
+	if want := filepath.Join(root, "bin", "python3.exe"); err != nil || python != want {
+		t.Errorf("resolvePythonExecutable = %q, %v; want %q", python, err, want)
+	}
+
+	for _, name := range qgisPathEnvVars {
+		t.Setenv(name, "")
+	}
+	got, err := resolveQGISPath(qgisPrefixPath(root))
+	if err != nil || got != root {
+		t.Errorf("resolveQGISPath(prefix) = %q, %v; want %q", got, err, root)
+	}
+}
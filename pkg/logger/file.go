@@ -0,0 +1,117 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// rotatingFile 是按大小轮转的日志文件：写入后超过 maxSize 字节时，将当前文件重命名为 "<path>.1" 并重新创建。
+type rotatingFile struct {
+	path    string
+	maxSize int64 // 0 表示不轮转
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// openRotatingFile 以追加方式打开（或创建）日志文件。
+func openRotatingFile(path string, maxSize int64) (*rotatingFile, error) {
+	rf := &rotatingFile{path: path, maxSize: maxSize}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *rotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("无法打开日志文件 %s: %w", rf.path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("无法读取日志文件 %s: %w", rf.path, err)
+	}
+	rf.file, rf.size = f, info.Size()
+	return nil
+}
+
+// Write 实现 io.Writer；一次写入即一条完整日志，轮转只发生在两条日志之间。
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.maxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// rotate 关闭当前文件，将其重命名为 "<path>.1"（覆盖旧的备份）后重新打开；调用方需持有锁。
+func (rf *rotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return fmt.Errorf("无法关闭日志文件 %s: %w", rf.path, err)
+	}
+	if err := os.Rename(rf.path, rf.path+".1"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("无法轮转日志文件 %s: %w", rf.path, err)
+	}
+	return rf.open()
+}
+
+// Close 关闭日志文件。
+func (rf *rotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	return rf.file.Close()
+}
+
+// multiHandler 将每条日志分发给多个 slog.Handler，例如同时输出到终端和日志文件。
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range m {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	hs := make(multiHandler, len(m))
+	for i, h := range m {
+		hs[i] = h.WithAttrs(attrs)
+	}
+	return hs
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	hs := make(multiHandler, len(m))
+	for i, h := range m {
+		hs[i] = h.WithGroup(name)
+	}
+	return hs
+}
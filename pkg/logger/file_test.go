@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	line := strings.Repeat("x", 59) + "\n" // 60 字节
+	tests := []struct {
+		name        string
+		maxSize     int64
+		writes      int
+		wantCurrent int // 当前文件中的日志条数
+		wantBackup  int // "<path>.1" 中的日志条数，-1 表示不应存在
+	}{
+		{"未超过阈值", 200, 3, 3, -1},
+		{"超过阈值后轮转", 100, 2, 1, 1},
+		{"多次轮转只保留一个备份", 100, 5, 1, 1},
+		{"不轮转", 0, 5, 5, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			rf, err := openRotatingFile(path, tt.maxSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range tt.writes {
+				if _, err := rf.Write([]byte(line)); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+			if err := rf.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := countLines(t, path); got != tt.wantCurrent {
+				t.Errorf("当前文件条数 = %d, want %d", got, tt.wantCurrent)
+			}
+			backup := path + ".1"
+			if tt.wantBackup < 0 {
+				if _, err := os.Stat(backup); !os.IsNotExist(err) {
+					t.Errorf("不应生成备份文件 %s: %v", backup, err)
+				}
+				return
+			}
+			if got := countLines(t, backup); got != tt.wantBackup {
+				t.Errorf("备份文件条数 = %d, want %d", got, tt.wantBackup)
+			}
+		})
+	}
+}
+
+func TestRotatingFileReopenKeepsSize(t *testing.T) {
+	// 重新打开已有文件时应计入原有大小，下一次写入超过阈值即轮转
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", 90)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rf, err := openRotatingFile(path, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rf.Write([]byte("0123456789abc\n")); err != nil {
+		t.Fatal(err)
+	}
+	rf.Close()
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Fatalf("超过阈值后应生成备份文件: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "0123456789abc\n" {
+		t.Errorf("当前文件内容 = %q, want 仅包含轮转后的新日志", data)
+	}
+}
+
+// countLines 返回文件中的行数。
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Count(string(data), "\n")
+}
@@ -4,27 +4,52 @@ Copyright © 2025 TheMachine <592858548@qq.com>
 package logger
 
 import (
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/lmittmann/tint"
 	"golang.org/x/term"
 )
 
 var (
-	log  *slog.Logger
+	log  atomic.Pointer[slog.Logger] // 全局 logger；Init 原子替换，Log 原子读取，可与并发日志调用同时重新配置
 	once sync.Once
+
+	fileMu  sync.Mutex
+	logFile *rotatingFile // 当前全局 logger 写入的日志文件，重新初始化时关闭
 )
 
 const DateTimeMilli = "2006-01-02 15:04:05.000"
 
+// Options 是全局日志的初始化选项。
+type Options struct {
+	// Level 日志级别：debug|info|warn|error，默认 info
+	Level string
+	// Format 输出格式：text（默认，终端带颜色）|json（每行一个 JSON 对象，便于 ELK/Loki 等采集）
+	Format string
+	// File 非空时同时将日志（无颜色）写入该文件，格式与 Format 一致
+	File string
+	// MaxSizeMB 日志文件超过该大小（MB）时轮转为 "<File>.1"，0 表示不轮转
+	MaxSizeMB int
+}
+
 // Init 根据级别初始化全局日志。
 // level: debug|info|warn|error
 func Init(level string) {
+	// 仅输出到终端时不会失败
+	_ = InitWithOptions(Options{Level: level})
+}
+
+// InitWithOptions 根据 opts 初始化全局日志：始终输出到终端，配置了 File 时同时写入日志文件。
+// Format 无效或日志文件打开失败时返回错误，此时全局日志仍以文本格式、仅终端输出的方式完成初始化。
+func InitWithOptions(opts Options) error {
 	lvl := slog.LevelInfo
-	logLevelStr := strings.ToLower(strings.TrimSpace(level))
+	logLevelStr := strings.ToLower(strings.TrimSpace(opts.Level))
 
 	switch logLevelStr {
 	case "debug":
@@ -35,7 +60,25 @@ func Init(level string) {
 		lvl = slog.LevelError
 	}
 
-	handler := tint.NewHandler(os.Stdout, &tint.Options{
+	logFormat := strings.ToLower(strings.TrimSpace(opts.Format))
+	if logFormat != "" && logFormat != "text" && logFormat != "json" {
+		Init(opts.Level)
+		return fmt.Errorf("不支持的日志格式: %s (可选 text、json)", opts.Format)
+	}
+	handlerOpts := &slog.HandlerOptions{
+		AddSource:   logLevelStr == "debug",
+		Level:       lvl,
+		ReplaceAttr: formatTime,
+	}
+	// newHandler 按格式创建写入 w 的无颜色 handler
+	newHandler := func(w io.Writer) slog.Handler {
+		if logFormat == "json" {
+			return slog.NewJSONHandler(w, handlerOpts)
+		}
+		return slog.NewTextHandler(w, handlerOpts)
+	}
+
+	var handler slog.Handler = tint.NewHandler(os.Stdout, &tint.Options{
 		AddSource:  logLevelStr == "debug",
 		Level:      lvl,
 		NoColor:    !isTerminalColorSupported(),
@@ -51,7 +94,35 @@ func Init(level string) {
 		// },
 	})
 
-	log = slog.New(handler)
+	if logFormat == "json" {
+		handler = newHandler(os.Stdout)
+	}
+
+	var err error
+	var rf *rotatingFile
+	if opts.File != "" {
+		if rf, err = openRotatingFile(opts.File, int64(opts.MaxSizeMB)<<20); err == nil {
+			handler = multiHandler{handler, newHandler(rf)}
+		}
+	}
+
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	log.Store(slog.New(handler))
+	// 新 logger 生效后再关闭旧文件，避免每次重新配置泄漏一个文件句柄
+	if logFile != nil {
+		logFile.Close()
+	}
+	logFile = rf
+	return err
+}
+
+// formatTime 将日志时间格式化为 DateTimeMilli，与终端输出保持一致。
+func formatTime(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.TimeKey && len(groups) == 0 && a.Value.Kind() == slog.KindTime {
+		return slog.String(a.Key, a.Value.Time().Format(DateTimeMilli))
+	}
+	return a
 }
 
 // isTerminalColorSupported checks if terminal supports color output
@@ -67,7 +138,7 @@ func isTerminalColorSupported() bool {
 // ensure 初始化默认 logger（仅在第一次访问且未手动 Init 时）。
 func ensure() {
 	once.Do(func() {
-		if log == nil {
+		if log.Load() == nil {
 			Init("info") // 默认级别
 		}
 	})
@@ -76,7 +147,7 @@ func ensure() {
 // L 返回全局 logger。
 func Log() *slog.Logger {
 	ensure()
-	return log
+	return log.Load()
 }
 
 // Helper wrappers
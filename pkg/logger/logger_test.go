@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInitWithOptionsJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := InitWithOptions(Options{Level: "info", Format: "json", File: path}); err != nil {
+		t.Fatalf("InitWithOptions: %v", err)
+	}
+	t.Cleanup(func() { Init("info") })
+
+	Log().Info("导出完成", "文件", 3)
+	Log().Debug("低于级别，不应输出")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("日志行数 = %d, want 1: %q", len(lines), data)
+	}
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatalf("日志行不是合法 JSON: %v: %s", err, lines[0])
+	}
+	want := map[string]any{"level": "INFO", "msg": "导出完成", "文件": float64(3)}
+	for k, v := range want {
+		if rec[k] != v {
+			t.Errorf("%s = %v, want %v", k, rec[k], v)
+		}
+	}
+	ts, _ := rec["time"].(string)
+	if _, err := time.Parse(DateTimeMilli, ts); err != nil {
+		t.Errorf("time = %q, want 格式 %s: %v", ts, DateTimeMilli, err)
+	}
+}
+
+func TestInitWithOptionsInvalidFormat(t *testing.T) {
+	t.Cleanup(func() { Init("info") })
+	if err := InitWithOptions(Options{Format: "xml"}); err == nil {
+		t.Fatal("不支持的格式应返回错误")
+	}
+	if Log() == nil {
+		t.Fatal("格式无效时仍应完成初始化")
+	}
+}
+
+func TestConcurrentInitAndLog(t *testing.T) {
+	// 需配合 go test -race 运行：Init 与 Log 并发调用不应产生数据竞争
+	t.Cleanup(func() { Init("info") })
+	levels := []string{"debug", "info", "warn", "error"}
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			for j := range 50 {
+				Init(levels[(i+j)%len(levels)])
+			}
+		})
+		wg.Go(func() {
+			for j := range 50 {
+				Log().Debug("并发日志", "goroutine", i, "序号", j)
+			}
+		})
+	}
+	wg.Wait()
+	if Log() == nil {
+		t.Fatal("Log() = nil")
+	}
+}
+
+func TestInitWithOptionsClosesPreviousFile(t *testing.T) {
+	t.Cleanup(func() { Init("info") })
+	dir := t.TempDir()
+	if err := InitWithOptions(Options{File: filepath.Join(dir, "a.log")}); err != nil {
+		t.Fatalf("InitWithOptions: %v", err)
+	}
+	first := logFile
+
+	if err := InitWithOptions(Options{File: filepath.Join(dir, "b.log")}); err != nil {
+		t.Fatalf("InitWithOptions: %v", err)
+	}
+	if _, err := first.Write([]byte("x\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("重新初始化后旧日志文件写入 err = %v, want os.ErrClosed", err)
+	}
+	second := logFile
+	if second == nil || second == first {
+		t.Fatal("logFile 应指向新的日志文件")
+	}
+
+	// 改为仅输出到终端时同样关闭之前的文件
+	Init("info")
+	if _, err := second.Write([]byte("x\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Init 后旧日志文件写入 err = %v, want os.ErrClosed", err)
+	}
+	if logFile != nil {
+		t.Errorf("仅终端输出时 logFile = %v, want nil", logFile)
+	}
+}
@@ -31,6 +31,45 @@ var (
 // 默认最大长度 (0 表示不截断)。可根据需要调整。
 const DefaultMaxNameLength = 52
 
+// SanitizeOptions 控制 SanitizeWithOptions 的行为。
+type SanitizeOptions struct {
+	// MaxLength 结果的最大 rune 数，包含唯一性后缀 (_N)；0 表示不截断。
+	// 不同目标的限制不同，例如 Shapefile 字段名为 10，GPKG 图层名则宽松得多。
+	MaxLength int
+	// ASCIIOnly 为 true 时非 ASCII 字母与数字（如中文）按非法字符处理，替换为下划线，
+	// 适用于 Shapefile 等无法处理非 ASCII 名称的目标；默认保留中文等字符。
+	ASCIIOnly bool
+	// Transliterate 为 true 时在清理前将常用汉字转写为不带声调的拼音（如 "耕地" -> "gengdi"），
+	// 与 ASCIIOnly 配合使用可避免中文名称全部变为下划线。
+	Transliterate bool
+	// Reserved 非 nil 时完全替代内置的保留字集合；ExtraReserved 在此基础上追加。
+	// 与保留字冲突的名称前加下划线，比较不区分大小写。
+	Reserved      map[string]struct{}
+	ExtraReserved map[string]struct{}
+}
+
+// isReserved 判断小写名称是否与保留字冲突：Reserved（为 nil 时使用内置 blacklist）与 ExtraReserved 的并集。
+func (o SanitizeOptions) isReserved(lower string) bool {
+	base := o.Reserved
+	if base == nil {
+		base = blacklist
+	}
+	return containsFold(base, lower) || containsFold(o.ExtraReserved, lower)
+}
+
+// containsFold 不区分大小写地判断 set 是否包含 lower；调用方提供的集合键不要求小写。
+func containsFold(set map[string]struct{}, lower string) bool {
+	if _, ok := set[lower]; ok {
+		return true
+	}
+	for k := range set {
+		if strings.EqualFold(k, lower) {
+			return true
+		}
+	}
+	return false
+}
+
 // Sanitize 将任意文件或图层名转换为一个安全的、可用于标识符的字符串。
 //
 // 处理流程:
@@ -52,7 +91,8 @@ const DefaultMaxNameLength = 52
 //	一个非空的、仅包含字母、数字和下划线的安全字符串。
 //
 // normalizeAndFold 负责：NFKC + 保留字母数字和下划线 + 合并非法段为单下划线 + 去首尾下划线。
-func normalizeAndFold(s string) string {
+// asciiOnly 为 true 时仅保留 ASCII 字母数字。
+func normalizeAndFold(s string, asciiOnly bool) string {
 	if s == "" {
 		return ""
 	}
@@ -61,7 +101,7 @@ func normalizeAndFold(s string) string {
 	b.Grow(len(s))
 	prevUnderscore := false
 	for _, r := range s {
-		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+		if r == '_' || (unicode.IsLetter(r) || unicode.IsDigit(r)) && (!asciiOnly || r < utf8.RuneSelf) {
 			b.WriteRune(r)
 			prevUnderscore = false
 			continue
@@ -97,8 +137,27 @@ func truncateRunes(s string, max int) string {
 	return s
 }
 
-// Sanitize 规范化名称并在 providedUsed 非空时确保唯一性。
+// fitSuffix 截短 base，使其追加 suffix 后不超过 max 个 rune；max 为 0 时不截断。
+// 截短后去掉末尾的下划线，避免出现 "a__1" 这样的名称。
+func fitSuffix(base, suffix string, max int) string {
+	if max <= 0 {
+		return base
+	}
+	room := max - utf8.RuneCountInString(suffix)
+	if room <= 0 {
+		return ""
+	}
+	return strings.TrimRight(truncateRunes(base, room), "_")
+}
+
+// Sanitize 规范化名称并在 providedUsed 非空时确保唯一性，最大长度为 DefaultMaxNameLength。
 func Sanitize(filePath string, providedUsed map[string]struct{}) string {
+	return SanitizeWithOptions(filePath, providedUsed, SanitizeOptions{MaxLength: DefaultMaxNameLength})
+}
+
+// SanitizeWithOptions 与 Sanitize 相同，但最大长度等由 opts 指定。
+// 需要追加唯一性后缀时先截短基础名称，保证结果仍不超过 MaxLength。
+func SanitizeWithOptions(filePath string, providedUsed map[string]struct{}, opts SanitizeOptions) string {
 	name := strings.TrimSpace(filePath)
 	if name == "" {
 		return "unnamed"
@@ -110,22 +169,21 @@ func Sanitize(filePath string, providedUsed map[string]struct{}) string {
 		stem = base
 	}
 
-	normalized := normalizeAndFold(stem)
+	if opts.Transliterate {
+		stem = transliterate(stem)
+	}
+	normalized := normalizeAndFold(stem, opts.ASCIIOnly)
 	if normalized == "" {
 		normalized = "unnamed"
 	}
 
-	lower := strings.ToLower(normalized)
-	if r, _ := utf8.DecodeRuneInString(normalized); unicode.IsDigit(r) || len(lower) > 0 && blacklist[lower] != struct{}{} { // 保留原逻辑判断黑名单
-		if _, exists := blacklist[lower]; exists || unicode.IsDigit(r) {
-			normalized = "_" + normalized
-		}
-	} else if _, exists := blacklist[lower]; exists {
+	// 以数字开头或与保留字冲突时加下划线前缀
+	if r, _ := utf8.DecodeRuneInString(normalized); unicode.IsDigit(r) || opts.isReserved(strings.ToLower(normalized)) {
 		normalized = "_" + normalized
 	}
 
-	if DefaultMaxNameLength > 0 {
-		normalized = truncateRunes(normalized, DefaultMaxNameLength)
+	if opts.MaxLength > 0 {
+		normalized = truncateRunes(normalized, opts.MaxLength)
 		normalized = strings.TrimRight(normalized, "_")
 		if normalized == "" {
 			normalized = "unnamed"
@@ -144,7 +202,8 @@ func Sanitize(filePath string, providedUsed map[string]struct{}) string {
 
 	original := normalized
 	for i := 1; ; i++ { // 从 1 开始更直观
-		cand := fmt.Sprintf("%s_%d", original, i)
+		suffix := fmt.Sprintf("_%d", i)
+		cand := fitSuffix(original, suffix, opts.MaxLength) + suffix
 		if _, exists := providedUsed[cand]; !exists {
 			providedUsed[cand] = struct{}{}
 			return cand
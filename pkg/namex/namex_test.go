@@ -0,0 +1,146 @@
+package namex
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizePrefix(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  SanitizeOptions
+		want  string
+	}{
+		{"数字开头", "123.txt", SanitizeOptions{}, "_123"},
+		{"数字开头的中文名", "1号地块.txt", SanitizeOptions{}, "_1号地块"},
+		{"全角数字开头", "１abc", SanitizeOptions{}, "_1abc"},
+		{"保留字", "select.txt", SanitizeOptions{}, "_select"},
+		{"保留字不区分大小写", "SELECT", SanitizeOptions{}, "_SELECT"},
+		{"GIS 字段名", "Area.shp", SanitizeOptions{}, "_Area"},
+		{"清理后才以数字开头", "__9a", SanitizeOptions{}, "_9a"},
+		{"包含保留字但不相等", "selection", SanitizeOptions{}, "selection"},
+		{"普通名称", "parcel_1", SanitizeOptions{}, "parcel_1"},
+		{"中文名称", "耕地", SanitizeOptions{}, "耕地"},
+		{"拼音转写不加前缀", "耕地", SanitizeOptions{Transliterate: true, ASCIIOnly: true}, "gengdi"},
+		{"拼音转写后数字开头", "3号地", SanitizeOptions{Transliterate: true, ASCIIOnly: true}, "_3haodi"},
+		{"自定义保留字", "parcel", SanitizeOptions{ExtraReserved: map[string]struct{}{"Parcel": {}}}, "_parcel"},
+		{"替换内置保留字", "select", SanitizeOptions{Reserved: map[string]struct{}{}}, "select"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeWithOptions(tt.input, nil, tt.opts); got != tt.want {
+				t.Errorf("SanitizeWithOptions(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeMaxLengthCollisions(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		n     int
+		want  map[int]string // 第 i 次（从 0 开始）调用的期望结果
+	}{
+		{"ASCII 长名称", "parcelname_long.txt", 1001, map[int]string{
+			0: "parcelname", 1: "parcelna_1", 9: "parcelna_9",
+			10: "parceln_10", 99: "parceln_99", 100: "parcel_100", 1000: "parce_1000",
+		}},
+		{"截短后末尾为下划线", "abcdefg_hij", 2, map[int]string{0: "abcdefg_hi", 1: "abcdefg_1"}},
+		{"中文按 rune 计数", "地块地块地块地块地块地块", 11, map[int]string{
+			0: "地块地块地块地块地块", 1: "地块地块地块地块_1", 10: "地块地块地块地_10",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			used := map[string]struct{}{}
+			for i := range tt.n {
+				got := SanitizeWithOptions(tt.input, used, SanitizeOptions{MaxLength: 10})
+				if n := utf8.RuneCountInString(got); n > 10 {
+					t.Fatalf("第 %d 个名称 %q 长度 %d 超过 10", i, got, n)
+				}
+				if want, ok := tt.want[i]; ok && got != want {
+					t.Errorf("第 %d 个名称 = %q, want %q", i, got, want)
+				}
+			}
+			if len(used) != tt.n {
+				t.Errorf("唯一名称数 = %d, want %d", len(used), tt.n)
+			}
+		})
+	}
+}
+
+func TestSanitizeASCIIOnly(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  SanitizeOptions
+		want  string
+	}{
+		{"保留中文", "地块A区.txt", SanitizeOptions{}, "地块A区"},
+		{"仅 ASCII", "地块A区.txt", SanitizeOptions{ASCIIOnly: true}, "A"},
+		{"仅 ASCII 并转写", "地块A区.txt", SanitizeOptions{ASCIIOnly: true, Transliterate: true}, "dikuaiAqu"},
+		{"全角字母归一化后保留", "地块Ａ区", SanitizeOptions{ASCIIOnly: true}, "A"},
+		{"全部为中文", "地块", SanitizeOptions{ASCIIOnly: true}, "unnamed"},
+		{"中文分隔的 ASCII 合并为单个下划线", "a地块b", SanitizeOptions{ASCIIOnly: true}, "a_b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeWithOptions(tt.input, nil, tt.opts); got != tt.want {
+				t.Errorf("SanitizeWithOptions(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	// 两种模式下重复的 "地块A区" 都获得唯一名称
+	for _, ascii := range []bool{false, true} {
+		used := map[string]struct{}{}
+		opts := SanitizeOptions{ASCIIOnly: ascii}
+		first := SanitizeWithOptions("地块A区", used, opts)
+		if second := SanitizeWithOptions("地块A区", used, opts); second != first+"_1" {
+			t.Errorf("ASCIIOnly=%v 重复名称 = %q, want %q", ascii, second, first+"_1")
+		}
+	}
+}
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"耕地", "gengdi"},
+		{"宗地", "zongdi"},
+		{"界址点", "jiezhidian"},
+		{"权属", "quanshu"},
+		{"农村集体所有", "nongcunjitisuoyou"},
+		{"1号地块", "1haodikuai"},
+		{"地块_A-1", "dikuai_A-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := transliterate(tt.input); got != tt.want {
+				t.Errorf("transliterate(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransliterateIdempotent(t *testing.T) {
+	opts := SanitizeOptions{ASCIIOnly: true, Transliterate: true, MaxLength: DefaultMaxNameLength}
+	for _, input := range []string{"parcel_01", "Gengdi", "a-b c", "_select", "耕地", "1号地块"} {
+		t.Run(input, func(t *testing.T) {
+			once := SanitizeWithOptions(input, nil, opts)
+			if twice := SanitizeWithOptions(once, nil, opts); twice != once {
+				t.Errorf("再次处理 %q 得到 %q", once, twice)
+			}
+			if strings.ContainsFunc(input, func(r rune) bool { return r >= utf8.RuneSelf }) {
+				return
+			}
+			if got := transliterate(input); got != input {
+				t.Errorf("transliterate(%q) = %q，ASCII 输入应保持不变", input, got)
+			}
+		})
+	}
+}
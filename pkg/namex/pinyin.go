@@ -0,0 +1,52 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package namex
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+//go:embed pinyin.txt
+var pinyinData string
+
+var (
+	pinyinOnce  sync.Once
+	pinyinTable map[rune]string
+)
+
+// loadPinyin 解析内嵌的拼音表，仅在首次需要转写时执行一次。
+func loadPinyin() {
+	pinyinTable = make(map[rune]string, 6800)
+	for line := range strings.SplitSeq(pinyinData, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		syllable, chars, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		for _, r := range chars {
+			pinyinTable[r] = syllable
+		}
+	}
+}
+
+// transliterate 将拼音表中的汉字替换为不带声调的拼音（如 "耕地" -> "gengdi"），其他字符原样保留。
+// 表中只收录 GB2312 一、二级汉字，其余汉字保持不变，由后续的清理步骤处理。
+func transliterate(s string) string {
+	pinyinOnce.Do(loadPinyin)
+	var b strings.Builder
+	b.Grow(len(s) * 2)
+	for _, r := range s {
+		if py, ok := pinyinTable[r]; ok {
+			b.WriteString(py)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
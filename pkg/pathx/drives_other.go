@@ -0,0 +1,21 @@
+//go:build !windows
+
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package pathx
+
+import "errors"
+
+// GetLogicalDrives 在非 Windows 平台没有逻辑驱动器的概念，始终返回错误。
+func GetLogicalDrives() ([]string, error) {
+	return nil, errors.New("逻辑驱动器仅在 Windows 上可用")
+}
+
+// GetLongPathName 在非 Windows 平台不存在短路径形式，原样返回。
+func GetLongPathName(shortPath string) (string, error) {
+	if shortPath == "" {
+		return "", errors.New("路径不能为空")
+	}
+	return shortPath, nil
+}
@@ -0,0 +1,61 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package pathx
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// GetLogicalDrives 返回当前系统可用的逻辑驱动器列表（仅 Windows）。
+// 失败或空集都会返回明确错误。
+func GetLogicalDrives() ([]string, error) {
+	bufferSize, err := windows.GetLogicalDriveStrings(0, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取驱动器缓冲区大小失败: %w", err)
+	}
+	if bufferSize == 0 {
+		return nil, fmt.Errorf("没有找到逻辑驱动器")
+	}
+	buffer := make([]uint16, bufferSize)
+	actualSize, err := windows.GetLogicalDriveStrings(bufferSize, &buffer[0])
+	if err != nil {
+		return nil, fmt.Errorf("获取驱动器字符串失败: %w", err)
+	}
+	if actualSize == 0 {
+		return nil, fmt.Errorf("获取驱动器字符串返回空结果")
+	}
+	var drives []string
+	for i, start := 0, 0; i < int(actualSize); i++ {
+		if buffer[i] == 0 && i > start {
+			drive := windows.UTF16ToString(buffer[start:i])
+			if drive != "" {
+				drives = append(drives, drive)
+			}
+			start = i + 1
+		}
+	}
+	return drives, nil
+}
+
+// GetLongPathName 返回规范的长路径形式（仅 Windows）。失败时回退原路径。
+func GetLongPathName(shortPath string) (string, error) {
+	if shortPath == "" {
+		return "", fmt.Errorf("路径不能为空")
+	}
+	utf16Path, err := windows.UTF16PtrFromString(shortPath)
+	if err != nil {
+		return "", fmt.Errorf("转换路径为UTF16失败: %w", err)
+	}
+	buffer := make([]uint16, windows.MAX_PATH)
+	length, err := windows.GetLongPathName(utf16Path, &buffer[0], uint32(len(buffer)))
+	if err != nil {
+		return shortPath, nil
+	}
+	if length == 0 {
+		return shortPath, nil
+	}
+	return windows.UTF16ToString(buffer[:length]), nil
+}
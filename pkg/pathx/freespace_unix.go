@@ -0,0 +1,26 @@
+//go:build unix
+
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package pathx
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// FreeSpace 返回 path 所在卷对非特权用户可用的剩余字节数。
+// path 可以是尚不存在的文件或目录，会先回退到最近的已存在父目录再查询。
+func FreeSpace(path string) (uint64, error) {
+	dir, err := existingDir(path)
+	if err != nil {
+		return 0, err
+	}
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return 0, fmt.Errorf("无法查询卷剩余空间 %s: %w", dir, err)
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
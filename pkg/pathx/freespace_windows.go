@@ -0,0 +1,28 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package pathx
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// FreeSpace 返回 path 所在卷对当前用户可用的剩余字节数（考虑磁盘配额）。
+// path 可以是尚不存在的文件或目录，会先回退到最近的已存在父目录再查询。
+func FreeSpace(path string) (uint64, error) {
+	dir, err := existingDir(path)
+	if err != nil {
+		return 0, err
+	}
+	p, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, fmt.Errorf("转换路径为UTF16失败: %w", err)
+	}
+	var free uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &free, nil, nil); err != nil {
+		return 0, fmt.Errorf("无法查询卷剩余空间 %s: %w", dir, err)
+	}
+	return free, nil
+}
@@ -4,15 +4,18 @@ Copyright © 2025 TheMachine <592858548@qq.com>
 package pathx
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
-
-	"golang.org/x/sys/windows"
+	"time"
+	"txt2geo/pkg/logger"
 )
 
 // Equal 判断两个路径是否逻辑相等（绝对化并忽略大小写于 Windows）。
@@ -31,7 +34,7 @@ func Equal(path1, path2 string) (bool, error) {
 	return strings.EqualFold(absPath1, absPath2), nil
 }
 
-// Resolve 对路径做格式与符号链接解析，不改变原语义，增强分隔符与盘符。
+// Resolve 对路径做格式与符号链接解析，不改变原语义；在 Windows 上额外统一分隔符、长路径与盘符。
 func Resolve(p string) (string, error) {
 	p = strings.TrimSpace(p)
 	if p == "" {
@@ -52,36 +55,7 @@ func Resolve(p string) (string, error) {
 		}
 	}
 
-	// 替换分隔符（不破坏特殊前缀）
-	if !strings.HasPrefix(p, `\\?\`) {
-		p = strings.ReplaceAll(p, `/`, `\\`)
-	}
-	// 长路径转换（失败忽略）
-	if long, err := GetLongPathName(p); err == nil && long != "" {
-		p = long
-	}
-	// 盘符规范为大写
-	if len(p) >= 2 && p[1] == ':' {
-		c := p[0]
-		if c >= 'a' && c <= 'z' {
-			p = strings.ToUpper(p[:1]) + p[1:]
-		}
-	}
-	// 去除尾部分隔符（根路径如 C:\ 或 \\?\C:\ 保留）
-	if len(p) > 3 { // 最短可能根: C:\
-		for strings.HasSuffix(p, `\\`) {
-			// 保留如 C:\ 以及 \\?\C:\ 形式
-			if len(p) == 3 && p[1] == ':' { // C:\ 结构（注意后续可能变成 C: ）
-				break
-			}
-			// 处理长路径前缀根，如 \\?\C:\
-			if strings.HasPrefix(p, `\\?\`) && len(p) <= 7 { // \\?\C:\ 长度=7
-				break
-			}
-			p = strings.TrimRight(p, `\\`)
-		}
-	}
-	return p, nil
+	return normalizeOSPath(p), nil
 }
 
 // Exists 判断路径是否存在。不存在返回 (false,nil)。其它错误包装返回。
@@ -142,57 +116,6 @@ func Stem(p string) (string, error) {
 	return stem, nil
 }
 
-// GetLogicalDrives 返回当前系统可用的逻辑驱动器列表（仅 Windows）。
-// 失败或空集都会返回明确错误。
-func GetLogicalDrives() ([]string, error) {
-	bufferSize, err := windows.GetLogicalDriveStrings(0, nil)
-	if err != nil {
-		return nil, fmt.Errorf("获取驱动器缓冲区大小失败: %w", err)
-	}
-	if bufferSize == 0 {
-		return nil, fmt.Errorf("没有找到逻辑驱动器")
-	}
-	buffer := make([]uint16, bufferSize)
-	actualSize, err := windows.GetLogicalDriveStrings(bufferSize, &buffer[0])
-	if err != nil {
-		return nil, fmt.Errorf("获取驱动器字符串失败: %w", err)
-	}
-	if actualSize == 0 {
-		return nil, fmt.Errorf("获取驱动器字符串返回空结果")
-	}
-	var drives []string
-	for i, start := 0, 0; i < int(actualSize); i++ {
-		if buffer[i] == 0 && i > start {
-			drive := windows.UTF16ToString(buffer[start:i])
-			if drive != "" {
-				drives = append(drives, drive)
-			}
-			start = i + 1
-		}
-	}
-	return drives, nil
-}
-
-// GetLongPathName 返回规范的长路径形式（仅 Windows）。失败时回退原路径。
-func GetLongPathName(shortPath string) (string, error) {
-	if shortPath == "" {
-		return "", fmt.Errorf("路径不能为空")
-	}
-	utf16Path, err := windows.UTF16PtrFromString(shortPath)
-	if err != nil {
-		return "", fmt.Errorf("转换路径为UTF16失败: %w", err)
-	}
-	buffer := make([]uint16, windows.MAX_PATH)
-	length, err := windows.GetLongPathName(utf16Path, &buffer[0], uint32(len(buffer)))
-	if err != nil {
-		return shortPath, nil
-	}
-	if length == 0 {
-		return shortPath, nil
-	}
-	return windows.UTF16ToString(buffer[:length]), nil
-}
-
 // Dirx 规范化路径，并在其指向文件或潜在文件路径时返回父目录路径。
 // 如果是目录，返回本身
 func Dirx(p string) (string, error) {
@@ -242,6 +165,30 @@ func ReadFile(path string) ([]byte, string, error) {
 //
 // 返回绝对规范化后的文件列表（稳定排序）。
 func WalkDir(root string, maxDepth int, sortResult bool, extensions []string) ([]string, error) {
+	return WalkDirWithOptions(root, WalkOptions{
+		MaxDepth:   maxDepth,
+		Sort:       sortResult,
+		Extensions: extensions,
+	})
+}
+
+// WalkOptions 控制 WalkDirWithOptions 的遍历行为。
+type WalkOptions struct {
+	MaxDepth   int      // 与 WalkDir 的 maxDepth 含义一致
+	Sort       bool     // 与 WalkDir 的 sortResult 含义一致
+	Extensions []string // 与 WalkDir 的 extensions 含义一致
+	// Excludes 排除的 glob 模式（filepath.Match 语法），在扩展名过滤之前生效：
+	// 不含路径分隔符的模式匹配基础名称（如 "backup"、"*_old.txt"），含分隔符的模式匹配相对 root 的路径（如 "2023/tmp"）。
+	// 匹配的目录整体跳过，不再深入遍历。
+	Excludes []string
+}
+
+// WalkDirWithOptions 与 WalkDir 相同，但通过 WalkOptions 提供排除模式等额外能力。
+func WalkDirWithOptions(root string, opts WalkOptions) ([]string, error) {
+	maxDepth, sortResult, extensions := opts.MaxDepth, opts.Sort, opts.Extensions
+	if err := ValidatePatterns(opts.Excludes); err != nil {
+		return nil, err
+	}
 	// 规范化根路径
 	nRoot, err := Resolve(root)
 	if err != nil {
@@ -276,6 +223,8 @@ func WalkDir(root string, maxDepth int, sortResult bool, extensions []string) ([
 	}
 	stack := []node{{path: nRoot, depth: 0}}
 	files := make([]string, 0, 128)
+	// 已进入目录的真实路径（EvalSymlinks 解析后），用于打断符号链接/目录联接造成的环
+	visited := make(map[string]struct{}, 32)
 
 	for len(stack) > 0 {
 		current := stack[len(stack)-1]
@@ -283,13 +232,32 @@ func WalkDir(root string, maxDepth int, sortResult bool, extensions []string) ([
 		if maxDepth >= 0 && current.depth > maxDepth {
 			continue
 		}
+		id := dirIdentity(current.path)
+		if _, seen := visited[id]; seen {
+			logger.Log().Debug("跳过已遍历的目录（符号链接环）", "目录", current.path, "真实路径", id)
+			continue
+		}
+		visited[id] = struct{}{}
 		entries, readErr := os.ReadDir(current.path)
 		if readErr != nil {
 			return nil, fmt.Errorf("读取目录失败 %s: %w", current.path, readErr)
 		}
 		for _, entry := range entries {
 			fullPath := filepath.Join(current.path, entry.Name())
-			if entry.IsDir() {
+			if len(opts.Excludes) > 0 {
+				rel, _ := filepath.Rel(nRoot, fullPath)
+				if matchExcludes(opts.Excludes, entry.Name(), rel) {
+					continue // 目录被排除时不再入栈，整棵子树被剪枝
+				}
+			}
+			isDir := entry.IsDir()
+			if entry.Type()&(os.ModeSymlink|os.ModeIrregular) != 0 {
+				// 符号链接或目录联接：跟随到目标判断类型，目标不可访问时按普通文件处理
+				if info, serr := os.Stat(fullPath); serr == nil {
+					isDir = info.IsDir()
+				}
+			}
+			if isDir {
 				if maxDepth < 0 || current.depth < maxDepth {
 					stack = append(stack, node{path: fullPath, depth: current.depth + 1})
 				}
@@ -307,6 +275,47 @@ func WalkDir(root string, maxDepth int, sortResult bool, extensions []string) ([
 	return files, nil
 }
 
+// dirIdentity 返回目录的唯一标识：EvalSymlinks 解析后的真实路径，解析失败时回退为清理后的原路径。
+// 仅在默认不区分大小写的 Windows 与 macOS 上转为小写，避免区分大小写的文件系统中 Data/ 与 data/ 被误判为同一目录。
+func dirIdentity(p string) string {
+	if real, err := filepath.EvalSymlinks(p); err == nil {
+		p = real
+	}
+	p = filepath.Clean(p)
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		p = strings.ToLower(p)
+	}
+	return p
+}
+
+// ValidatePatterns 检查 glob 模式语法是否合法。
+func ValidatePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("无效的排除模式 '%s': %w", p, err)
+		}
+	}
+	return nil
+}
+
+// matchExcludes 判断条目是否被排除：不含分隔符的模式匹配基础名称，否则匹配相对路径（统一使用 / 分隔，大小写不敏感）。
+// 调用前需已通过 ValidatePatterns 校验模式。
+func matchExcludes(patterns []string, name, rel string) bool {
+	name = strings.ToLower(name)
+	rel = strings.ToLower(filepath.ToSlash(rel))
+	for _, p := range patterns {
+		p = strings.ToLower(filepath.ToSlash(p))
+		target := name
+		if strings.Contains(p, "/") {
+			target = rel
+		}
+		if ok, _ := filepath.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // hasAllowedExt 判断文件名是否匹配允许的扩展集合（集合已为小写且含点）。
 func hasAllowedExt(name string, allowed map[string]struct{}) bool {
 	ext := strings.ToLower(filepath.Ext(name))
@@ -317,15 +326,19 @@ func hasAllowedExt(name string, allowed map[string]struct{}) bool {
 // stablePathSort 对路径进行跨平台稳定排序：主键为不区分大小写的值，次键为原值。
 func stablePathSort(paths []string) {
 	sort.Slice(paths, func(i, j int) bool {
-		ai := strings.ToLower(paths[i])
-		aj := strings.ToLower(paths[j])
-		if ai == aj {
-			return paths[i] < paths[j]
-		}
-		return ai < aj
+		return ComparePaths(paths[i], paths[j]) < 0
 	})
 }
 
+// ComparePaths 按 stablePathSort 的规则比较两个路径：先比较不区分大小写的值，相同时比较原值。
+// 返回值与 strings.Compare 一致，可直接用于 slices.SortFunc。
+func ComparePaths(a, b string) int {
+	if c := strings.Compare(strings.ToLower(a), strings.ToLower(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
 func normalizeExts(exts []string) []string {
 	out := make([]string, 0, len(exts))
 	for _, e := range exts {
@@ -343,18 +356,45 @@ func normalizeExts(exts []string) []string {
 
 // CollectFiles 对输入的混合路径（文件或目录）按扩展名与深度规则进行收集。
 // 参数说明：
-//   - inputs: 文件或目录路径切片，可包含相对路径与重复；空元素自动跳过
+//   - inputs: 文件或目录路径切片，可包含相对路径、通配符（* ? [）与重复；空元素自动跳过
 //   - maxDepth: 目录递归最大深度；与 WalkDir 含义一致（-1 不限制；0 仅目录本身文件；1 包含一级子目录...）
 //   - extensions: 需要匹配的扩展名集合（大小写不敏感，支持不带点；空集合表示不过滤全部文件）
 //   - sortResult: 是否对最终结果进行稳定排序（不区分大小写主键 + 原值次键）
 //
 // 返回：满足扩展过滤的绝对规范化文件路径切片（去重）。
 // 行为：
+//   - 含通配符的输入先经 filepath.Glob 展开，匹配结果按普通路径处理；无匹配时与不存在的路径一样被忽略
 //   - 不存在的路径被自动忽略（不报错）
 //   - 单个输入若是目录按目录递归处理；若是文件需扩展匹配（或未启用过滤）才加入
 //   - 解析使用 Resolve，存在性与类型检查使用 Exists / IsDir
 //   - 发生严重错误（例如 Stat 非不存在错误）立即返回
 func CollectFiles(inputs []string, maxDepth int, extensions []string, sortResult bool) ([]string, error) {
+	return CollectFilesWithOptions(inputs, CollectOptions{
+		MaxDepth:   maxDepth,
+		Extensions: extensions,
+		Sort:       sortResult,
+	})
+}
+
+// CollectOptions 控制 CollectFilesWithOptions 的收集行为。
+type CollectOptions struct {
+	MaxDepth   int      // 与 CollectFiles 的 maxDepth 含义一致
+	Extensions []string // 与 CollectFiles 的 extensions 含义一致
+	Sort       bool     // 与 CollectFiles 的 sortResult 含义一致
+	Excludes   []string // 与 WalkOptions.Excludes 含义一致；直接给出的文件仅按基础名称匹配
+	// 文件大小（字节）与修改时间过滤，在扩展名匹配之后生效；零值表示不限制
+	MinSize        int64
+	MaxSize        int64
+	ModifiedAfter  time.Time // 仅保留修改时间晚于（含）该时刻的文件
+	ModifiedBefore time.Time // 仅保留修改时间早于该时刻的文件
+	// SkipMarker 非空时，首行以该标记开头的文件被视为工具自身生成的文件并跳过，
+	// 避免输出与输入共用目录和扩展名时被重复处理。
+	SkipMarker string
+}
+
+// CollectFilesWithOptions 与 CollectFiles 相同，但通过 CollectOptions 提供额外的过滤能力。
+func CollectFilesWithOptions(inputs []string, opts CollectOptions) ([]string, error) {
+	maxDepth, extensions, sortResult := opts.MaxDepth, opts.Extensions, opts.Sort
 	// 规范化扩展集合
 	normExts := normalizeExts(extensions)
 	allowed := make(map[string]struct{}, len(normExts))
@@ -362,15 +402,20 @@ func CollectFiles(inputs []string, maxDepth int, extensions []string, sortResult
 		allowed[e] = struct{}{}
 	}
 	filterEnabled := len(allowed) > 0
+	if err := ValidatePatterns(opts.Excludes); err != nil {
+		return nil, err
+	}
+
+	// 展开通配符
+	expanded, err := expandGlobs(inputs)
+	if err != nil {
+		return nil, err
+	}
 
 	// 使用 map 去重
 	resultSet := make(map[string]struct{}, 256)
 
-	for _, in := range inputs {
-		in = strings.TrimSpace(in)
-		if in == "" {
-			continue
-		}
+	for _, in := range expanded {
 		resolved, err := Resolve(in)
 		if err != nil {
 			return nil, fmt.Errorf("解析路径失败 '%s': %w", in, err)
@@ -388,7 +433,11 @@ func CollectFiles(inputs []string, maxDepth int, extensions []string, sortResult
 		}
 		if isDir {
 			// 目录递归收集；不在此处排序，统一最终排序
-			files, werr := WalkDir(resolved, maxDepth, false, extensions)
+			files, werr := WalkDirWithOptions(resolved, WalkOptions{
+				MaxDepth:   maxDepth,
+				Extensions: extensions,
+				Excludes:   opts.Excludes,
+			})
 			if werr != nil {
 				return nil, werr
 			}
@@ -397,8 +446,11 @@ func CollectFiles(inputs []string, maxDepth int, extensions []string, sortResult
 			}
 			continue
 		}
-		// 单文件路径：扩展过滤
+		// 单文件路径：先排除，再扩展过滤
 		name := filepath.Base(resolved)
+		if matchExcludes(opts.Excludes, name, name) {
+			continue
+		}
 		if !filterEnabled || hasAllowedExt(name, allowed) {
 			resultSet[resolved] = struct{}{}
 		}
@@ -407,6 +459,24 @@ func CollectFiles(inputs []string, maxDepth int, extensions []string, sortResult
 	// 转换为切片
 	out := make([]string, 0, len(resultSet))
 	for p := range resultSet {
+		if opts.hasStatFilter() {
+			info, err := os.Stat(p)
+			if err != nil {
+				return nil, fmt.Errorf("无法获取文件信息 %s: %w", p, err)
+			}
+			if !opts.acceptInfo(info) {
+				continue
+			}
+		}
+		if opts.SkipMarker != "" {
+			marked, err := HasMarker(p, opts.SkipMarker)
+			if err != nil {
+				return nil, err
+			}
+			if marked {
+				continue
+			}
+		}
 		out = append(out, p)
 	}
 	if sortResult {
@@ -414,3 +484,93 @@ func CollectFiles(inputs []string, maxDepth int, extensions []string, sortResult
 	}
 	return out, nil
 }
+
+// expandGlobs 去除空白输入并展开含通配符的输入。
+// 字面路径本身存在时（文件名恰好包含 [ 等字符）按字面处理，不再展开；
+// 通配符无匹配时返回结果中不包含该输入，模式语法错误则返回错误。
+func expandGlobs(inputs []string) ([]string, error) {
+	out := make([]string, 0, len(inputs))
+	for _, in := range inputs {
+		in = strings.TrimSpace(in)
+		if in == "" {
+			continue
+		}
+		if !strings.ContainsAny(in, "*?[") {
+			out = append(out, in)
+			continue
+		}
+		if exists, err := Exists(in); err == nil && exists {
+			out = append(out, in)
+			continue
+		}
+		matches, err := filepath.Glob(in)
+		if err != nil {
+			return nil, fmt.Errorf("无效的通配符模式 '%s': %w", in, err)
+		}
+		out = append(out, matches...)
+	}
+	return out, nil
+}
+
+// hasStatFilter 判断是否启用了大小或修改时间过滤。
+func (o CollectOptions) hasStatFilter() bool {
+	return o.MinSize > 0 || o.MaxSize > 0 || !o.ModifiedAfter.IsZero() || !o.ModifiedBefore.IsZero()
+}
+
+// acceptInfo 判断文件信息是否满足大小与修改时间约束。
+func (o CollectOptions) acceptInfo(info os.FileInfo) bool {
+	size, mod := info.Size(), info.ModTime()
+	switch {
+	case o.MinSize > 0 && size < o.MinSize:
+		return false
+	case o.MaxSize > 0 && size > o.MaxSize:
+		return false
+	case !o.ModifiedAfter.IsZero() && mod.Before(o.ModifiedAfter):
+		return false
+	case !o.ModifiedBefore.IsZero() && !mod.Before(o.ModifiedBefore):
+		return false
+	}
+	return true
+}
+
+// HasMarker 判断文件首行（忽略 UTF-8 BOM 与前导空白）是否以 marker 开头。
+// 仅读取文件开头的少量字节，空文件返回 false。
+func HasMarker(path, marker string) (bool, error) {
+	if marker == "" {
+		return false, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("无法打开文件 %s: %w", path, err)
+	}
+	defer f.Close()
+
+	// 文件短于缓冲区时 ReadFull 返回 ErrUnexpectedEOF，按已读内容判断即可
+	head := make([]byte, len(marker)+64)
+	n, _ := io.ReadFull(f, head)
+	head = bytes.TrimPrefix(head[:n], []byte{0xEF, 0xBB, 0xBF})
+	head = bytes.TrimLeft(head, " \t\r\n")
+	return bytes.HasPrefix(head, []byte(marker)), nil
+}
+
+// existingDir 返回 path 自身（若为已存在目录）或其最近的已存在祖先目录，用于定位所在卷。
+func existingDir(path string) (string, error) {
+	abs, err := filepath.Abs(strings.TrimSpace(path))
+	if err != nil {
+		return "", fmt.Errorf("无法解析路径 '%s': %w", path, err)
+	}
+	for dir := abs; ; {
+		if info, err := os.Stat(dir); err == nil {
+			if info.IsDir() {
+				return dir, nil
+			}
+		} else if !os.IsNotExist(err) {
+			return "", fmt.Errorf("检查路径时出错: %w", err)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("找不到路径 '%s' 所在的卷", path)
+		}
+		dir = parent
+	}
+}
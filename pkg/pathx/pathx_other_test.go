@@ -0,0 +1,53 @@
+//go:build !windows
+
+package pathx
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestResolveKeepsSlashes(t *testing.T) {
+	dir := t.TempDir()
+	got, err := Resolve(dir + "/sub/../x.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, `\`) {
+		t.Errorf("Resolve 引入了反斜杠: %q", got)
+	}
+	if !strings.HasSuffix(got, "/x.txt") || !filepath.IsAbs(got) {
+		t.Errorf("Resolve = %q，应为以 /x.txt 结尾的绝对路径", got)
+	}
+}
+
+func TestCollectFilesNonWindows(t *testing.T) {
+	dir := t.TempDir()
+	a := touch(t, dir, "a.txt", "a")
+	b := touch(t, dir, "sub/b.txt", "b")
+	c := touch(t, dir, "glob/c.txt", "c")
+	touch(t, dir, "glob/c.csv", "c")
+
+	tests := []struct {
+		name   string
+		inputs []string
+		want   []string
+	}{
+		{"字面文件", []string{filepath.Join(dir, "a.txt")}, []string{a}},
+		{"目录", []string{filepath.Join(dir, "sub")}, []string{b}},
+		{"通配符", []string{filepath.Join(dir, "glob", "*.txt")}, []string{c}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CollectFiles(tt.inputs, -1, []string{"txt"}, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("CollectFiles(%v) = %v, want %v", tt.inputs, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package pathx
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// touch 在 dir 下创建相对路径 rel 的文件（自动创建父目录），返回其 Resolve 后的路径。
+func touch(t *testing.T, dir, rel, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := Resolve(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolved
+}
+
+func TestWalkDirSymlinkCycle(t *testing.T) {
+	dir := t.TempDir()
+	want := touch(t, dir, "a/x.txt", "x")
+	// a/loop -> a：不打断环时遍历永不结束
+	if err := os.Symlink(filepath.Join(dir, "a"), filepath.Join(dir, "a", "loop")); err != nil {
+		t.Skipf("当前环境不支持符号链接: %v", err)
+	}
+	got, err := WalkDir(dir, -1, true, []string{"txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []string{want}) {
+		t.Errorf("WalkDir = %v, want [%s]", got, want)
+	}
+}
+
+func TestWalkDirCaseSensitiveSiblings(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("默认文件系统不区分大小写")
+	}
+	dir := t.TempDir()
+	upper := touch(t, dir, "Data/a.txt", "a")
+	if _, err := os.Stat(filepath.Join(dir, "data")); err == nil {
+		t.Skip("临时目录所在文件系统不区分大小写")
+	}
+	lower := touch(t, dir, "data/b.txt", "b")
+	got, err := WalkDir(dir, -1, true, []string{"txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{upper, lower}; !slices.Equal(got, want) {
+		t.Errorf("WalkDir = %v, want %v", got, want)
+	}
+}
+
+func TestCollectFilesMixedDirsAndGlobs(t *testing.T) {
+	dir := t.TempDir()
+	a := touch(t, dir, "dirA/a.txt", "a")
+	a2 := touch(t, dir, "dirA/nested/a2.TXT", "a")
+	b1 := touch(t, dir, "globbed/b1.txt", "b")
+	touch(t, dir, "globbed/b2.csv", "b")
+	touch(t, dir, "globbed/other.txt", "o")
+	touch(t, dir, "dirA/skip.csv", "s")
+
+	inputs := []string{
+		filepath.Join(dir, "dirA"),
+		filepath.Join(dir, "globbed", "b*"),
+		filepath.Join(dir, "dirA", "*.txt"), // 与目录输入重复，需去重
+		filepath.Join(dir, "nomatch", "*.txt"),
+		filepath.Join(dir, "missing.txt"),
+		"  ",
+	}
+	got, err := CollectFiles(inputs, -1, []string{"txt"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{a, a2, b1}; !slices.Equal(got, want) {
+		t.Errorf("CollectFiles = %v, want %v", got, want)
+	}
+
+	if _, err := CollectFiles([]string{filepath.Join(dir, "[")}, -1, nil, true); err == nil {
+		t.Error("非法通配符应返回错误")
+	}
+}
+
+func TestCollectFilesExcludes(t *testing.T) {
+	dir := t.TempDir()
+	keep := touch(t, dir, "keep.txt", "k")
+	old := touch(t, dir, "plan_old.txt", "o")
+	backup := touch(t, dir, "backup/in_backup.txt", "b")
+	nested := touch(t, dir, "2024/tmp/x.txt", "x")
+	y := touch(t, dir, "2023/tmp/y.txt", "y")
+
+	tests := []struct {
+		name     string
+		inputs   []string
+		excludes []string
+		want     []string
+	}{
+		{"文件级", []string{dir}, []string{"*_old.txt"}, []string{y, nested, backup, keep}},
+		{"目录级", []string{dir}, []string{"backup", "tmp"}, []string{keep, old}},
+		{"相对路径", []string{dir}, []string{"2023/tmp", "backup", "*_OLD.txt"}, []string{nested, keep}},
+		{"直接给出的文件", []string{filepath.Join(dir, "plan_old.txt"), filepath.Join(dir, "keep.txt")}, []string{"*_old.txt"}, []string{keep}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CollectFilesWithOptions(tt.inputs, CollectOptions{MaxDepth: -1, Extensions: []string{"txt"}, Sort: true, Excludes: tt.excludes})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Excludes=%v: got %v, want %v", tt.excludes, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := WalkDirWithOptions(dir, WalkOptions{MaxDepth: -1, Excludes: []string{"["}}); err == nil {
+		t.Error("非法排除模式应返回错误")
+	}
+}
+
+func TestCollectFilesSizeAndMtime(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	empty := touch(t, dir, "empty.txt", "")
+	small := touch(t, dir, "small.txt", "0123456789")
+	large := touch(t, dir, "large.txt", strings.Repeat("x", 1000))
+	for path, mod := range map[string]time.Time{
+		empty: base.AddDate(0, 0, -30),
+		small: base.AddDate(0, 0, -1),
+		large: base,
+	} {
+		if err := os.Chtimes(path, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts CollectOptions
+		want []string
+	}{
+		{"无限制", CollectOptions{}, []string{empty, large, small}},
+		{"MinSize", CollectOptions{MinSize: 1}, []string{large, small}},
+		{"MaxSize", CollectOptions{MaxSize: 10}, []string{empty, small}},
+		{"ModifiedAfter 含边界", CollectOptions{ModifiedAfter: base.AddDate(0, 0, -1)}, []string{large, small}},
+		{"ModifiedBefore 不含边界", CollectOptions{ModifiedBefore: base}, []string{empty, small}},
+		{"组合", CollectOptions{MinSize: 1, ModifiedBefore: base}, []string{small}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.MaxDepth, opts.Extensions, opts.Sort = -1, []string{"txt"}, true
+			got, err := CollectFilesWithOptions([]string{dir}, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFreeSpaceNonexistentPath(t *testing.T) {
+	dir := t.TempDir()
+	want, err := FreeSpace(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want == 0 {
+		t.Skip("临时目录所在卷无剩余空间")
+	}
+	// 尚不存在的多级路径回退到最近的已存在父目录，与其位于同一卷
+	got, err := FreeSpace(filepath.Join(dir, "no", "such", "out.gpkg"))
+	if err != nil {
+		t.Fatalf("FreeSpace(不存在路径) 出错: %v", err)
+	}
+	if got == 0 {
+		t.Errorf("FreeSpace(不存在路径) = 0，应回退到 %s 所在卷", dir)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package pathx
+
+// normalizeOSPath 在非 Windows 平台无需额外处理：filepath.Abs 已给出以 / 分隔的清理后路径。
+func normalizeOSPath(p string) string {
+	return p
+}
@@ -0,0 +1,33 @@
+/*
+Copyright © 2025 TheMachine <592858548@qq.com>
+*/
+package pathx
+
+import "strings"
+
+// normalizeOSPath 统一 Windows 路径形式：反斜杠分隔符、长路径、大写盘符，并去除多余的尾部分隔符。
+func normalizeOSPath(p string) string {
+	// 替换分隔符（不破坏特殊前缀）
+	if !strings.HasPrefix(p, `\\?\`) {
+		p = strings.ReplaceAll(p, `/`, `\`)
+	}
+	// 长路径转换（失败忽略）
+	if long, err := GetLongPathName(p); err == nil && long != "" {
+		p = long
+	}
+	// 盘符规范为大写
+	if len(p) >= 2 && p[1] == ':' {
+		c := p[0]
+		if c >= 'a' && c <= 'z' {
+			p = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	// 去除尾部分隔符（根路径如 C:\ 或 \\?\C:\ 保留）
+	for len(p) > 3 && strings.HasSuffix(p, `\`) {
+		if strings.HasPrefix(p, `\\?\`) && len(p) <= 7 { // \\?\C:\ 长度=7
+			break
+		}
+		p = p[:len(p)-1]
+	}
+	return p
+}